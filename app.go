@@ -2,13 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 
+	"markviewpro/internal/analysis"
 	"markviewpro/internal/exporter"
 	"markviewpro/internal/filemanager"
 	"markviewpro/internal/foldermanager"
 	"markviewpro/internal/imagemanager"
+	"markviewpro/internal/importer"
+	"markviewpro/internal/linkcheck"
+	"markviewpro/internal/localfiles"
 	"markviewpro/internal/markdown"
 	"markviewpro/internal/settings"
+	"markviewpro/internal/trust"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -21,17 +33,31 @@ type App struct {
 	imageManager  *imagemanager.ImageManager
 	settings      *settings.Settings
 	exporter      *exporter.Exporter
+	analyzer      *analysis.Analyzer
+	linter        *markdown.Linter
+	linkChecker   *linkcheck.Checker
+	trust         *trust.Store
+	localFiles    *localfiles.Handler
 	initialFile   string
+
+	exportMu     sync.Mutex
+	cancelExport context.CancelFunc
 }
 
 func NewApp() *App {
+	renderer := markdown.NewRenderer()
 	return &App{
-		renderer:      markdown.NewRenderer(),
+		renderer:      renderer,
 		fileManager:   filemanager.NewFileManager(),
 		folderManager: foldermanager.NewFolderManager(),
 		imageManager:  imagemanager.NewImageManager(),
 		settings:      settings.NewSettings(),
 		exporter:      exporter.NewExporter(),
+		analyzer:      analysis.NewAnalyzer(renderer),
+		linter:        markdown.NewLinter(renderer),
+		linkChecker:   linkcheck.NewChecker(renderer),
+		trust:         trust.NewStore(),
+		localFiles:    localfiles.NewHandler(),
 	}
 }
 
@@ -99,7 +125,7 @@ func (a *App) OpenFile() (map[string]string, error) {
 }
 
 func (a *App) SaveFile(path, content string) error {
-	return a.fileManager.SaveFile(path, content)
+	return a.fileManager.SaveFile(path, a.formatOnSave(path, content))
 }
 
 func (a *App) SaveFileAs(content string) (string, error) {
@@ -117,7 +143,7 @@ func (a *App) SaveFileAs(content string) (string, error) {
 		return "", nil
 	}
 
-	err = a.fileManager.SaveFile(file, content)
+	err = a.fileManager.SaveFile(file, a.formatOnSave(file, content))
 	if err != nil {
 		return "", err
 	}
@@ -125,12 +151,91 @@ func (a *App) SaveFileAs(content string) (string, error) {
 	return file, nil
 }
 
+// formatOnSave formats content when the setting is enabled and tells the
+// frontend, so the editor can pick up the text that was written to disk.
+func (a *App) formatOnSave(path, content string) string {
+	if !a.settings.Get().FormatOnSave {
+		return content
+	}
+	result := a.renderer.Format(content, a.formatOptions())
+	if result.Changed && a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "file:formatted", map[string]string{
+			"path":    path,
+			"content": result.Text,
+		})
+	}
+	return result.Text
+}
+
+func (a *App) formatOptions() markdown.FormatOptions {
+	s := a.settings.Get()
+	opts := markdown.DefaultFormatOptions()
+	opts.ProseWrap = s.FormatProseWrap
+	opts.LineWidth = s.FormatLineWidth
+	return opts
+}
+
+// FormatDocument returns content in the normalized Markdown style along with
+// the edits that produce it.
+func (a *App) FormatDocument(content string) markdown.FormatResult {
+	return a.renderer.Format(content, a.formatOptions())
+}
+
 func (a *App) GetCurrentFilePath() string {
 	return a.fileManager.GetCurrentFilePath()
 }
 
-func (a *App) RenderMarkdown(content string) (string, error) {
-	return a.renderer.Render(content)
+// RenderMarkdown renders content for the preview of the document at path.
+// Relative images are rewritten to be served by the local file handler;
+// links are left alone so that they cannot open local pages in the app.
+func (a *App) RenderMarkdown(content, path string) (string, error) {
+	baseDir := ""
+	if path != "" {
+		baseDir = filepath.Dir(path)
+	}
+
+	html, err := a.renderer.RenderRewritingImages(content, func(dest string) string {
+		return localfiles.Rewrite(baseDir, dest)
+	})
+	if err != nil {
+		return "", err
+	}
+	if !a.trust.IsTrusted(path) {
+		html = markdown.Sanitize(html)
+	}
+	return html, nil
+}
+
+// SetActiveDocument tells the local file handler which document the preview
+// shows, so that its relative images resolve.
+func (a *App) SetActiveDocument(path string) {
+	a.localFiles.SetDocument(path)
+}
+
+// render sanitizes raw HTML unless path is inside a trusted folder.
+func (a *App) render(content, path string) (string, error) {
+	if a.trust.IsTrusted(path) {
+		return a.renderer.Render(content)
+	}
+	return a.renderer.RenderSafe(content)
+}
+
+func (a *App) IsTrusted(path string) bool {
+	return a.trust.IsTrusted(path)
+}
+
+// TrustFolder allows documents in folder, and its subfolders, to use raw
+// HTML without sanitization.
+func (a *App) TrustFolder(folder string) error {
+	return a.trust.Trust(folder)
+}
+
+func (a *App) RevokeTrust(folder string) error {
+	return a.trust.Revoke(folder)
+}
+
+func (a *App) GetTrustedFolders() []string {
+	return a.trust.Folders()
 }
 
 func (a *App) GetTableOfContents(content string) []markdown.TOCItem {
@@ -138,7 +243,47 @@ func (a *App) GetTableOfContents(content string) []markdown.TOCItem {
 }
 
 func (a *App) GetWordCount(content string) markdown.Stats {
-	return a.renderer.GetStats(content)
+	return a.renderer.GetStatsWithOptions(content, a.statsOptions())
+}
+
+// GetSelectionStats returns statistics for the text currently selected in the editor.
+func (a *App) GetSelectionStats(selection string) markdown.Stats {
+	return a.renderer.GetStatsWithOptions(selection, a.statsOptions())
+}
+
+func (a *App) statsOptions() markdown.StatsOptions {
+	return markdown.StatsOptions{
+		IncludeCode: a.settings.Get().StatsIncludeCode,
+	}
+}
+
+func (a *App) AnalyzeReadability(content string) analysis.Report {
+	return a.analyzer.Analyze(content)
+}
+
+// LintDocument checks content against the lint rules, using the
+// .markdownlint.json found next to the document or in a parent folder.
+func (a *App) LintDocument(content, path string) ([]markdown.Diagnostic, error) {
+	cfg, _, err := markdown.FindLintConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.linter.Lint(content, cfg), nil
+}
+
+func (a *App) CheckLinks(content, path string) linkcheck.DocumentReport {
+	return a.linkChecker.CheckDocument(a.ctx, path, content, a.linkCheckOptions())
+}
+
+func (a *App) CheckFolderLinks(folder string) ([]linkcheck.DocumentReport, error) {
+	return a.linkChecker.CheckFolder(a.ctx, folder, a.linkCheckOptions())
+}
+
+func (a *App) linkCheckOptions() linkcheck.Options {
+	return linkcheck.Options{
+		Root:          a.folderManager.GetCurrentPath(),
+		CheckExternal: a.settings.Get().CheckExternalLinks,
+	}
 }
 
 func (a *App) SearchInDocument(content, query string) []markdown.SearchResult {
@@ -195,7 +340,9 @@ func (a *App) UpdateSettings(s settings.UserSettings) error {
 	return a.settings.Update(s)
 }
 
-func (a *App) ExportToHTML(content string) error {
+// ExportToHTML writes a standalone HTML file. An empty template uses the
+// document's template: front matter key, then the one in the settings.
+func (a *App) ExportToHTML(content, path, theme, template string) error {
 	// Show save dialog for HTML
 	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
 		Title:           "Export to HTML",
@@ -211,14 +358,54 @@ func (a *App) ExportToHTML(content string) error {
 		return nil
 	}
 
-	html, err := a.renderer.Render(content)
+	fm, body := markdown.SplitFrontMatter([]byte(content))
+	html, err := a.render(string(body), path)
+	if err != nil {
+		return err
+	}
+	return a.runExport("html", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts := withFrontMatter(a.exportOptions(path, theme), fm, template)
+		opts.Progress = progress
+		return a.exporter.ToHTML(ctx, html, outputPath, opts)
+	})
+}
+
+// ExportToSelfContainedHTML writes a single HTML file with local images and
+// stylesheets embedded, and reports what was embedded.
+func (a *App) ExportToSelfContainedHTML(content, path, theme, template string) (*exporter.SizeReport, error) {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to Single-File HTML",
+		DefaultFilename: "export.html",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HTML Files", Pattern: "*.html"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if outputPath == "" {
+		return nil, nil
+	}
+
+	fm, body := markdown.SplitFrontMatter([]byte(content))
+	html, err := a.render(string(body), path)
 	if err != nil {
+		return nil, err
+	}
+	var report exporter.SizeReport
+	err = a.runExport("html-single", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts := withFrontMatter(a.exportOptions(path, theme), fm, template)
+		opts.Progress = progress
+		report, err = a.exporter.ToSelfContainedHTML(ctx, html, outputPath, opts)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return a.exporter.ToHTML(html, outputPath)
+	return &report, nil
 }
 
-func (a *App) ExportToPDF(filePath string) error {
+func (a *App) ExportToPDF(filePath, theme, template string) error {
 	var content string
 	var err error
 
@@ -232,7 +419,8 @@ func (a *App) ExportToPDF(filePath string) error {
 		return nil
 	}
 
-	html, err := a.renderer.Render(content)
+	fm, body := markdown.SplitFrontMatter([]byte(content))
+	html, err := a.render(string(body), filePath)
 	if err != nil {
 		return err
 	}
@@ -252,11 +440,12 @@ func (a *App) ExportToPDF(filePath string) error {
 		return nil
 	}
 
-	return a.exporter.ToPDF(html, outputPath)
+	return a.toPDF(string(body), html, outputPath, withFrontMatter(a.exportOptions(filePath, theme), fm, template))
 }
 
-func (a *App) ExportContentToPDF(content string) error {
-	html, err := a.renderer.Render(content)
+func (a *App) ExportContentToPDF(content, theme, template string) error {
+	fm, body := markdown.SplitFrontMatter([]byte(content))
+	html, err := a.renderer.RenderSafe(string(body))
 	if err != nil {
 		return err
 	}
@@ -276,7 +465,689 @@ func (a *App) ExportContentToPDF(content string) error {
 		return nil
 	}
 
-	return a.exporter.ToPDF(html, outputPath)
+	return a.toPDF(string(body), html, outputPath, withFrontMatter(a.exportOptions("", theme), fm, template))
+}
+
+// ExportToDOCX writes the document as a Word file, styled after the
+// reference document in the settings when one is set.
+func (a *App) ExportToDOCX(content, path string) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to Word",
+		DefaultFilename: "export.docx",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Word Documents", Pattern: "*.docx"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+
+	return a.runExport("docx", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		fm, body := markdown.SplitFrontMatter([]byte(content))
+		opts := withFrontMatter(a.exportOptions(path, ""), fm, "")
+		opts.Progress = progress
+		return a.exporter.ToDOCX(ctx, body, a.renderer.Parse(body), outputPath, opts)
+	})
+}
+
+// ExportToLaTeX writes the document as a LaTeX article. Title, author and
+// date come from its front matter.
+func (a *App) ExportToLaTeX(content, path string) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to LaTeX",
+		DefaultFilename: "export.tex",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "LaTeX Files", Pattern: "*.tex"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+
+	return a.runExport("latex", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts := a.exportOptions(path, "")
+		opts.Progress = progress
+		fm, body := markdown.SplitFrontMatter([]byte(content))
+		opts.Title = fm.Title
+		opts.Authors = fm.Authors
+		opts.Date = fm.Date
+		return a.exporter.ToLaTeX(ctx, body, a.renderer.Parse(body), outputPath, opts)
+	})
+}
+
+// ExportToPlainText writes the document as wrapped plain text, with links
+// and footnotes as numbered notes.
+func (a *App) ExportToPlainText(content, path string) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to Plain Text",
+		DefaultFilename: "export.txt",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Text Files", Pattern: "*.txt"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+
+	return a.runExport("text", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts := a.exportOptions(path, "")
+		opts.Progress = progress
+		_, body := markdown.SplitFrontMatter([]byte(content))
+		return a.exporter.ToPlainText(ctx, body, a.renderer.Parse(body), outputPath, opts)
+	})
+}
+
+// ConvertToPlainText returns the document as plain text for the clipboard.
+func (a *App) ConvertToPlainText(content string) string {
+	_, body := markdown.SplitFrontMatter([]byte(content))
+	return a.exporter.PlainText(body, a.renderer.Parse(body), a.exportOptions("", ""))
+}
+
+// ExportToCommonMark writes the document as portable CommonMark, rewriting
+// the extensions the settings say the target lacks.
+func (a *App) ExportToCommonMark(content, path string) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to CommonMark",
+		DefaultFilename: "export.md",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Markdown Files", Pattern: "*.md"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+	if path != "" && filepath.Clean(outputPath) == filepath.Clean(path) {
+		return errors.New("choose a different file than the document itself")
+	}
+
+	return a.runExport("commonmark", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		progress("Converting document", 10)
+		return os.WriteFile(outputPath, []byte(a.ConvertToCommonMark(content)), 0644)
+	})
+}
+
+// ConvertToCommonMark returns the document as portable CommonMark for the
+// clipboard.
+func (a *App) ConvertToCommonMark(content string) string {
+	_, body := markdown.SplitFrontMatter([]byte(content))
+	return a.renderer.CommonMark(string(body), a.commonMarkOptions())
+}
+
+func (a *App) commonMarkOptions() markdown.CommonMarkOptions {
+	s := a.settings.Get()
+	return markdown.CommonMarkOptions{
+		Tables:    s.CommonMarkTables,
+		TaskLists: s.CommonMarkTaskLists,
+		Footnotes: s.CommonMarkFootnotes,
+	}
+}
+
+// GetSlides splits a document into slides for presenter mode.
+func (a *App) GetSlides(content string) []markdown.Slide {
+	_, slides := markdown.SplitSlides(content)
+	return slides
+}
+
+// ExportSlidesToHTML writes the document as a self-contained slide deck.
+func (a *App) ExportSlidesToHTML(content, path string) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Slides to HTML",
+		DefaultFilename: "slides.html",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HTML Files", Pattern: "*.html"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+
+	slides, opts, err := a.deck(content, path)
+	if err != nil {
+		return err
+	}
+	return a.runExport("slides-html", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts.Progress = progress
+		_, err := a.exporter.ToSlidesHTML(ctx, slides, outputPath, opts)
+		return err
+	})
+}
+
+// ExportSlidesToPDF prints the document's slides one per page.
+func (a *App) ExportSlidesToPDF(content, path string) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Slides to PDF",
+		DefaultFilename: "slides.pdf",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "PDF Files", Pattern: "*.pdf"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+
+	slides, opts, err := a.deck(content, path)
+	if err != nil {
+		return err
+	}
+	return a.runExport("slides-pdf", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts.Progress = progress
+		err := a.exporter.ToSlidesPDF(ctx, slides, outputPath, opts)
+		if errors.Is(err, exporter.ErrChromeNotFound) {
+			return errors.New("slide PDFs need Chrome or Chromium to be installed")
+		}
+		return err
+	})
+}
+
+// deck renders a document's slides and notes. A theme named in the front
+// matter is used when it is one of the export themes.
+func (a *App) deck(content, path string) ([]exporter.DeckSlide, exporter.Options, error) {
+	fm, slides := markdown.SplitSlides(content)
+	theme := ""
+	for _, t := range a.exporter.Themes() {
+		if t.Name == fm.Fields["theme"] {
+			theme = t.Name
+		}
+	}
+	opts := a.exportOptions(path, theme)
+	if fm.Title != "" {
+		opts.Title = fm.Title
+	}
+
+	deck := make([]exporter.DeckSlide, 0, len(slides))
+	for _, s := range slides {
+		html, err := a.render(s.Markdown, path)
+		if err != nil {
+			return nil, opts, err
+		}
+		notes := ""
+		if s.Notes != "" {
+			if notes, err = a.render(s.Notes, path); err != nil {
+				return nil, opts, err
+			}
+		}
+		deck = append(deck, exporter.DeckSlide{HTML: html, Notes: notes, Settings: s.Settings})
+	}
+	return deck, opts, nil
+}
+
+// ExportToEPUB packages the document as an e-book. Title and authors come
+// from its front matter.
+func (a *App) ExportToEPUB(content, path string) error {
+	title := "export"
+	if path != "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	fm, body := markdown.SplitFrontMatter([]byte(content))
+	if fm.Title != "" {
+		title = fm.Title
+	}
+	html, err := a.render(string(body), path)
+	if err != nil {
+		return err
+	}
+	return a.exportEPUB(title, fm.Authors, []exporter.EPUBDocument{{Path: path, Title: title, HTML: html}})
+}
+
+// ExportFolderToEPUB packages every Markdown file in folder as one book,
+// in path order, so numbered names such as 01-intro.md set the chapter
+// order. An empty folder means the open workspace.
+func (a *App) ExportFolderToEPUB(folder string) error {
+	if folder == "" {
+		folder = a.folderManager.GetCurrentPath()
+	}
+	if folder == "" {
+		return errors.New("no folder is open")
+	}
+	files, err := foldermanager.ListMarkdownFiles(folder)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("the folder has no Markdown files")
+	}
+
+	docs := make([]exporter.EPUBDocument, 0, len(files))
+	var authors []string
+	for _, file := range files {
+		content, err := a.folderManager.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fm, body := markdown.SplitFrontMatter([]byte(content))
+		html, err := a.render(string(body), file)
+		if err != nil {
+			return err
+		}
+		title := fm.Title
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		for _, author := range fm.Authors {
+			if !slices.Contains(authors, author) {
+				authors = append(authors, author)
+			}
+		}
+		docs = append(docs, exporter.EPUBDocument{Path: file, Title: title, HTML: html})
+	}
+	return a.exportEPUB(filepath.Base(folder), authors, docs)
+}
+
+// ExportFolderToSite publishes every Markdown file in folder as a static
+// HTML site in a chosen directory. An empty folder means the open
+// workspace.
+func (a *App) ExportFolderToSite(folder string) error {
+	if folder == "" {
+		folder = a.folderManager.GetCurrentPath()
+	}
+	if folder == "" {
+		return errors.New("no folder is open")
+	}
+	files, err := foldermanager.ListMarkdownFiles(folder)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("the folder has no Markdown files")
+	}
+
+	outputDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Export Site To",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return err
+	}
+	if outputDir == "" {
+		return nil
+	}
+
+	return a.runExport("site", outputDir, func(ctx context.Context, progress exporter.Progress) error {
+		pages := make([]exporter.SitePage, 0, len(files))
+		for _, file := range files {
+			content, err := a.folderManager.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			fm, body := markdown.SplitFrontMatter([]byte(content))
+			html, err := a.render(string(body), file)
+			if err != nil {
+				return err
+			}
+			title := fm.Title
+			if title == "" {
+				title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			}
+			pages = append(pages, exporter.SitePage{Path: file, Title: title, HTML: html})
+		}
+		opts := a.exportOptions("", "")
+		opts.Title = filepath.Base(folder)
+		opts.Progress = progress
+		return a.exporter.ToSite(ctx, folder, pages, outputDir, opts)
+	})
+}
+
+// ChooseSiteTemplate asks for an HTML layout template for static sites.
+func (a *App) ChooseSiteTemplate() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Choose Site Template",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HTML Templates", Pattern: "*.html;*.tmpl"},
+		},
+	})
+}
+
+// BatchExportRequest picks the documents of a batch export: Paths when
+// given, otherwise every Markdown file under Folder (the open workspace
+// when empty) that matches an Include pattern and no Exclude pattern.
+type BatchExportRequest struct {
+	Paths   []string `json:"paths"`
+	Folder  string   `json:"folder"`
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	// Format is one of exporter.BatchFormats.
+	Format string `json:"format"`
+	// OutputDir is asked for when empty.
+	OutputDir string `json:"outputDir"`
+}
+
+// ExportBatch converts many documents at once, mirroring their folders
+// under the output directory. Each file is reported with an export:file
+// event as it finishes; one failing does not stop the others.
+func (a *App) ExportBatch(req BatchExportRequest) ([]exporter.BatchResult, error) {
+	root := ""
+	sources := req.Paths
+	if len(sources) == 0 {
+		root = req.Folder
+		if root == "" {
+			root = a.folderManager.GetCurrentPath()
+		}
+		if root == "" {
+			return nil, errors.New("no folder is open")
+		}
+		files, err := foldermanager.ListMarkdownFiles(root)
+		if err != nil {
+			return nil, err
+		}
+		if sources, err = foldermanager.FilterFiles(root, files, req.Include, req.Exclude); err != nil {
+			return nil, err
+		}
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no Markdown files match")
+	}
+	if _, err := exporter.BatchPlan(root, sources, req.Format, ""); err != nil {
+		return nil, err
+	}
+
+	outputDir := req.OutputDir
+	if outputDir == "" {
+		var err error
+		outputDir, err = runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:                "Export Documents To",
+			CanCreateDirectories: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if outputDir == "" {
+			return nil, nil
+		}
+	}
+	files, err := exporter.BatchPlan(root, sources, req.Format, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []exporter.BatchResult
+	err = a.runExport("batch", outputDir, func(ctx context.Context, progress exporter.Progress) error {
+		var mu sync.Mutex
+		finished, failed := 0, 0
+		progress(fmt.Sprintf("Exporting %d files", len(files)), 0)
+		results = exporter.RunBatch(ctx, files, func(ctx context.Context, file exporter.BatchFile) error {
+			return a.exportFile(ctx, req.Format, file)
+		}, func(result exporter.BatchResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			finished++
+			if result.Error != "" {
+				failed++
+			}
+			runtime.EventsEmit(a.ctx, "export:file", result)
+			progress(fmt.Sprintf("Exported %d of %d files", finished, len(files)), finished*100/len(files))
+		})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d files failed", failed, len(files))
+		}
+		return nil
+	})
+	if err != nil && len(results) == 0 {
+		return nil, err
+	}
+	return results, nil
+}
+
+// exportFile converts one document of a batch the way the single-document
+// export of its format does, without dialogs.
+func (a *App) exportFile(ctx context.Context, format string, file exporter.BatchFile) error {
+	content, err := a.folderManager.ReadFile(file.Source)
+	if err != nil {
+		return err
+	}
+	fm, body := markdown.SplitFrontMatter([]byte(content))
+	opts := withFrontMatter(a.exportOptions(file.Source, ""), fm, "")
+
+	switch format {
+	case "docx":
+		return a.exporter.ToDOCX(ctx, body, a.renderer.Parse(body), file.Output, opts)
+	case "latex":
+		return a.exporter.ToLaTeX(ctx, body, a.renderer.Parse(body), file.Output, opts)
+	case "text":
+		return a.exporter.ToPlainText(ctx, body, a.renderer.Parse(body), file.Output, opts)
+	case "commonmark":
+		return os.WriteFile(file.Output, []byte(a.renderer.CommonMark(string(body), a.commonMarkOptions())), 0644)
+	case "epub":
+		html, err := a.render(string(body), file.Source)
+		if err != nil {
+			return err
+		}
+		epubOpts := a.exportOptions("", "")
+		epubOpts.Title = opts.Title
+		epubOpts.Authors = opts.Authors
+		return a.exporter.ToEPUB(ctx, []exporter.EPUBDocument{{Path: file.Source, Title: opts.Title, HTML: html}}, file.Output, epubOpts)
+	}
+
+	html, err := a.render(string(body), file.Source)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "html":
+		return a.exporter.ToHTML(ctx, html, file.Output, opts)
+	case "html-single":
+		_, err := a.exporter.ToSelfContainedHTML(ctx, html, file.Output, opts)
+		return err
+	case "pdf":
+		return a.printPDF(ctx, string(body), html, file.Output, opts)
+	}
+	return fmt.Errorf("unsupported batch export format %q", format)
+}
+
+// GetBatchFormats lists the formats ExportBatch can write.
+func (a *App) GetBatchFormats() []string {
+	return exporter.BatchFormats()
+}
+
+func (a *App) exportEPUB(title string, authors []string, docs []exporter.EPUBDocument) error {
+	outputPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to EPUB",
+		DefaultFilename: title + ".epub",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "EPUB Books", Pattern: "*.epub"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+
+	return a.runExport("epub", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts := a.exportOptions("", "")
+		opts.Title = title
+		opts.Authors = authors
+		opts.Progress = progress
+		return a.exporter.ToEPUB(ctx, docs, outputPath, opts)
+	})
+}
+
+// ChooseDocxReference asks for a reference .docx and returns its path, or
+// an empty string when the dialog is cancelled.
+func (a *App) ChooseDocxReference() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Choose Reference Document",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Word Documents", Pattern: "*.docx"},
+		},
+	})
+}
+
+// ChooseLatexTemplate asks for a file to use as the LaTeX preamble.
+func (a *App) ChooseLatexTemplate() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Choose LaTeX Preamble",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "LaTeX Files", Pattern: "*.tex;*.sty"},
+		},
+	})
+}
+
+// withFrontMatter gives the export template a document's front matter.
+// Its title, authors and date override the defaults, and its template key
+// picks the layout when the export call did not.
+func withFrontMatter(opts exporter.Options, fm markdown.FrontMatter, template string) exporter.Options {
+	opts.FrontMatter = fm.Fields
+	if fm.Title != "" {
+		opts.Title = fm.Title
+	}
+	opts.Authors = fm.Authors
+	opts.Date = fm.Date
+	if template == "" {
+		template = fm.Fields["template"]
+	}
+	if template != "" {
+		opts.Template = template
+	}
+	return opts
+}
+
+// GetExportTemplates lists the built-in export templates and the user's own
+// from the templates folder in the config directory.
+func (a *App) GetExportTemplates() []exporter.ExportTemplate {
+	return a.exporter.Templates()
+}
+
+// toPDF runs printPDF as a tracked export.
+func (a *App) toPDF(content, html, outputPath string, opts exporter.Options) error {
+	return a.runExport("pdf", outputPath, func(ctx context.Context, progress exporter.Progress) error {
+		opts.Progress = progress
+		return a.printPDF(ctx, content, html, outputPath, opts)
+	})
+}
+
+// printPDF prints with Chrome when it is installed and falls back to the
+// built-in layout otherwise.
+func (a *App) printPDF(ctx context.Context, content, html, outputPath string, opts exporter.Options) error {
+	err := a.exporter.ToPDF(ctx, html, outputPath, opts)
+	if !errors.Is(err, exporter.ErrChromeNotFound) {
+		return err
+	}
+	source := []byte(content)
+	return a.exporter.ToBuiltinPDF(ctx, source, a.renderer.Parse(source), outputPath, opts)
+}
+
+// errExportCancelled is returned by an export stopped with CancelExport.
+var errExportCancelled = errors.New("export cancelled")
+
+// runExport runs one export at a time and reports on it with
+// export:progress and export:done events.
+func (a *App) runExport(format, outputPath string, export func(ctx context.Context, progress exporter.Progress) error) error {
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	a.exportMu.Lock()
+	if a.cancelExport != nil {
+		a.exportMu.Unlock()
+		return errors.New("another export is in progress")
+	}
+	a.cancelExport = cancel
+	a.exportMu.Unlock()
+	defer func() {
+		a.exportMu.Lock()
+		a.cancelExport = nil
+		a.exportMu.Unlock()
+	}()
+
+	err := export(ctx, func(stage string, percent int) {
+		runtime.EventsEmit(a.ctx, "export:progress", map[string]interface{}{
+			"format":  format,
+			"stage":   stage,
+			"percent": percent,
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		err = errExportCancelled
+	}
+
+	done := map[string]interface{}{
+		"format":    format,
+		"path":      outputPath,
+		"cancelled": err == errExportCancelled,
+		"error":     "",
+	}
+	if err != nil && err != errExportCancelled {
+		done["error"] = err.Error()
+	}
+	runtime.EventsEmit(a.ctx, "export:done", done)
+	return err
+}
+
+// CancelExport stops the export in progress, if there is one.
+func (a *App) CancelExport() {
+	a.exportMu.Lock()
+	defer a.exportMu.Unlock()
+	if a.cancelExport != nil {
+		a.cancelExport()
+	}
+}
+
+// exportOptions describes the source document at path for the exporter. An
+// empty theme follows the preview theme and light/dark setting.
+func (a *App) exportOptions(path, theme string) exporter.Options {
+	s := a.settings.Get()
+	if theme == "" {
+		theme = a.exporter.ResolveTheme(s.PreviewTheme, s.Theme)
+	}
+	opts := exporter.Options{
+		Theme:         theme,
+		MaxImageWidth: s.ExportMaxImageWidth,
+		ReferenceDocx: s.DocxReference,
+		ChapterLevel:  *s.EpubChapterLevel,
+		LaTeXTemplate: s.LatexTemplate,
+		LaTeXMinted:   s.LatexMinted,
+		SiteTemplate:  s.SiteTemplate,
+		TextWidth:     *s.PlainTextWidth,
+		Template:      s.ExportTemplate,
+		Fields:        s.TemplateFields,
+		Workspace:     a.folderManager.GetCurrentPath(),
+		PDF: exporter.PDFOptions{
+			PaperSize:       s.PDF.PaperSize,
+			Landscape:       s.PDF.Landscape,
+			MarginTop:       s.PDF.MarginTop,
+			MarginBottom:    s.PDF.MarginBottom,
+			MarginLeft:      s.PDF.MarginLeft,
+			MarginRight:     s.PDF.MarginRight,
+			Scale:           s.PDF.Scale,
+			PrintBackground: s.PDF.PrintBackground,
+			HeaderFooter:    s.PDF.HeaderFooter,
+			HeaderTemplate:  s.PDF.HeaderTemplate,
+			FooterTemplate:  s.PDF.FooterTemplate,
+		},
+	}
+	if path != "" {
+		opts.BaseDir = filepath.Dir(path)
+		opts.Source = path
+		opts.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return opts
+}
+
+// GetExportThemes lists the built-in export themes and the user's own from
+// the themes folder in the config directory.
+func (a *App) GetExportThemes() []exporter.Theme {
+	return a.exporter.Themes()
 }
 
 func (a *App) ToggleFullscreen() {
@@ -295,10 +1166,12 @@ func (a *App) OpenFolder() ([]foldermanager.FileNode, error) {
 		return nil, nil
 	}
 
+	a.localFiles.SetWorkspace(folder)
 	return a.folderManager.OpenFolder(folder)
 }
 
 func (a *App) GetFolderTree(path string) ([]foldermanager.FileNode, error) {
+	a.localFiles.SetWorkspace(path)
 	return a.folderManager.OpenFolder(path)
 }
 
@@ -315,3 +1188,137 @@ func (a *App) CopyImageToAssets(sourcePath, documentPath string) (string, error)
 	return a.imageManager.CopyImageToAssets(sourcePath, documentPath)
 }
 
+// ConvertPastedHTML turns clipboard HTML into Markdown for pasting into
+// the document at documentPath, saving embedded and remote images in its
+// assets folder. Any web page can set the clipboard, so local files it
+// names are never read. It returns "" when the HTML is plain text, which
+// pastes better as such.
+func (a *App) ConvertPastedHTML(htmlContent, documentPath string) (string, error) {
+	if !importer.HasFormatting(htmlContent) {
+		return "", nil
+	}
+	return importer.HTMLToMarkdown(strings.NewReader(htmlContent), importer.Options{
+		SaveImage: func(dataURI string) (string, error) {
+			return a.imageManager.SaveBase64Image(dataURI, documentPath)
+		},
+		RemoteImages: true,
+	})
+}
+
+// ImportDocument asks for an HTML page or Word document, converts it to
+// Markdown and saves it where the user picks, with its images in the
+// assets folder beside it. The new file is returned like OpenFile's.
+func (a *App) ImportDocument() (map[string]string, error) {
+	source, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Document",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HTML and Word Documents", Pattern: "*.html;*.htm;*.docx"},
+		},
+	})
+	if err != nil || source == "" {
+		return nil, err
+	}
+	output, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:            "Save Imported Markdown",
+		DefaultDirectory: filepath.Dir(source),
+		DefaultFilename:  strings.TrimSuffix(filepath.Base(source), filepath.Ext(source)) + ".md",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Markdown Files", Pattern: "*.md"},
+		},
+	})
+	if err != nil || output == "" {
+		return nil, err
+	}
+
+	content, err := importer.ImportFile(source, importer.Options{
+		SaveImage: func(dataURI string) (string, error) {
+			return a.imageManager.SaveBase64Image(dataURI, output)
+		},
+		LocalImages: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s: %w", filepath.Base(source), err)
+	}
+	if err := a.fileManager.SaveFile(output, content); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"content": content,
+		"path":    output,
+		"name":    filepath.Base(output),
+	}, nil
+}
+
+// Table operations
+
+// ConvertCSVToTable turns CSV or TSV text into a Markdown table.
+func (a *App) ConvertCSVToTable(text string) (string, error) {
+	return markdown.CSVToTable(text, 0)
+}
+
+// ImportCSVTable asks for a CSV or TSV file and returns it as a Markdown
+// table, or "" when the dialog is cancelled.
+func (a *App) ImportCSVTable() (string, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Insert Table from CSV",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV and TSV Files", Pattern: "*.csv;*.tsv;*.txt"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var delimiter rune
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		delimiter = '\t'
+	}
+	table, err := markdown.CSVToTable(string(data), delimiter)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return table, nil
+}
+
+// TableToCSV returns the table on the 1-based line as CSV.
+func (a *App) TableToCSV(content string, line int) (string, error) {
+	return a.renderer.TableToCSV(content, line, 0)
+}
+
+// ExportTableToCSV saves the table on the 1-based line as a CSV file,
+// offering the folder of the document at path. It returns the file written,
+// or "" when the dialog is cancelled.
+func (a *App) ExportTableToCSV(content string, line int, path string) (string, error) {
+	data, err := a.renderer.TableToCSV(content, line, 0)
+	if err != nil {
+		return "", err
+	}
+	opts := runtime.SaveDialogOptions{
+		Title:           "Export Table to CSV",
+		DefaultFilename: "table.csv",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV Files", Pattern: "*.csv"},
+		},
+	}
+	if path != "" {
+		opts.DefaultDirectory = filepath.Dir(path)
+		opts.DefaultFilename = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".csv"
+	}
+	outputPath, err := runtime.SaveFileDialog(a.ctx, opts)
+	if err != nil || outputPath == "" {
+		return "", err
+	}
+	if err := os.WriteFile(outputPath, []byte(data), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", filepath.Base(outputPath), err)
+	}
+	return outputPath, nil
+}
+
+// EditTable sorts, inserts or deletes the column under the cursor, or
+// transposes the table there, returning the edited document.
+func (a *App) EditTable(content string, pos markdown.Position, edit markdown.TableEdit) (markdown.FormatResult, error) {
+	return a.renderer.EditTable(content, pos, edit)
+}
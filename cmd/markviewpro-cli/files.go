@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+
+	"markviewpro/internal/foldermanager"
+)
+
+// collectMarkdownFiles expands directories into the Markdown files they
+// contain.
+func collectMarkdownFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		found, err := foldermanager.ListMarkdownFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
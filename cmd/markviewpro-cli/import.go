@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"markviewpro/internal/imagemanager"
+	"markviewpro/internal/importer"
+)
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	output := flags.String("o", "", "output file, or - for standard output (default: the input's name with .md)")
+	assets := flags.String("assets", "assets", "folder for extracted images, relative to the output")
+	force := flags.Bool("f", false, "overwrite existing Markdown files")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: markviewpro-cli import [flags] <file.html|file.docx>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitCode(2)
+	}
+	if *output != "" && flags.NArg() > 1 {
+		return errors.New("-o takes a single input file")
+	}
+
+	images := imagemanager.NewImageManager()
+	images.SetAssetsFolder(*assets)
+	for _, source := range flags.Args() {
+		target := *output
+		if target == "" {
+			target = strings.TrimSuffix(source, filepath.Ext(source)) + ".md"
+		}
+		// Images of a document written to standard output go in the
+		// current folder.
+		document := target
+		if target == "-" {
+			document = ""
+		} else if _, err := os.Stat(target); err == nil && !*force {
+			return fmt.Errorf("%s already exists; use -f to overwrite it", target)
+		}
+
+		content, err := importer.ImportFile(source, importer.Options{
+			SaveImage: func(dataURI string) (string, error) {
+				return images.SaveBase64Image(dataURI, document)
+			},
+			LocalImages: true,
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+		if target == "-" {
+			fmt.Print(content)
+			continue
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("%s -> %s\n", source, target)
+	}
+	return nil
+}
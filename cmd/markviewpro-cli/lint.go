@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"markviewpro/internal/markdown"
+)
+
+type lintResult struct {
+	Path        string                `json:"path"`
+	Diagnostics []markdown.Diagnostic `json:"diagnostics"`
+}
+
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := flags.String("config", "", "lint configuration file (default: nearest "+markdown.LintConfigFile+")")
+	format := flags.String("format", "text", "output format: text or json")
+	failOn := flags.String("fail-on", "warning", "lowest severity that fails the run: info, warning or error")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: markviewpro-cli lint [flags] <file or folder>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitCode(2)
+	}
+	switch markdown.Severity(*failOn) {
+	case markdown.SeverityInfo, markdown.SeverityWarning, markdown.SeverityError:
+	default:
+		fmt.Fprintf(flags.Output(), "invalid -fail-on %q: use info, warning or error\n", *failOn)
+		flags.Usage()
+		return exitCode(2)
+	}
+
+	files, err := collectMarkdownFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	var fixedConfig *markdown.LintConfig
+	if *configPath != "" {
+		cfg, err := markdown.LoadLintConfig(*configPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *configPath, err)
+		}
+		fixedConfig = &cfg
+	}
+
+	linter := markdown.NewLinter(markdown.NewRenderer())
+	results := make([]lintResult, 0, len(files))
+	failed := false
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		cfg := markdown.DefaultLintConfig()
+		if fixedConfig != nil {
+			cfg = *fixedConfig
+		} else if cfg, _, err = markdown.FindLintConfig(file); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		diagnostics := linter.Lint(string(content), cfg)
+		for _, d := range diagnostics {
+			if severityRank(d.Severity) >= severityRank(markdown.Severity(*failOn)) {
+				failed = true
+			}
+		}
+		results = append(results, lintResult{Path: file, Diagnostics: diagnostics})
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	default:
+		for _, r := range results {
+			for _, d := range r.Diagnostics {
+				fmt.Printf("%s:%d:%d %s/%s %s [%s]\n",
+					r.Path, d.Range.Start.Line, d.Range.Start.Column,
+					d.RuleID, d.RuleAlias, d.Message, d.Severity)
+			}
+		}
+	}
+
+	if failed {
+		return exitCode(1)
+	}
+	return nil
+}
+
+func severityRank(s markdown.Severity) int {
+	switch s {
+	case markdown.SeverityError:
+		return 2
+	case markdown.SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,55 @@
+// Command markviewpro-cli runs MarkViewPro's document tooling without the
+// desktop app, for use in scripts and CI.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: markviewpro-cli <command> [flags] [arguments]
+
+Commands:
+  lint    Check Markdown files against the lint rules
+  import  Convert HTML and Word documents to Markdown
+  table   Convert CSV and TSV data to Markdown tables, and back
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "lint":
+		err = runLint(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "table":
+		err = runTable(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		if code, ok := err.(exitCode); ok {
+			os.Exit(int(code))
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// exitCode is returned by commands that have already reported their result
+// and only need to set the process exit status.
+type exitCode int
+
+func (e exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"markviewpro/internal/markdown"
+)
+
+func runTable(args []string) error {
+	flags := flag.NewFlagSet("table", flag.ExitOnError)
+	toCSV := flags.Bool("csv", false, "extract a table from a Markdown file as CSV instead")
+	line := flags.Int("line", 0, "with -csv, a line of the table to extract (default: the first table)")
+	delim := flags.String("d", "", `field delimiter: a character or "tab" (default: detected, or a comma for -csv)`)
+	output := flags.String("o", "-", "output file, or - for standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: markviewpro-cli table [flags] <file.csv|file.tsv|->")
+		fmt.Fprintln(flags.Output(), "       markviewpro-cli table -csv [flags] <file.md|->")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitCode(2)
+	}
+	source := flags.Arg(0)
+
+	var delimiter rune
+	switch {
+	case *delim == "tab" || *delim == `\t`:
+		delimiter = '\t'
+	case utf8.RuneCountInString(*delim) == 1:
+		delimiter, _ = utf8.DecodeRuneInString(*delim)
+	case *delim != "":
+		return fmt.Errorf("invalid delimiter %q", *delim)
+	case strings.EqualFold(filepath.Ext(source), ".tsv"):
+		delimiter = '\t'
+	}
+
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return err
+	}
+
+	var result string
+	if *toCSV {
+		result, err = markdown.NewRenderer().TableToCSV(string(data), *line, delimiter)
+	} else if *line != 0 {
+		return errors.New("-line needs -csv")
+	} else {
+		result, err = markdown.CSVToTable(string(data), delimiter)
+		result += "\n"
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	if *output == "-" {
+		fmt.Print(result)
+		return nil
+	}
+	return os.WriteFile(*output, []byte(result), 0644)
+}
@@ -5,15 +5,19 @@ import { MarkdownViewer } from './components/Viewer/MarkdownViewer';
 import { StatusBar } from './components/StatusBar/StatusBar';
 import { SettingsModal } from './components/Settings/SettingsModal';
 import { SearchBar } from './components/Search/SearchBar';
+import { Presenter } from './components/Presenter/Presenter';
 import { ViewModeToggle, ViewMode } from './components/Toolbar/ViewModeToggle';
 import { WelcomeScreen } from './components/Welcome/WelcomeScreen';
 import { ToastContainer } from './components/Toast/Toast';
+import { ExportProgress } from './components/Export/ExportProgress';
+import { BatchExportDialog } from './components/Export/BatchExportDialog';
 import { useMarkdown } from './hooks/useMarkdown';
 import { useTabs } from './hooks/useTabs';
 import { useAppKeyboard } from './hooks/useKeyboard';
 import { useToast } from './hooks/useToast';
 import { useSettings } from './hooks/useSettings';
 import { wails, FileNode } from './utils/wailsBindings';
+import type { ExportProgressEvent, ExportDoneEvent } from './utils/wailsBindings';
 import type { RecentFile } from './types';
 
 // Lazy load heavy components
@@ -24,6 +28,7 @@ const CommandPalette = lazy(() => import('./components/CommandPalette/CommandPal
 export default function App() {
   const [sidebarOpen, setSidebarOpen] = useState(false);
   const [settingsOpen, setSettingsOpen] = useState(false);
+  const [batchExportOpen, setBatchExportOpen] = useState(false);
   const [searchOpen, setSearchOpen] = useState(false);
   const [commandPaletteOpen, setCommandPaletteOpen] = useState(false);
   const [recentFiles, setRecentFiles] = useState<RecentFile[]>([]);
@@ -32,6 +37,11 @@ export default function App() {
   const [isDragging, setIsDragging] = useState(false);
   const [viewMode, setViewMode] = useState<ViewMode>('preview');
   const [folderTree, setFolderTree] = useState<FileNode[]>([]);
+  // Trust is tied to the path it was checked for, so a tab switch never
+  // shows a document with the previous tab's trust.
+  const [trust, setTrust] = useState<{ path: string; trusted: boolean } | null>(null);
+  const [presenting, setPresenting] = useState(false);
+  const [exportProgress, setExportProgress] = useState<ExportProgressEvent | null>(null);
 
   const { tabs, activeTab, activeTabId, setActiveTabId, addTab, closeTab, updateTab, updateTabContent } = useTabs();
   const { toasts, dismissToast, success, error, info } = useToast();
@@ -91,6 +101,25 @@ export default function App() {
     }
   }, [activeTab, updateTabContent, updateContent]);
 
+  // Raw HTML is only rendered as-is for documents inside a trusted folder
+  useEffect(() => {
+    const path = activeTab?.filePath;
+    if (!path) return;
+    let cancelled = false;
+    wails.isTrusted(path).then(trusted => {
+      if (!cancelled) setTrust({ path, trusted });
+    });
+    return () => {
+      cancelled = true;
+    };
+  }, [activeTab?.filePath]);
+  const activeTrusted = !!activeTab?.filePath && trust?.path === activeTab.filePath && trust.trusted;
+
+  // Scope the local file handler to the active document's folder
+  useEffect(() => {
+    wails.setActiveDocument(activeTab?.filePath || '');
+  }, [activeTab?.filePath]);
+
   // Listen for fullscreen changes
   useEffect(() => {
     const handleFullscreenChange = () => {
@@ -221,6 +250,45 @@ export default function App() {
     };
   }, [activeTab?.filePath, settings.autoReload, activeTab?.id, updateTab, info]);
 
+  // Pick up content rewritten by format-on-save
+  useEffect(() => {
+    const handleFormatted = (data: unknown) => {
+      const { path, content } = data as { path: string; content: string };
+      const tab = tabs.find((t) => t.filePath === path);
+      if (tab) {
+        updateTab(tab.id, { content, isModified: false });
+      }
+    };
+
+    wails.onEvent('file:formatted', handleFormatted);
+    return () => {
+      wails.offEvent('file:formatted');
+    };
+  }, [tabs, updateTab]);
+
+  // Track the running export; failures and cancellation are reported here
+  useEffect(() => {
+    const handleProgress = (data: unknown) => {
+      setExportProgress(data as ExportProgressEvent);
+    };
+    const handleDone = (data: unknown) => {
+      const done = data as ExportDoneEvent;
+      setExportProgress(null);
+      if (done.cancelled) {
+        info('Export cancelled');
+      } else if (done.error) {
+        error(`Export failed: ${done.error}`, 6000);
+      }
+    };
+
+    wails.onEvent('export:progress', handleProgress);
+    wails.onEvent('export:done', handleDone);
+    return () => {
+      wails.offEvent('export:progress');
+      wails.offEvent('export:done');
+    };
+  }, [info, error]);
+
   // Handle image paste
   useEffect(() => {
     const handlePaste = async (e: ClipboardEvent) => {
@@ -340,6 +408,24 @@ export default function App() {
     }
   }, [openFile, addTab, updateRecentFiles, success, error]);
 
+  const handlePasteHTML = useCallback(
+    (html: string) => wails.convertPastedHTML(html, activeTab?.filePath || filePath || ''),
+    [activeTab?.filePath, filePath],
+  );
+
+  const handleImport = useCallback(async () => {
+    try {
+      const result = await wails.importDocument();
+      if (result) {
+        addTab(result.name, result.path, result.content);
+        updateRecentFiles();
+        success(`Imported ${result.name}`);
+      }
+    } catch (err) {
+      error(String(err));
+    }
+  }, [addTab, updateRecentFiles, success, error]);
+
   const handleOpenRecentFile = useCallback(async (path: string) => {
     try {
       const result = await wails.readFileByPath(path);
@@ -423,29 +509,117 @@ export default function App() {
   }, [activeTab, updateTab, success, error]);
 
   const handleExportPDF = useCallback(async () => {
-    try {
-      if (filePath) {
-        await wails.exportToPDF(filePath);
-        success('PDF exported successfully');
-      } else {
-        await wails.exportContentToPDF(content);
-        success('PDF exported successfully');
-      }
-    } catch (err) {
-      error('Failed to export PDF');
-      console.error('Export error:', err);
+    const exported = filePath
+      ? await wails.exportToPDF(filePath)
+      : await wails.exportContentToPDF(content);
+    if (exported) {
+      success('PDF exported successfully');
     }
-  }, [filePath, content, success, error]);
+  }, [filePath, content, success]);
 
   const handleExportHTML = useCallback(async () => {
-    try {
-      await wails.exportToHTML(content);
+    if (await wails.exportToHTML(content, activeTab?.filePath || filePath || '')) {
       success('HTML exported successfully');
+    }
+  }, [content, activeTab?.filePath, filePath, success]);
+
+  const handleExportDOCX = useCallback(async () => {
+    if (await wails.exportToDOCX(activeContent, activeTab?.filePath || filePath || '')) {
+      success('Word document exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleExportEPUB = useCallback(async () => {
+    if (await wails.exportToEPUB(activeContent, activeTab?.filePath || filePath || '')) {
+      success('EPUB exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleExportLaTeX = useCallback(async () => {
+    if (await wails.exportToLaTeX(activeContent, activeTab?.filePath || filePath || '')) {
+      success('LaTeX exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleExportPlainText = useCallback(async () => {
+    if (await wails.exportToPlainText(activeContent, activeTab?.filePath || filePath || '')) {
+      success('Plain text exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleExportCommonMark = useCallback(async () => {
+    if (await wails.exportToCommonMark(activeContent, activeTab?.filePath || filePath || '')) {
+      success('CommonMark exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleCopyAs = useCallback(async (format: 'text' | 'commonmark') => {
+    const text = format === 'text'
+      ? await wails.convertToPlainText(activeContent)
+      : await wails.convertToCommonMark(activeContent);
+    try {
+      await navigator.clipboard.writeText(text);
+      success(format === 'text' ? 'Copied as plain text' : 'Copied as CommonMark');
+    } catch {
+      error('Could not write to the clipboard');
+    }
+  }, [activeContent, success, error]);
+
+  const handleExportSlidesHTML = useCallback(async () => {
+    if (await wails.exportSlidesToHTML(activeContent, activeTab?.filePath || filePath || '')) {
+      success('Slides exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleExportSlidesPDF = useCallback(async () => {
+    if (await wails.exportSlidesToPDF(activeContent, activeTab?.filePath || filePath || '')) {
+      success('Slides exported successfully');
+    }
+  }, [activeContent, activeTab?.filePath, filePath, success]);
+
+  const handleExportFolderEPUB = useCallback(async () => {
+    if (await wails.exportFolderToEPUB()) {
+      success('Folder exported as EPUB');
+    }
+  }, [success]);
+
+  const handleExportFolderSite = useCallback(async () => {
+    if (await wails.exportFolderToSite()) {
+      success('Folder exported as a static site');
+    }
+  }, [success]);
+
+  const handleExportSelfContainedHTML = useCallback(async () => {
+    try {
+      const report = await wails.exportToSelfContainedHTML(activeContent, activeTab?.filePath || filePath || '');
+      if (!report) return;
+      const size = report.totalBytes >= 1024 * 1024
+        ? `${(report.totalBytes / (1024 * 1024)).toFixed(1)} MB`
+        : `${Math.ceil(report.totalBytes / 1024)} KB`;
+      success(`Exported ${size} with ${report.assets.length} embedded file(s)`);
+      if (report.external.length > 0) {
+        info(`${report.external.length} reference(s) could not be embedded: ${report.external.slice(0, 3).join(', ')}`);
+      }
     } catch (err) {
-      error('Failed to export HTML');
+      // Shown by the export:done listener.
       console.error('Export error:', err);
     }
-  }, [content, success, error]);
+  }, [activeContent, activeTab?.filePath, filePath, success, info]);
+
+  const handleTrustFolder = useCallback(async () => {
+    const path = activeTab?.filePath;
+    if (!path) {
+      info('Save the document before trusting its folder');
+      return;
+    }
+    const folder = path.substring(0, Math.max(path.lastIndexOf('/'), path.lastIndexOf('\\')));
+    if (await wails.trustFolder(folder)) {
+      setTrust({ path, trusted: true });
+      success(`Trusted ${folder}`);
+    } else {
+      error('Failed to trust folder');
+    }
+  }, [activeTab?.filePath, success, error, info]);
 
   const handleToggleSidebar = useCallback(() => {
     setSidebarOpen(prev => !prev);
@@ -498,6 +672,13 @@ export default function App() {
       action: handleOpenFolder,
       category: 'File',
     },
+    {
+      id: 'import-document',
+      label: 'Import…',
+      description: 'Convert an HTML page or Word document to Markdown',
+      action: handleImport,
+      category: 'File',
+    },
     {
       id: 'new-file',
       label: 'New File',
@@ -529,6 +710,112 @@ export default function App() {
       action: handleExportHTML,
       category: 'Export',
     },
+    {
+      id: 'export-html-single',
+      label: 'Export to Single-File HTML',
+      description: 'Export HTML with images and styles embedded',
+      action: handleExportSelfContainedHTML,
+      category: 'Export',
+    },
+    {
+      id: 'export-docx',
+      label: 'Export to Word',
+      description: 'Export document as a DOCX file',
+      action: handleExportDOCX,
+      category: 'Export',
+    },
+    {
+      id: 'export-epub',
+      label: 'Export to EPUB',
+      description: 'Export document as an e-book',
+      action: handleExportEPUB,
+      category: 'Export',
+    },
+    {
+      id: 'export-folder-epub',
+      label: 'Export Folder to EPUB',
+      description: 'Export every document in the open folder as one e-book',
+      action: handleExportFolderEPUB,
+      category: 'Export',
+    },
+    {
+      id: 'export-folder-site',
+      label: 'Export Folder as Static Site',
+      description: 'Publish every document in the open folder as browsable HTML',
+      action: handleExportFolderSite,
+      category: 'Export',
+    },
+    {
+      id: 'export-batch',
+      label: 'Batch Export…',
+      description: 'Export many documents at once, keeping their folder layout',
+      action: () => setBatchExportOpen(true),
+      category: 'Export',
+    },
+    {
+      id: 'present',
+      label: 'Start Presentation',
+      description: 'Present the document as slides, split on --- or headings',
+      shortcut: 'F5',
+      action: () => setPresenting(true),
+      category: 'View',
+    },
+    {
+      id: 'export-slides-html',
+      label: 'Export Slides to HTML',
+      description: 'Export the document as a self-contained slide deck',
+      action: handleExportSlidesHTML,
+      category: 'Export',
+    },
+    {
+      id: 'export-slides-pdf',
+      label: 'Export Slides to PDF',
+      description: 'Export the slides as a PDF with one slide per page',
+      action: handleExportSlidesPDF,
+      category: 'Export',
+    },
+    {
+      id: 'export-latex',
+      label: 'Export to LaTeX',
+      description: 'Export document as a LaTeX article',
+      action: handleExportLaTeX,
+      category: 'Export',
+    },
+    {
+      id: 'export-text',
+      label: 'Export to Plain Text',
+      description: 'Export document as wrapped text with links as numbered notes',
+      action: handleExportPlainText,
+      category: 'Export',
+    },
+    {
+      id: 'export-commonmark',
+      label: 'Export to CommonMark',
+      description: 'Export document as portable CommonMark without GFM extensions',
+      action: handleExportCommonMark,
+      category: 'Export',
+    },
+    {
+      id: 'copy-text',
+      label: 'Copy as Plain Text',
+      description: 'Copy the document as plain text',
+      action: () => handleCopyAs('text'),
+      category: 'Export',
+    },
+    {
+      id: 'copy-commonmark',
+      label: 'Copy as CommonMark',
+      description: 'Copy the document as portable CommonMark',
+      action: () => handleCopyAs('commonmark'),
+      category: 'Export',
+    },
+    {
+      id: 'export-cancel',
+      label: 'Cancel Export',
+      description: 'Stop the export in progress',
+      action: () => wails.cancelExport(),
+      category: 'Export',
+    },
     {
       id: 'toggle-split',
       label: 'Toggle Split View',
@@ -599,6 +886,13 @@ export default function App() {
       action: handleZoomReset,
       category: 'View',
     },
+    {
+      id: 'trust-folder',
+      label: 'Trust This Folder',
+      description: 'Render raw HTML in documents from this folder without sanitizing',
+      action: handleTrustFolder,
+      category: 'Other',
+    },
     {
       id: 'settings',
       label: 'Settings',
@@ -621,6 +915,17 @@ export default function App() {
     handleSave,
     handleExportPDF,
     handleExportHTML,
+    handleExportSelfContainedHTML,
+    handleExportDOCX,
+    handleExportEPUB,
+    handleExportFolderEPUB,
+    handleExportFolderSite,
+    handleExportLaTeX,
+    handleExportPlainText,
+    handleExportCommonMark,
+    handleCopyAs,
+    handleExportSlidesHTML,
+    handleExportSlidesPDF,
     handleToggleSidebar,
     handleToggleSearch,
     handleToggleFullscreen,
@@ -628,6 +933,7 @@ export default function App() {
     handleZoomOut,
     handleZoomReset,
     handleToggleSettings,
+    handleTrustFolder,
     handlePrint,
   ]);
 
@@ -644,6 +950,11 @@ export default function App() {
         e.preventDefault();
         setViewMode(prev => prev === 'split' ? 'preview' : 'split');
       }
+      // Start Presentation
+      if (e.key === 'F5' && tabs.length > 0) {
+        e.preventDefault();
+        setPresenting(true);
+      }
       // Open Folder
       if (e.ctrlKey && e.shiftKey && e.key === 'O') {
         e.preventDefault();
@@ -653,7 +964,7 @@ export default function App() {
 
     window.addEventListener('keydown', handleKeyDown);
     return () => window.removeEventListener('keydown', handleKeyDown);
-  }, [handleOpenFolder]);
+  }, [handleOpenFolder, tabs.length]);
 
   useAppKeyboard({
     onOpen: handleOpen,
@@ -690,9 +1001,18 @@ export default function App() {
         onTabClose={closeTab}
         onNew={handleNew}
         onOpen={handleOpen}
+        onImport={handleImport}
         onSave={handleSave}
         onExportPDF={handleExportPDF}
         onExportHTML={handleExportHTML}
+        onExportSelfContainedHTML={handleExportSelfContainedHTML}
+        onExportDOCX={handleExportDOCX}
+        onExportEPUB={handleExportEPUB}
+        onExportLaTeX={handleExportLaTeX}
+        onExportPlainText={handleExportPlainText}
+        onExportCommonMark={handleExportCommonMark}
+        onExportSlidesHTML={handleExportSlidesHTML}
+        onExportSlidesPDF={handleExportSlidesPDF}
         onOpenSettings={() => setSettingsOpen(true)}
         onToggleSidebar={handleToggleSidebar}
         onPrint={handlePrint}
@@ -738,6 +1058,8 @@ export default function App() {
                     key={searchOpen ? 'search-open' : 'search-closed'} 
                     content={activeContent} 
                     headings={activeHeadings} 
+                    trusted={activeTrusted}
+                    filePath={activeTab?.filePath}
                   />
                 </div>
               )}
@@ -749,6 +1071,10 @@ export default function App() {
                       content={activeContent} 
                       onChange={handleContentChange}
                       theme="dark"
+                      onPasteHTML={settings.plainPaste ? undefined : handlePasteHTML}
+                      filePath={activeTab?.filePath}
+                      onSuccess={success}
+                      onError={error}
                     />
                   </div>
                 </Suspense>
@@ -760,7 +1086,12 @@ export default function App() {
                     content={activeContent}
                     onChange={handleContentChange}
                     headings={activeHeadings}
+                    trusted={activeTrusted}
+                    filePath={activeTab?.filePath}
                     theme="dark"
+                    onPasteHTML={settings.plainPaste ? undefined : handlePasteHTML}
+                    onSuccess={success}
+                    onError={error}
                   />
                 </Suspense>
               )}
@@ -787,6 +1118,17 @@ export default function App() {
       )}
 
       <SettingsModal isOpen={settingsOpen} onClose={() => setSettingsOpen(false)} />
+      <BatchExportDialog
+        isOpen={batchExportOpen}
+        onClose={() => setBatchExportOpen(false)}
+        openPaths={tabs.flatMap((t) => (t.filePath ? [t.filePath] : []))}
+        onFinished={(results) => {
+          if (results.length > 0 && results.every((r) => !r.error)) {
+            success(`Exported ${results.length} document(s)`);
+          }
+        }}
+        onError={(message) => error(`Batch export failed: ${message}`, 6000)}
+      />
       {commandPaletteOpen && (
         <Suspense fallback={null}>
           <CommandPalette 
@@ -796,6 +1138,15 @@ export default function App() {
           />
         </Suspense>
       )}
+      {presenting && (
+        <Presenter
+          content={activeContent}
+          trusted={activeTrusted}
+          filePath={activeTab?.filePath}
+          onClose={() => setPresenting(false)}
+        />
+      )}
+      <ExportProgress progress={exportProgress} onCancel={() => wails.cancelExport()} />
       <ToastContainer toasts={toasts} onDismiss={dismissToast} />
     </div>
   );
@@ -1,18 +1,114 @@
 import { useEffect, useRef } from 'react';
 import Editor from '@monaco-editor/react';
-import type { editor } from 'monaco-editor';
+import type { editor, IPosition } from 'monaco-editor';
+import { wails } from '../../utils/wailsBindings';
+import type { TableEdit } from '../../utils/wailsBindings';
 
 interface MarkdownEditorProps {
   content: string;
   onChange: (value: string) => void;
   theme?: 'light' | 'dark';
+  // Converts pasted HTML to Markdown; null or '' pastes the plain text.
+  onPasteHTML?: (html: string) => Promise<string | null>;
+  filePath?: string | null;
+  onSuccess?: (message: string) => void;
+  onError?: (message: string) => void;
 }
 
-export function MarkdownEditor({ content, onChange, theme = 'dark' }: MarkdownEditorProps) {
+export function MarkdownEditor({ content, onChange, theme = 'dark', onPasteHTML, filePath, onSuccess, onError }: MarkdownEditorProps) {
   const editorRef = useRef<editor.IStandaloneCodeEditor | null>(null);
+  const pasteHTMLRef = useRef(onPasteHTML);
+  pasteHTMLRef.current = onPasteHTML;
+  const tableRef = useRef({ filePath, onSuccess, onError });
+  tableRef.current = { filePath, onSuccess, onError };
 
   const handleEditorDidMount = (editor: editor.IStandaloneCodeEditor) => {
     editorRef.current = editor;
+
+    // Catch rich pastes before Monaco inserts their plain text. Copies from
+    // code editors keep their text.
+    editor.getContainerDomNode().addEventListener('paste', (e: ClipboardEvent) => {
+      const data = e.clipboardData;
+      const html = data?.getData('text/html');
+      const convert = pasteHTMLRef.current;
+      if (!data || !html || !convert || data.types.includes('vscode-editor-data')) return;
+      e.preventDefault();
+      e.stopPropagation();
+      const plain = data.getData('text/plain');
+      const selection = editor.getSelection();
+      convert(html).then((markdown) => {
+        if (!selection) return;
+        editor.executeEdits('paste-markdown', [{ range: selection, text: markdown || plain, forceMoveMarkers: true }]);
+        editor.pushUndoStop();
+      });
+    }, true);
+
+    // Table commands in the context menu act on the table under the cursor.
+    const tableAction = (id: string, label: string, run: (content: string, position: IPosition) => Promise<string | void>) => {
+      editor.addAction({
+        id,
+        label,
+        contextMenuGroupId: '9_table',
+        run: async () => {
+          const position = editor.getPosition();
+          if (!position) return;
+          try {
+            const message = await run(editor.getValue(), position);
+            if (message) tableRef.current.onSuccess?.(message);
+          } catch (err) {
+            tableRef.current.onError?.(err instanceof Error ? err.message : String(err));
+          }
+        },
+      });
+    };
+    const insertTable = (table: string | null) => {
+      const selection = editor.getSelection();
+      if (!table || !selection) return;
+      editor.executeEdits('csv-table', [{ range: selection, text: table, forceMoveMarkers: true }]);
+      editor.pushUndoStop();
+    };
+    const editTable = (edit: TableEdit) => async (content: string, position: IPosition) => {
+      const result = await wails.editTable(content, { line: position.lineNumber, column: position.column }, edit);
+      if (!result?.changed) return;
+      editor.executeEdits('edit-table', result.edits.map(({ range, newText }) => ({
+        range: {
+          startLineNumber: range.start.line,
+          startColumn: range.start.column,
+          endLineNumber: range.end.line,
+          endColumn: range.end.column,
+        },
+        text: newText,
+      })));
+      editor.pushUndoStop();
+    };
+
+    tableAction('table.sortAscending', 'Table: Sort by Column (Ascending)', editTable({ op: 'sort' }));
+    tableAction('table.sortDescending', 'Table: Sort by Column (Descending)', editTable({ op: 'sort', descending: true }));
+    tableAction('table.insertLeft', 'Table: Insert Column Left', editTable({ op: 'insertLeft' }));
+    tableAction('table.insertRight', 'Table: Insert Column Right', editTable({ op: 'insertRight' }));
+    tableAction('table.deleteColumn', 'Table: Delete Column', editTable({ op: 'deleteColumn' }));
+    tableAction('table.transpose', 'Table: Transpose', editTable({ op: 'transpose' }));
+    tableAction('table.copyCSV', 'Table: Copy as CSV', async (content, position) => {
+      const csv = await wails.tableToCSV(content, position.lineNumber);
+      if (csv === null) return;
+      await navigator.clipboard.writeText(csv);
+      return 'Copied table as CSV';
+    });
+    tableAction('table.exportCSV', 'Table: Export to CSV…', async (content, position) => {
+      const path = await wails.exportTableToCSV(content, position.lineNumber, tableRef.current.filePath || '');
+      return path ? `Exported table to ${path}` : undefined;
+    });
+    tableAction('table.fromCSV', 'Table: Convert Selected CSV to Table', async () => {
+      const selection = editor.getSelection();
+      const model = editor.getModel();
+      if (!selection || !model || selection.isEmpty()) {
+        throw new Error('Select CSV or TSV text to convert');
+      }
+      insertTable(await wails.convertCSVToTable(model.getValueInRange(selection)));
+    });
+    tableAction('table.insertCSV', 'Table: Insert from CSV File…', async () => {
+      insertTable(await wails.importCSVTable());
+    });
     
     // Focus editor on mount
     editor.focus();
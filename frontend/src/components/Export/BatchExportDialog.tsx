@@ -0,0 +1,199 @@
+import { X, Check, AlertCircle } from 'lucide-react';
+import { useEffect, useState } from 'react';
+import { wails, type BatchResult } from '../../utils/wailsBindings';
+
+interface BatchExportDialogProps {
+  isOpen: boolean;
+  onClose: () => void;
+  // Saved documents open in tabs, offered as an alternative to the folder.
+  openPaths: string[];
+  onFinished: (results: BatchResult[]) => void;
+  onError: (message: string) => void;
+}
+
+const formatLabels: Record<string, string> = {
+  html: 'HTML',
+  'html-single': 'Single-file HTML',
+  pdf: 'PDF',
+  docx: 'Word',
+  epub: 'EPUB',
+  latex: 'LaTeX',
+  text: 'Plain text',
+  commonmark: 'CommonMark',
+};
+
+// Patterns are separated by commas or new lines.
+function patterns(text: string): string[] {
+  return text.split(/[,\n]/).map((p) => p.trim()).filter(Boolean);
+}
+
+function fileName(path: string): string {
+  return path.split(/[\\/]/).pop() || path;
+}
+
+export function BatchExportDialog({ isOpen, onClose, openPaths, onFinished, onError }: BatchExportDialogProps) {
+  const [formats, setFormats] = useState<string[]>([]);
+  const [format, setFormat] = useState('html');
+  const [source, setSource] = useState<'folder' | 'tabs'>('folder');
+  const [include, setInclude] = useState('');
+  const [exclude, setExclude] = useState('');
+  const [running, setRunning] = useState(false);
+  const [results, setResults] = useState<BatchResult[]>([]);
+
+  useEffect(() => {
+    if (isOpen) {
+      wails.getBatchFormats().then(setFormats);
+      setResults([]);
+    }
+  }, [isOpen]);
+
+  useEffect(() => {
+    if (!isOpen) return;
+    wails.onEvent('export:file', (data: unknown) => {
+      setResults((r) => [...r, data as BatchResult]);
+    });
+    return () => wails.offEvent('export:file');
+  }, [isOpen]);
+
+  if (!isOpen) return null;
+
+  const handleExport = async () => {
+    setRunning(true);
+    setResults([]);
+    try {
+      const done = await wails.exportBatch({
+        paths: source === 'tabs' ? openPaths : [],
+        folder: '',
+        include: patterns(include),
+        exclude: patterns(exclude),
+        format,
+        outputDir: '',
+      });
+      if (done) onFinished(done);
+    } catch (err) {
+      onError(String(err));
+    } finally {
+      setRunning(false);
+    }
+  };
+
+  const failed = results.filter((r) => r.error).length;
+
+  return (
+    <div className="fixed inset-0 z-50 flex items-center justify-center">
+      <div
+        className="absolute inset-0 bg-black/70 backdrop-blur-sm"
+        onClick={running ? undefined : onClose}
+      />
+
+      <div className="relative bg-zinc-900 border border-zinc-800 rounded-lg shadow-2xl w-full max-w-md mx-4 animate-fade-in">
+        <div className="flex items-center justify-between px-4 py-3 border-b border-zinc-800">
+          <h2 className="text-sm font-semibold text-zinc-100">Batch Export</h2>
+          <button
+            onClick={onClose}
+            disabled={running}
+            className="p-1 text-zinc-500 hover:text-zinc-100 hover:bg-zinc-800 rounded transition-colors disabled:opacity-50"
+          >
+            <X className="w-4 h-4" />
+          </button>
+        </div>
+
+        <div className="p-4 space-y-4 max-h-[60vh] overflow-y-auto">
+          <div>
+            <label className="block text-xs font-medium text-zinc-400 mb-2">
+              Documents
+            </label>
+            <div className="flex gap-1.5">
+              {[
+                { value: 'folder' as const, label: 'Open folder' },
+                { value: 'tabs' as const, label: `Open tabs (${openPaths.length})` },
+              ].map(({ value, label }) => (
+                <button
+                  key={value}
+                  onClick={() => setSource(value)}
+                  className={`
+                    flex-1 px-3 py-2 rounded text-xs font-medium transition-all
+                    ${source === value
+                      ? 'bg-cyan-600 text-white'
+                      : 'bg-zinc-800 text-zinc-400 hover:bg-zinc-700 hover:text-zinc-200'
+                    }
+                  `}
+                >
+                  {label}
+                </button>
+              ))}
+            </div>
+          </div>
+
+          {source === 'folder' && (
+            <div className="space-y-2">
+              <input
+                type="text"
+                placeholder="Include, e.g. docs/**, *.md"
+                value={include}
+                onChange={(e) => setInclude(e.target.value)}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs font-mono text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              />
+              <input
+                type="text"
+                placeholder="Exclude, e.g. drafts/**"
+                value={exclude}
+                onChange={(e) => setExclude(e.target.value)}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs font-mono text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              />
+              <p className="text-[10px] text-zinc-500">
+                Patterns without a slash match file names; ** matches any folders.
+              </p>
+            </div>
+          )}
+
+          <div>
+            <label className="block text-xs font-medium text-zinc-400 mb-2">
+              Format
+            </label>
+            <select
+              value={format}
+              onChange={(e) => setFormat(e.target.value)}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              {formats.map((f) => (
+                <option key={f} value={f}>{formatLabels[f] || f}</option>
+              ))}
+            </select>
+          </div>
+
+          {results.length > 0 && (
+            <div>
+              <div className="text-xs font-medium text-zinc-400 mb-2">
+                {results.length - failed} exported{failed > 0 ? `, ${failed} failed` : ''}
+              </div>
+              <ul className="space-y-1 text-xs">
+                {results.map((r) => (
+                  <li key={r.source} className="flex items-start gap-2" title={r.output}>
+                    {r.error ? (
+                      <AlertCircle className="w-3.5 h-3.5 flex-shrink-0 text-red-400" />
+                    ) : (
+                      <Check className="w-3.5 h-3.5 flex-shrink-0 text-emerald-400" />
+                    )}
+                    <span className="text-zinc-300 truncate">{fileName(r.source)}</span>
+                    {r.error && <span className="text-red-400 truncate">{r.error}</span>}
+                  </li>
+                ))}
+              </ul>
+            </div>
+          )}
+        </div>
+
+        <div className="flex justify-end gap-2 px-4 py-3 border-t border-zinc-800">
+          <button
+            onClick={handleExport}
+            disabled={running || (source === 'tabs' && openPaths.length === 0)}
+            className="px-3 py-1.5 rounded text-xs font-medium bg-cyan-600 text-white hover:bg-cyan-500 transition-colors disabled:opacity-50"
+          >
+            {running ? 'Exporting…' : 'Export…'}
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,33 @@
+import { Loader2, X } from 'lucide-react';
+import type { ExportProgressEvent } from '../../utils/wailsBindings';
+
+interface ExportProgressProps {
+  progress: ExportProgressEvent | null;
+  onCancel: () => void;
+}
+
+export function ExportProgress({ progress, onCancel }: ExportProgressProps) {
+  if (!progress) return null;
+
+  return (
+    <div className="fixed bottom-16 left-4 z-50 w-64 px-3 py-2 rounded-lg shadow-lg bg-zinc-800 border border-zinc-700 text-xs text-zinc-200">
+      <div className="flex items-center gap-2">
+        <Loader2 className="w-4 h-4 flex-shrink-0 animate-spin text-cyan-400" />
+        <span className="flex-1 truncate">{progress.stage}…</span>
+        <button
+          onClick={onCancel}
+          className="p-0.5 hover:bg-white/20 rounded transition-colors"
+          title="Cancel export"
+        >
+          <X className="w-3.5 h-3.5" />
+        </button>
+      </div>
+      <div className="mt-2 h-1 bg-zinc-700 rounded overflow-hidden">
+        <div
+          className="h-full bg-cyan-500 transition-all duration-150"
+          style={{ width: `${progress.percent}%` }}
+        />
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,152 @@
+import { useState, useEffect, useCallback } from 'react';
+import { X, ChevronLeft, ChevronRight, StickyNote } from 'lucide-react';
+import { MarkdownViewer } from '../Viewer/MarkdownViewer';
+import { wails, type Slide } from '../../utils/wailsBindings';
+
+interface PresenterProps {
+  content: string;
+  trusted: boolean;
+  filePath?: string | null;
+  onClose: () => void;
+}
+
+// Slide directives that map straight onto CSS.
+function slideStyle(slide: Slide): React.CSSProperties {
+  return {
+    background: slide.settings.background || undefined,
+    color: slide.settings.color || undefined,
+  };
+}
+
+export function Presenter({ content, trusted, filePath, onClose }: PresenterProps) {
+  const [slides, setSlides] = useState<Slide[]>([]);
+  const [current, setCurrent] = useState(0);
+  const [showNotes, setShowNotes] = useState(false);
+  const [startedAt] = useState(() => Date.now());
+  const [elapsed, setElapsed] = useState(0);
+
+  useEffect(() => {
+    wails.getSlides(content).then((s) => {
+      setSlides(s);
+      setCurrent((c) => Math.min(c, Math.max(0, s.length - 1)));
+    });
+  }, [content]);
+
+  useEffect(() => {
+    document.documentElement.requestFullscreen?.().catch(() => {});
+    return () => {
+      if (document.fullscreenElement) document.exitFullscreen().catch(() => {});
+    };
+  }, []);
+
+  useEffect(() => {
+    const timer = setInterval(() => setElapsed(Math.floor((Date.now() - startedAt) / 1000)), 1000);
+    return () => clearInterval(timer);
+  }, [startedAt]);
+
+  const go = useCallback((index: number) => {
+    setCurrent(Math.max(0, Math.min(slides.length - 1, index)));
+  }, [slides.length]);
+
+  useEffect(() => {
+    const handleKeyDown = (e: KeyboardEvent) => {
+      switch (e.key) {
+        case 'ArrowRight': case 'ArrowDown': case 'PageDown': case ' ':
+          go(current + 1);
+          break;
+        case 'ArrowLeft': case 'ArrowUp': case 'PageUp': case 'Backspace':
+          go(current - 1);
+          break;
+        case 'Home':
+          go(0);
+          break;
+        case 'End':
+          go(slides.length - 1);
+          break;
+        case 'n': case 'N':
+          setShowNotes((v) => !v);
+          break;
+        case 'Escape':
+          onClose();
+          break;
+        default:
+          return;
+      }
+      e.preventDefault();
+      e.stopPropagation();
+    };
+    window.addEventListener('keydown', handleKeyDown, true);
+    return () => window.removeEventListener('keydown', handleKeyDown, true);
+  }, [current, slides.length, go, onClose]);
+
+  const slide = slides[current];
+  const next = slides[current + 1];
+  const minutes = Math.floor(elapsed / 60);
+  const seconds = String(elapsed % 60).padStart(2, '0');
+
+  return (
+    <div className="fixed inset-0 z-50 flex flex-col bg-zinc-950 text-zinc-100">
+      <div className="flex flex-1 min-h-0">
+        <div className="flex-1 flex items-center justify-center p-6 min-w-0">
+          {slide ? (
+            <div
+              className={`presenter-slide aspect-video w-full max-h-full flex flex-col justify-center overflow-hidden rounded bg-zinc-900 ${slide.settings.class || ''}`}
+              style={slideStyle(slide)}
+            >
+              <MarkdownViewer content={slide.markdown} headings={[]} trusted={trusted} filePath={filePath} />
+            </div>
+          ) : (
+            <div className="text-zinc-500">This document has no slides</div>
+          )}
+        </div>
+
+        {showNotes && (
+          <aside className="w-96 flex flex-col gap-4 p-4 border-l border-zinc-800 bg-zinc-900 overflow-y-auto">
+            <div>
+              <div className="text-xs uppercase text-zinc-500 mb-1">Notes</div>
+              {slide?.notes ? (
+                <MarkdownViewer content={slide.notes} headings={[]} trusted={trusted} filePath={filePath} />
+              ) : (
+                <div className="text-sm text-zinc-500">No notes for this slide</div>
+              )}
+            </div>
+            {next && (
+              <div>
+                <div className="text-xs uppercase text-zinc-500 mb-1">Next</div>
+                <div className="aspect-video overflow-hidden rounded border border-zinc-800 text-[0.5rem] pointer-events-none" style={slideStyle(next)}>
+                  <MarkdownViewer content={next.markdown} headings={[]} trusted={trusted} filePath={filePath} />
+                </div>
+              </div>
+            )}
+          </aside>
+        )}
+      </div>
+
+      <div className="flex items-center justify-between px-4 py-2 text-xs text-zinc-400 border-t border-zinc-800">
+        <div className="flex items-center gap-2">
+          <button onClick={() => go(current - 1)} className="p-1 hover:text-zinc-100" aria-label="Previous slide">
+            <ChevronLeft className="w-4 h-4" />
+          </button>
+          <span>{slides.length > 0 ? `${current + 1} / ${slides.length}` : '0 / 0'}</span>
+          <button onClick={() => go(current + 1)} className="p-1 hover:text-zinc-100" aria-label="Next slide">
+            <ChevronRight className="w-4 h-4" />
+          </button>
+        </div>
+        <span>{minutes}:{seconds}</span>
+        <div className="flex items-center gap-2">
+          <button
+            onClick={() => setShowNotes((v) => !v)}
+            className={`flex items-center gap-1 p-1 hover:text-zinc-100 ${showNotes ? 'text-cyan-400' : ''}`}
+          >
+            <StickyNote className="w-4 h-4" />
+            Notes (N)
+          </button>
+          <button onClick={onClose} className="flex items-center gap-1 p-1 hover:text-zinc-100">
+            <X className="w-4 h-4" />
+            Exit (Esc)
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
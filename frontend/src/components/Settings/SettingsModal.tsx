@@ -1,5 +1,8 @@
 import { X, RotateCcw, Monitor, Sun, Moon } from 'lucide-react';
+import { useEffect, useState } from 'react';
 import { useSettings } from '../../hooks/useSettings';
+import type { PdfSettings } from '../../types';
+import { wails, type ExportTheme, type ExportTemplate } from '../../utils/wailsBindings';
 
 interface SettingsModalProps {
   isOpen: boolean;
@@ -8,6 +11,36 @@ interface SettingsModalProps {
 
 export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
   const { settings, updateSettings, resetSettings } = useSettings();
+  const [exportThemes, setExportThemes] = useState<ExportTheme[]>([]);
+  const [exportTemplates, setExportTemplates] = useState<ExportTemplate[]>([]);
+  const [templateFields, setTemplateFields] = useState('');
+
+  const updatePdf = (updates: Partial<PdfSettings>) => {
+    updateSettings({ pdf: { ...settings.pdf, ...updates } });
+  };
+
+  // Themes and templates can be added to the config folder at any time, so
+  // reload on open. Fields are read only on open so edits are not reformatted.
+  useEffect(() => {
+    if (isOpen) {
+      wails.getExportThemes().then(setExportThemes);
+      wails.getExportTemplates().then(setExportTemplates);
+      setTemplateFields(
+        Object.entries(settings.templateFields || {})
+          .map(([key, value]) => `${key}: ${value}`)
+          .join('\n')
+      );
+    }
+  }, [isOpen]);
+
+  const saveTemplateFields = () => {
+    const fields: Record<string, string> = {};
+    for (const line of templateFields.split('\n')) {
+      const at = line.indexOf(':');
+      if (at > 0) fields[line.slice(0, at).trim()] = line.slice(at + 1).trim();
+    }
+    updateSettings({ templateFields: fields });
+  };
 
   if (!isOpen) return null;
 
@@ -110,6 +143,92 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
             </select>
           </div>
 
+          <div>
+            <label className="block text-xs font-medium text-zinc-400 mb-2">
+              Export Theme
+            </label>
+            <select
+              value={settings.previewTheme}
+              onChange={(e) => updateSettings({ previewTheme: e.target.value })}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              {exportThemes
+                .filter((t) => !t.name.endsWith('-dark'))
+                .map((t) => (
+                  <option key={t.name} value={t.name}>
+                    {t.builtin ? t.name : `${t.name} (custom)`}
+                  </option>
+                ))}
+              {!exportThemes.some((t) => t.name === settings.previewTheme) && (
+                <option value={settings.previewTheme}>
+                  {exportThemes.length > 0 ? `${settings.previewTheme} (missing, exports use default)` : settings.previewTheme}
+                </option>
+              )}
+            </select>
+          </div>
+
+          <div className="space-y-2">
+            <label className="block text-xs font-medium text-zinc-400">
+              Export Template
+            </label>
+            <select
+              value={settings.exportTemplate}
+              onChange={(e) => updateSettings({ exportTemplate: e.target.value })}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              {exportTemplates.map((t) => (
+                <option key={t.name} value={t.name}>
+                  {t.builtin ? t.name : `${t.name} (custom)`}
+                </option>
+              ))}
+              {!exportTemplates.some((t) => t.name === settings.exportTemplate) && (
+                <option value={settings.exportTemplate}>{settings.exportTemplate}</option>
+              )}
+            </select>
+            <textarea
+              rows={3}
+              placeholder={'Template fields, one per line\ncompany: Example Corp'}
+              value={templateFields}
+              onChange={(e) => setTemplateFields(e.target.value)}
+              onBlur={saveTemplateFields}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs font-mono text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            />
+            <p className="text-[10px] text-zinc-500">
+              HTML and PDF layouts. Add your own as .html files in the templates folder of the config directory.
+            </p>
+          </div>
+
+          <div>
+            <label className="block text-xs font-medium text-zinc-400 mb-2">
+              Prose Wrap (Format Document)
+            </label>
+            <select
+              value={settings.formatProseWrap}
+              onChange={(e) => updateSettings({ formatProseWrap: e.target.value })}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              <option value="preserve">Preserve line breaks</option>
+              <option value="always">Wrap at {settings.formatLineWidth} columns</option>
+              <option value="never">Unwrap paragraphs</option>
+            </select>
+          </div>
+
+          <div>
+            <label className="block text-xs font-medium text-zinc-400 mb-2">
+              Image Width (Single-File Export)
+            </label>
+            <select
+              value={settings.exportMaxImageWidth}
+              onChange={(e) => updateSettings({ exportMaxImageWidth: Number(e.target.value) })}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              <option value={0}>Original size</option>
+              <option value={1920}>Downscale to 1920px</option>
+              <option value={1200}>Downscale to 1200px</option>
+              <option value={800}>Downscale to 800px</option>
+            </select>
+          </div>
+
           <div className="space-y-2">
             <label className="block text-xs font-medium text-zinc-400">
               Options
@@ -135,6 +254,19 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
               />
             </label>
 
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Paste rich text as plain text</span>
+                <span className="text-[10px] text-zinc-500">Otherwise HTML from browsers and wikis is converted to Markdown</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.plainPaste}
+                onChange={(e) => updateSettings({ plainPaste: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
             <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
               <span className="text-xs text-zinc-300">Auto-reload on file change</span>
               <input
@@ -208,6 +340,303 @@ export function SettingsModal({ isOpen, onClose }: SettingsModalProps) {
                 className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
               />
             </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Count code in statistics</span>
+                <span className="text-[10px] text-zinc-500">Include code blocks and inline code in word counts</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.statsIncludeCode}
+                onChange={(e) => updateSettings({ statsIncludeCode: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Format on save</span>
+                <span className="text-[10px] text-zinc-500">Normalize Markdown style when saving</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.formatOnSave}
+                onChange={(e) => updateSettings({ formatOnSave: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Check external links</span>
+                <span className="text-[10px] text-zinc-500">Request http(s) links when checking for broken links</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.checkExternalLinks}
+                onChange={(e) => updateSettings({ checkExternalLinks: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+          </div>
+
+          <div className="space-y-2">
+            <label className="block text-xs font-medium text-zinc-400">
+              PDF Export
+            </label>
+
+            <div className="flex gap-2">
+              <select
+                value={settings.pdf.paperSize}
+                onChange={(e) => updatePdf({ paperSize: e.target.value })}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              >
+                {['A3', 'A4', 'A5', 'Letter', 'Legal', 'Tabloid'].map((size) => (
+                  <option key={size} value={size}>{size}</option>
+                ))}
+              </select>
+              <select
+                value={settings.pdf.landscape ? 'landscape' : 'portrait'}
+                onChange={(e) => updatePdf({ landscape: e.target.value === 'landscape' })}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              >
+                <option value="portrait">Portrait</option>
+                <option value="landscape">Landscape</option>
+              </select>
+            </div>
+
+            <div className="grid grid-cols-4 gap-2">
+              {(['marginTop', 'marginRight', 'marginBottom', 'marginLeft'] as const).map((side) => (
+                <label key={side} className="flex flex-col gap-1 text-[10px] text-zinc-500">
+                  {side.replace('margin', '')} (mm)
+                  <input
+                    type="number"
+                    min={0}
+                    value={settings.pdf[side]}
+                    onChange={(e) => updatePdf({ [side]: Number(e.target.value) })}
+                    className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+                  />
+                </label>
+              ))}
+            </div>
+
+            <label className="flex items-center justify-between gap-2 text-xs text-zinc-300">
+              Scale
+              <input
+                type="number"
+                min={0.1}
+                max={2}
+                step={0.1}
+                value={settings.pdf.scale}
+                onChange={(e) => updatePdf({ scale: Number(e.target.value) })}
+                className="w-20 px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              />
+            </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <span className="text-xs text-zinc-300">Print backgrounds</span>
+              <input
+                type="checkbox"
+                checked={settings.pdf.printBackground}
+                onChange={(e) => updatePdf({ printBackground: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Header and footer</span>
+                <span className="text-[10px] text-zinc-500">Use {'{title}'}, {'{date}'}, {'{page}'} and {'{pages}'}</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.pdf.headerFooter}
+                onChange={(e) => updatePdf({ headerFooter: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
+            {settings.pdf.headerFooter && (
+              <>
+                <input
+                  type="text"
+                  placeholder="Header"
+                  value={settings.pdf.headerTemplate}
+                  onChange={(e) => updatePdf({ headerTemplate: e.target.value })}
+                  className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+                />
+                <input
+                  type="text"
+                  placeholder="Footer"
+                  value={settings.pdf.footerTemplate}
+                  onChange={(e) => updatePdf({ footerTemplate: e.target.value })}
+                  className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+                />
+              </>
+            )}
+          </div>
+
+          <div className="space-y-2">
+            <label className="block text-xs font-medium text-zinc-400">
+              Word Export
+            </label>
+            <div className="flex gap-2">
+              <input
+                type="text"
+                placeholder="Reference document (optional)"
+                value={settings.docxReference}
+                onChange={(e) => updateSettings({ docxReference: e.target.value })}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              />
+              <button
+                onClick={async () => {
+                  const path = await wails.chooseDocxReference();
+                  if (path) updateSettings({ docxReference: path });
+                }}
+                className="px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-300 hover:bg-zinc-700 transition-colors"
+              >
+                Browse
+              </button>
+            </div>
+            <p className="text-[10px] text-zinc-500">
+              Styles and theme are taken from this .docx when set.
+            </p>
+          </div>
+
+          <div>
+            <label className="block text-xs font-medium text-zinc-400 mb-2">
+              EPUB Chapters
+            </label>
+            <select
+              value={settings.epubChapterLevel}
+              onChange={(e) => updateSettings({ epubChapterLevel: Number(e.target.value) })}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              <option value={0}>One chapter per document</option>
+              <option value={1}>Split at H1</option>
+              <option value={2}>Split at H1 and H2</option>
+              <option value={3}>Split at H1 to H3</option>
+            </select>
+          </div>
+
+          <div className="space-y-2">
+            <label className="block text-xs font-medium text-zinc-400">
+              LaTeX Export
+            </label>
+            <div className="flex gap-2">
+              <input
+                type="text"
+                placeholder="Preamble template (optional)"
+                value={settings.latexTemplate}
+                onChange={(e) => updateSettings({ latexTemplate: e.target.value })}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              />
+              <button
+                onClick={async () => {
+                  const path = await wails.chooseLatexTemplate();
+                  if (path) updateSettings({ latexTemplate: path });
+                }}
+                className="px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-300 hover:bg-zinc-700 transition-colors"
+              >
+                Browse
+              </button>
+            </div>
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Use minted for code</span>
+                <span className="text-[10px] text-zinc-500">Needs -shell-escape and Pygments</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.latexMinted}
+                onChange={(e) => updateSettings({ latexMinted: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+          </div>
+
+          <div className="space-y-2">
+            <label className="block text-xs font-medium text-zinc-400">
+              Static Site Export
+            </label>
+            <div className="flex gap-2">
+              <input
+                type="text"
+                placeholder="Layout template (optional)"
+                value={settings.siteTemplate}
+                onChange={(e) => updateSettings({ siteTemplate: e.target.value })}
+                className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+              />
+              <button
+                onClick={async () => {
+                  const path = await wails.chooseSiteTemplate();
+                  if (path) updateSettings({ siteTemplate: path });
+                }}
+                className="px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-300 hover:bg-zinc-700 transition-colors"
+              >
+                Browse
+              </button>
+            </div>
+            <p className="text-[10px] text-zinc-500">
+              An html/template file with .Title, .SiteTitle, .Root, .Nav, .TOC and .Content.
+            </p>
+          </div>
+
+          <div className="space-y-2">
+            <label className="block text-xs font-medium text-zinc-400">
+              Plain Text & CommonMark Export
+            </label>
+            <select
+              value={settings.plainTextWidth}
+              onChange={(e) => updateSettings({ plainTextWidth: Number(e.target.value) })}
+              className="w-full px-3 py-2 bg-zinc-800 border border-zinc-700 rounded text-xs text-zinc-200 focus:ring-1 focus:ring-cyan-500 focus:border-transparent"
+            >
+              <option value={0}>Don't wrap plain text</option>
+              <option value={60}>Wrap plain text at 60 columns</option>
+              <option value={72}>Wrap plain text at 72 columns</option>
+              <option value={80}>Wrap plain text at 80 columns</option>
+              <option value={100}>Wrap plain text at 100 columns</option>
+            </select>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Keep tables in CommonMark</span>
+                <span className="text-[10px] text-zinc-500">Otherwise they become HTML tables</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.commonMarkTables}
+                onChange={(e) => updateSettings({ commonMarkTables: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Keep task lists in CommonMark</span>
+                <span className="text-[10px] text-zinc-500">Otherwise the boxes become [ ] text</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.commonMarkTaskLists}
+                onChange={(e) => updateSettings({ commonMarkTaskLists: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
+
+            <label className="flex items-center justify-between p-2.5 bg-zinc-800/50 rounded cursor-pointer hover:bg-zinc-800 transition-colors">
+              <div className="flex flex-col">
+                <span className="text-xs text-zinc-300">Keep footnotes in CommonMark</span>
+                <span className="text-[10px] text-zinc-500">Otherwise they become numbered notes at the end</span>
+              </div>
+              <input
+                type="checkbox"
+                checked={settings.commonMarkFootnotes}
+                onChange={(e) => updateSettings({ commonMarkFootnotes: e.target.checked })}
+                className="w-4 h-4 text-cyan-500 bg-zinc-700 border-zinc-600 rounded focus:ring-cyan-500"
+              />
+            </label>
           </div>
         </div>
 
@@ -8,10 +8,15 @@ interface SplitViewProps {
   content: string;
   onChange: (content: string) => void;
   headings: HeadingItem[];
+  trusted?: boolean;
+  filePath?: string | null;
   theme?: 'light' | 'dark';
+  onPasteHTML?: (html: string) => Promise<string | null>;
+  onSuccess?: (message: string) => void;
+  onError?: (message: string) => void;
 }
 
-export function SplitView({ content, onChange, headings, theme = 'dark' }: SplitViewProps) {
+export function SplitView({ content, onChange, headings, trusted = false, filePath, theme = 'dark', onPasteHTML, onSuccess, onError }: SplitViewProps) {
   const [splitRatio, setSplitRatio] = useState(50);
   const [isDragging, setIsDragging] = useState(false);
 
@@ -46,7 +51,15 @@ export function SplitView({ content, onChange, headings, theme = 'dark' }: Split
         className="overflow-hidden border-r border-zinc-700"
         style={{ width: `${splitRatio}%` }}
       >
-        <MarkdownEditor content={content} onChange={onChange} theme={theme} />
+        <MarkdownEditor
+          content={content}
+          onChange={onChange}
+          theme={theme}
+          onPasteHTML={onPasteHTML}
+          filePath={filePath}
+          onSuccess={onSuccess}
+          onError={onError}
+        />
       </div>
 
       {/* Resizer */}
@@ -64,7 +77,7 @@ export function SplitView({ content, onChange, headings, theme = 'dark' }: Split
         className="overflow-y-auto flex-1"
         style={{ width: `${100 - splitRatio}%` }}
       >
-        <MarkdownViewer content={content} headings={headings} />
+        <MarkdownViewer content={content} headings={headings} trusted={trusted} filePath={filePath} />
       </div>
     </div>
   );
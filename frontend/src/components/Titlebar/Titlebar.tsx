@@ -4,6 +4,7 @@ import {
   Square,
   X,
   FolderOpen,
+  FileInput,
   Download,
   Sun,
   Moon,
@@ -12,6 +13,13 @@ import {
   Save,
   FileText,
   FileCode,
+  Package,
+  FileType,
+  BookOpen,
+  Sigma,
+  Type,
+  Hash,
+  Presentation,
   PanelLeftClose,
   PanelLeft,
   Copy,
@@ -29,9 +37,18 @@ interface TitlebarProps {
   onTabClose: (tabId: string) => void;
   onNew: () => void;
   onOpen: () => void;
+  onImport: () => void;
   onSave: () => void;
   onExportPDF: () => void;
   onExportHTML: () => void;
+  onExportSelfContainedHTML: () => void;
+  onExportDOCX: () => void;
+  onExportEPUB: () => void;
+  onExportLaTeX: () => void;
+  onExportPlainText: () => void;
+  onExportCommonMark: () => void;
+  onExportSlidesHTML: () => void;
+  onExportSlidesPDF: () => void;
   onOpenSettings: () => void;
   onToggleSidebar: () => void;
   onPrint: () => void;
@@ -48,9 +65,18 @@ export function Titlebar({
   onTabClose,
   onNew,
   onOpen,
+  onImport,
   onSave,
   onExportPDF,
   onExportHTML,
+  onExportSelfContainedHTML,
+  onExportDOCX,
+  onExportEPUB,
+  onExportLaTeX,
+  onExportPlainText,
+  onExportCommonMark,
+  onExportSlidesHTML,
+  onExportSlidesPDF,
   onOpenSettings,
   onToggleSidebar,
   onPrint,
@@ -108,6 +134,10 @@ export function Titlebar({
           <FolderOpen className="w-4 h-4" />
         </button>
 
+        <button onClick={onImport} className="titlebar-btn" title="Import HTML or Word document…">
+          <FileInput className="w-4 h-4" />
+        </button>
+
         <button onClick={onSave} className="titlebar-btn" title="Save (Ctrl+S)">
           <Save className="w-4 h-4" />
         </button>
@@ -139,6 +169,62 @@ export function Titlebar({
                   <FileCode className="w-3.5 h-3.5" />
                   Export HTML
                 </button>
+                <button
+                  onClick={() => { onExportSelfContainedHTML(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <Package className="w-3.5 h-3.5" />
+                  Export Single-File HTML
+                </button>
+                <button
+                  onClick={() => { onExportDOCX(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <FileType className="w-3.5 h-3.5" />
+                  Export Word
+                </button>
+                <button
+                  onClick={() => { onExportEPUB(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <BookOpen className="w-3.5 h-3.5" />
+                  Export EPUB
+                </button>
+                <button
+                  onClick={() => { onExportLaTeX(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <Sigma className="w-3.5 h-3.5" />
+                  Export LaTeX
+                </button>
+                <button
+                  onClick={() => { onExportPlainText(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <Type className="w-3.5 h-3.5" />
+                  Export Plain Text
+                </button>
+                <button
+                  onClick={() => { onExportCommonMark(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <Hash className="w-3.5 h-3.5" />
+                  Export CommonMark
+                </button>
+                <button
+                  onClick={() => { onExportSlidesHTML(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <Presentation className="w-3.5 h-3.5" />
+                  Export Slides (HTML)
+                </button>
+                <button
+                  onClick={() => { onExportSlidesPDF(); setExportMenuOpen(false); }}
+                  className="dropdown-item"
+                >
+                  <Presentation className="w-3.5 h-3.5" />
+                  Export Slides (PDF)
+                </button>
               </div>
             </>
           )}
@@ -6,6 +6,8 @@ import rehypeKatex from 'rehype-katex';
 import { CodeBlock } from './CodeBlock';
 import { MermaidDiagram } from './MermaidDiagram';
 import { useSettings } from '../../hooks/useSettings';
+import { rehypeSanitizeHtml } from '../../utils/sanitizeHtml';
+import { previewUrlTransform } from '../../utils/localFiles';
 import { Link } from 'lucide-react';
 import type { HeadingItem } from '../../types';
 import 'katex/dist/katex.min.css';
@@ -14,6 +16,10 @@ import { useState, useCallback, useEffect } from 'react';
 interface MarkdownViewerProps {
   content: string;
   headings: HeadingItem[];
+  // Untrusted documents have their raw HTML reduced to a safe allowlist.
+  trusted?: boolean;
+  // Relative links and images resolve against this document's folder.
+  filePath?: string | null;
 }
 
 interface HeadingProps {
@@ -97,7 +103,7 @@ function HeadingWithAnchor({ id, children, Tag }: HeadingProps & { Tag: 'h1' | '
   );
 }
 
-export function MarkdownViewer({ content, headings }: MarkdownViewerProps) {
+export function MarkdownViewer({ content, headings, trusted = false, filePath }: MarkdownViewerProps) {
   const { settings } = useSettings();
   const [contextMenu, setContextMenu] = useState<{ x: number; y: number } | null>(null);
   let headingIndex = 0;
@@ -120,7 +126,8 @@ export function MarkdownViewer({ content, headings }: MarkdownViewerProps) {
       >
       <ReactMarkdown
         remarkPlugins={[remarkGfm, remarkMath]}
-        rehypePlugins={[rehypeRaw, rehypeKatex]}
+        urlTransform={previewUrlTransform(filePath)}
+        rehypePlugins={trusted ? [rehypeRaw, rehypeKatex] : [rehypeRaw, rehypeSanitizeHtml, rehypeKatex]}
         components={{
           code({ node, className, children, ...props }) {
             const match = /language-(\w+)/.exec(className || '');
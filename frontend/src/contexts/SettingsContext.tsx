@@ -1,7 +1,21 @@
 import { createContext, useContext, useState, useEffect, useCallback } from 'react';
-import type { Settings } from '../types';
+import type { Settings, PdfSettings } from '../types';
 import { wails, type BackendSettings } from '../utils/wailsBindings';
 
+const defaultPdfSettings: PdfSettings = {
+  paperSize: 'A4',
+  landscape: false,
+  marginTop: 15,
+  marginBottom: 15,
+  marginLeft: 15,
+  marginRight: 15,
+  scale: 1,
+  printBackground: true,
+  headerFooter: false,
+  headerTemplate: '{title}',
+  footerTemplate: 'Page {page} of {pages}',
+};
+
 const defaultSettings: Settings = {
   theme: 'system',
   fontSize: 16,
@@ -18,6 +32,25 @@ const defaultSettings: Settings = {
   syncScroll: true,
   spellCheck: false,
   openInNewTab: true,
+  statsIncludeCode: false,
+  formatOnSave: false,
+  formatProseWrap: 'preserve',
+  formatLineWidth: 80,
+  checkExternalLinks: false,
+  exportMaxImageWidth: 0,
+  pdf: defaultPdfSettings,
+  docxReference: '',
+  epubChapterLevel: 1,
+  latexTemplate: '',
+  latexMinted: false,
+  siteTemplate: '',
+  exportTemplate: 'default',
+  templateFields: {},
+  plainTextWidth: 72,
+  commonMarkTables: false,
+  commonMarkTaskLists: false,
+  commonMarkFootnotes: false,
+  plainPaste: false,
 };
 
 interface SettingsContextType {
@@ -48,6 +81,25 @@ function backendToFrontend(backend: BackendSettings): Settings {
     syncScroll: backend.syncScroll ?? true,
     spellCheck: backend.spellCheck ?? false,
     openInNewTab: backend.openInNewTab ?? true,
+    statsIncludeCode: backend.statsIncludeCode ?? false,
+    formatOnSave: backend.formatOnSave ?? false,
+    formatProseWrap: backend.formatProseWrap || 'preserve',
+    formatLineWidth: backend.formatLineWidth || 80,
+    checkExternalLinks: backend.checkExternalLinks ?? false,
+    exportMaxImageWidth: backend.exportMaxImageWidth || 0,
+    pdf: { ...defaultPdfSettings, ...backend.pdf },
+    docxReference: backend.docxReference || '',
+    epubChapterLevel: backend.epubChapterLevel ?? 1,
+    latexTemplate: backend.latexTemplate || '',
+    latexMinted: backend.latexMinted ?? false,
+    siteTemplate: backend.siteTemplate || '',
+    exportTemplate: backend.exportTemplate || 'default',
+    templateFields: backend.templateFields || {},
+    plainTextWidth: backend.plainTextWidth ?? 72,
+    commonMarkTables: backend.commonMarkTables ?? false,
+    commonMarkTaskLists: backend.commonMarkTaskLists ?? false,
+    commonMarkFootnotes: backend.commonMarkFootnotes ?? false,
+    plainPaste: backend.plainPaste ?? false,
   };
 }
 
@@ -67,6 +119,25 @@ function frontendToBackend(frontend: Settings): BackendSettings {
     syncScroll: frontend.syncScroll,
     spellCheck: frontend.spellCheck,
     openInNewTab: frontend.openInNewTab,
+    statsIncludeCode: frontend.statsIncludeCode,
+    formatOnSave: frontend.formatOnSave,
+    formatProseWrap: frontend.formatProseWrap,
+    formatLineWidth: frontend.formatLineWidth,
+    checkExternalLinks: frontend.checkExternalLinks,
+    exportMaxImageWidth: frontend.exportMaxImageWidth,
+    pdf: frontend.pdf,
+    docxReference: frontend.docxReference,
+    epubChapterLevel: frontend.epubChapterLevel,
+    latexTemplate: frontend.latexTemplate,
+    latexMinted: frontend.latexMinted,
+    siteTemplate: frontend.siteTemplate,
+    exportTemplate: frontend.exportTemplate,
+    templateFields: frontend.templateFields,
+    plainTextWidth: frontend.plainTextWidth,
+    commonMarkTables: frontend.commonMarkTables,
+    commonMarkTaskLists: frontend.commonMarkTaskLists,
+    commonMarkFootnotes: frontend.commonMarkFootnotes,
+    plainPaste: frontend.plainPaste,
   };
 }
 
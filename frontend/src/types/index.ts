@@ -14,6 +14,40 @@ export interface Settings {
   syncScroll: boolean;
   spellCheck: boolean;
   openInNewTab: boolean;
+  statsIncludeCode: boolean;
+  formatOnSave: boolean;
+  formatProseWrap: string;
+  formatLineWidth: number;
+  checkExternalLinks: boolean;
+  exportMaxImageWidth: number;
+  pdf: PdfSettings;
+  docxReference: string;
+  epubChapterLevel: number;
+  latexTemplate: string;
+  latexMinted: boolean;
+  siteTemplate: string;
+  exportTemplate: string;
+  templateFields: Record<string, string>;
+  plainTextWidth: number;
+  commonMarkTables: boolean;
+  commonMarkTaskLists: boolean;
+  commonMarkFootnotes: boolean;
+  plainPaste: boolean;
+}
+
+// Page setup for PDF export; margins are in millimetres
+export interface PdfSettings {
+  paperSize: string;
+  landscape: boolean;
+  marginTop: number;
+  marginBottom: number;
+  marginLeft: number;
+  marginRight: number;
+  scale: number;
+  printBackground: boolean;
+  headerFooter: boolean;
+  headerTemplate: string;
+  footerTemplate: string;
 }
 
 export interface HeadingItem {
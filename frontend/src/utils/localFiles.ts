@@ -0,0 +1,56 @@
+import { defaultUrlTransform } from 'react-markdown';
+
+// Must match localfiles.Prefix in the Go backend.
+const LOCAL_FILE_PREFIX = '/localfile/';
+
+function dirname(path: string): string {
+  const index = Math.max(path.lastIndexOf('/'), path.lastIndexOf('\\'));
+  return index >= 0 ? path.substring(0, index) : '';
+}
+
+// Resolves `.` and `..` segments; the backend refuses anything that ends up
+// outside the document folder or workspace.
+function joinPath(base: string, relative: string): string {
+  const segments = base.replace(/\\/g, '/').split('/');
+  for (const segment of relative.split('/')) {
+    if (segment === '' || segment === '.') continue;
+    if (segment === '..') {
+      if (segments.length > 1) segments.pop();
+    } else {
+      segments.push(segment);
+    }
+  }
+  return segments.join('/');
+}
+
+// Rewrites a relative image or media source in the document at documentPath
+// to a URL served by the local file handler.
+export function localFileUrl(url: string, documentPath?: string | null): string {
+  if (!documentPath || !url || url.startsWith('#') || url.startsWith('/') || url.startsWith('\\')) {
+    return url;
+  }
+  if (/^[a-z][a-z0-9+.-]*:/i.test(url)) {
+    return url;
+  }
+
+  const match = /^([^?#]*)(.*)$/.exec(url);
+  if (!match || !match[1]) return url;
+
+  let relative = match[1];
+  try {
+    relative = decodeURI(relative);
+  } catch {
+    // Keep the raw path when it is not valid percent-encoding.
+  }
+
+  const absolute = joinPath(dirname(documentPath), relative).replace(/^\//, '');
+  const encoded = absolute.split('/').map(encodeURIComponent).join('/');
+  return LOCAL_FILE_PREFIX + encoded + match[2];
+}
+
+// Only sources are rewritten: a link to a local page must not open it
+// inside the app.
+export function previewUrlTransform(documentPath?: string | null) {
+  return (url: string, key: string) =>
+    defaultUrlTransform(key === 'src' ? localFileUrl(url, documentPath) : url);
+}
@@ -0,0 +1,106 @@
+// Allowlist sanitizer for the preview of untrusted documents. It runs after
+// rehype-raw and mirrors the policy the Go renderer applies on export.
+
+interface HastNode {
+  type: string;
+  tagName?: string;
+  properties?: Record<string, unknown>;
+  children?: HastNode[];
+}
+
+// Elements whose content is dropped along with the element.
+const droppedTags = new Set([
+  'script', 'style', 'iframe', 'frame', 'frameset', 'object', 'embed', 'applet',
+  'form', 'button', 'textarea', 'select', 'option', 'link', 'meta', 'base', 'noscript',
+  'template', 'svg', 'math',
+]);
+
+const allowedTags = new Set([
+  'a', 'abbr', 'b', 'blockquote', 'br', 'caption', 'cite', 'code', 'col', 'colgroup',
+  'dd', 'del', 'details', 'dfn', 'div', 'dl', 'dt', 'em', 'figcaption', 'figure',
+  'h1', 'h2', 'h3', 'h4', 'h5', 'h6', 'hr', 'i', 'img', 'input', 'ins', 'kbd', 'li',
+  'mark', 'ol', 'p', 'pre', 'q', 's', 'samp', 'section', 'small', 'span', 'strike',
+  'strong', 'sub', 'summary', 'sup', 'table', 'tbody', 'td', 'tfoot', 'th', 'thead',
+  'tr', 'u', 'ul', 'var',
+]);
+
+const globalProps = new Set(['className', 'id', 'title', 'lang', 'dir', 'align']);
+
+const tagProps: Record<string, Set<string>> = {
+  a: new Set(['href', 'name']),
+  img: new Set(['src', 'alt', 'width', 'height']),
+  input: new Set(['type', 'checked', 'disabled']),
+  details: new Set(['open']),
+  td: new Set(['colSpan', 'rowSpan']),
+  th: new Set(['colSpan', 'rowSpan', 'scope']),
+  ol: new Set(['start', 'reversed']),
+  li: new Set(['value']),
+};
+
+const safeUrl = /^(https?:|mailto:|#|[^:]*$)/i;
+const safeImage = /^data:image\/(png|gif|jpe?g|webp);/i;
+
+function cleanProperties(tagName: string, properties: Record<string, unknown> = {}) {
+  const allowed = tagProps[tagName];
+  const clean: Record<string, unknown> = {};
+
+  for (const [key, value] of Object.entries(properties)) {
+    if (!globalProps.has(key) && !allowed?.has(key)) continue;
+
+    if (key === 'href' || key === 'src') {
+      const url = String(value).trim();
+      if (!safeUrl.test(url) && !(key === 'src' && safeImage.test(url))) continue;
+    }
+    clean[key] = value;
+  }
+
+  if (tagName === 'input' && clean.type !== 'checkbox') {
+    return null;
+  }
+  if (tagName === 'input') {
+    clean.disabled = true;
+  }
+  return clean;
+}
+
+function sanitizeChildren(children: HastNode[]): HastNode[] {
+  const result: HastNode[] = [];
+
+  for (const child of children) {
+    if (child.type === 'comment' || child.type === 'doctype' || child.type === 'raw') {
+      continue;
+    }
+    if (child.type !== 'element' || !child.tagName) {
+      result.push(child);
+      continue;
+    }
+
+    const tagName = child.tagName.toLowerCase();
+    if (droppedTags.has(tagName)) {
+      continue;
+    }
+    if (!allowedTags.has(tagName)) {
+      // Unknown elements are unwrapped so their text stays readable.
+      result.push(...sanitizeChildren(child.children ?? []));
+      continue;
+    }
+
+    const properties = cleanProperties(tagName, child.properties);
+    if (!properties) {
+      continue;
+    }
+    result.push({
+      ...child,
+      properties,
+      children: sanitizeChildren(child.children ?? []),
+    });
+  }
+
+  return result;
+}
+
+export function rehypeSanitizeHtml() {
+  return (tree: HastNode) => {
+    tree.children = sanitizeChildren(tree.children ?? []);
+  };
+}
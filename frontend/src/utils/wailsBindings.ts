@@ -1,3 +1,5 @@
+import type { PdfSettings } from '../types';
+
 declare global {
   interface Window {
     go?: {
@@ -6,9 +8,30 @@ declare global {
           OpenFile: () => Promise<{ content: string; path: string; name: string }>;
           SaveFile: (path: string, content: string) => Promise<void>;
           SaveFileAs: (content: string) => Promise<string>;
-          ExportToPDF: (path: string) => Promise<void>;
-          ExportContentToPDF: (content: string) => Promise<void>;
-          ExportToHTML: (content: string) => Promise<void>;
+          ExportToPDF: (path: string, theme: string, template: string) => Promise<void>;
+          ExportContentToPDF: (content: string, theme: string, template: string) => Promise<void>;
+          ExportToHTML: (content: string, path: string, theme: string, template: string) => Promise<void>;
+          ExportToSelfContainedHTML: (content: string, path: string, theme: string, template: string) => Promise<ExportSizeReport | null>;
+          ExportToDOCX: (content: string, path: string) => Promise<void>;
+          ChooseDocxReference: () => Promise<string>;
+          ExportToLaTeX: (content: string, path: string) => Promise<void>;
+          ExportToPlainText: (content: string, path: string) => Promise<void>;
+          ConvertToPlainText: (content: string) => Promise<string>;
+          ExportToCommonMark: (content: string, path: string) => Promise<void>;
+          ConvertToCommonMark: (content: string) => Promise<string>;
+          ChooseLatexTemplate: () => Promise<string>;
+          ExportFolderToSite: (folder: string) => Promise<void>;
+          ExportBatch: (req: BatchExportRequest) => Promise<BatchResult[] | null>;
+          GetBatchFormats: () => Promise<string[]>;
+          ChooseSiteTemplate: () => Promise<string>;
+          ExportToEPUB: (content: string, path: string) => Promise<void>;
+          GetSlides: (content: string) => Promise<Slide[]>;
+          ExportSlidesToHTML: (content: string, path: string) => Promise<void>;
+          ExportSlidesToPDF: (content: string, path: string) => Promise<void>;
+          ExportFolderToEPUB: (folder: string) => Promise<void>;
+          GetExportThemes: () => Promise<ExportTheme[]>;
+          GetExportTemplates: () => Promise<ExportTemplate[]>;
+          CancelExport: () => Promise<void>;
           GetRecentFiles: () => Promise<Array<{ path: string; name: string; accessedAt: string }>>;
           OpenFileDialog: () => Promise<string>;
           SaveFileDialog: (defaultName: string) => Promise<string>;
@@ -19,11 +42,21 @@ declare global {
           ReadFileFromFolder: (path: string) => Promise<string>;
           SavePastedImage: (base64Data: string, documentPath: string) => Promise<string>;
           CopyImageToAssets: (sourcePath: string, documentPath: string) => Promise<string>;
+          ConvertPastedHTML: (htmlContent: string, documentPath: string) => Promise<string>;
+          ImportDocument: () => Promise<{ content: string; path: string; name: string } | null>;
+          ConvertCSVToTable: (text: string) => Promise<string>;
+          ImportCSVTable: () => Promise<string>;
+          TableToCSV: (content: string, line: number) => Promise<string>;
+          ExportTableToCSV: (content: string, line: number, path: string) => Promise<string>;
+          EditTable: (content: string, pos: TextPosition, edit: TableEdit) => Promise<FormatResult>;
           GetInitialFile: () => Promise<string>;
           GetSettings: () => Promise<BackendSettings>;
           UpdateSettings: (settings: BackendSettings) => Promise<void>;
           StartWatching: (path: string) => Promise<void>;
           StopWatching: () => Promise<void>;
+          IsTrusted: (path: string) => Promise<boolean>;
+          TrustFolder: (folder: string) => Promise<void>;
+          SetActiveDocument: (path: string) => Promise<void>;
         };
       };
     };
@@ -56,6 +89,97 @@ export interface BackendSettings {
   wordWrap: boolean;
   spellCheck: boolean;
   openInNewTab: boolean;
+  statsIncludeCode: boolean;
+  formatOnSave: boolean;
+  formatProseWrap: string;
+  formatLineWidth: number;
+  checkExternalLinks: boolean;
+  exportMaxImageWidth: number;
+  pdf: PdfSettings;
+  docxReference: string;
+  epubChapterLevel: number | null;
+  latexTemplate: string;
+  latexMinted: boolean;
+  siteTemplate: string;
+  exportTemplate: string;
+  templateFields: Record<string, string>;
+  plainTextWidth: number | null;
+  commonMarkTables: boolean;
+  commonMarkTaskLists: boolean;
+  commonMarkFootnotes: boolean;
+  plainPaste: boolean;
+}
+
+export interface ExportTheme {
+  name: string;
+  builtin: boolean;
+}
+
+export interface ExportTemplate {
+  name: string;
+  builtin: boolean;
+}
+
+export interface BatchExportRequest {
+  paths: string[];
+  folder: string;
+  include: string[];
+  exclude: string[];
+  format: string;
+  outputDir: string;
+}
+
+export interface BatchResult {
+  source: string;
+  output: string;
+  error: string;
+}
+
+export interface Slide {
+  markdown: string;
+  notes: string;
+  settings: Record<string, string>;
+}
+
+export interface ExportSizeReport {
+  outputPath: string;
+  totalBytes: number;
+  assets: Array<{ source: string; mimeType: string; originalBytes: number; embeddedBytes: number; downscaled: boolean }>;
+  external: string[];
+}
+
+export interface ExportProgressEvent {
+  format: string;
+  stage: string;
+  percent: number;
+}
+
+export interface ExportDoneEvent {
+  format: string;
+  path: string;
+  cancelled: boolean;
+  error: string;
+}
+
+export interface TextPosition {
+  line: number;
+  column: number;
+}
+
+export interface TableEdit {
+  op: 'sort' | 'insertLeft' | 'insertRight' | 'deleteColumn' | 'transpose';
+  descending?: boolean;
+}
+
+export interface TextEdit {
+  range: { start: TextPosition; end: TextPosition };
+  newText: string;
+}
+
+export interface FormatResult {
+  text: string;
+  edits: TextEdit[];
+  changed: boolean;
 }
 
 export interface FileNode {
@@ -106,10 +230,12 @@ export const wails = {
     }
   },
 
-  async exportToPDF(path: string): Promise<boolean> {
+  // An empty theme exports with the current preview theme, and an empty
+  // template with the document's or the settings' template.
+  async exportToPDF(path: string, theme: string = '', template: string = ''): Promise<boolean> {
     try {
       if (window.go?.main?.App?.ExportToPDF) {
-        await window.go.main.App.ExportToPDF(path);
+        await window.go.main.App.ExportToPDF(path, theme, template);
         return true;
       }
       return false;
@@ -119,10 +245,10 @@ export const wails = {
     }
   },
 
-  async exportContentToPDF(content: string): Promise<boolean> {
+  async exportContentToPDF(content: string, theme: string = '', template: string = ''): Promise<boolean> {
     try {
       if (window.go?.main?.App?.ExportContentToPDF) {
-        await window.go.main.App.ExportContentToPDF(content);
+        await window.go.main.App.ExportContentToPDF(content, theme, template);
         return true;
       }
       return false;
@@ -132,10 +258,10 @@ export const wails = {
     }
   },
 
-  async exportToHTML(content: string): Promise<boolean> {
+  async exportToHTML(content: string, path: string = '', theme: string = '', template: string = ''): Promise<boolean> {
     try {
       if (window.go?.main?.App?.ExportToHTML) {
-        await window.go.main.App.ExportToHTML(content);
+        await window.go.main.App.ExportToHTML(content, path, theme, template);
         return true;
       }
       return false;
@@ -145,6 +271,242 @@ export const wails = {
     }
   },
 
+  async exportToSelfContainedHTML(content: string, path: string = '', theme: string = '', template: string = ''): Promise<ExportSizeReport | null> {
+    if (window.go?.main?.App?.ExportToSelfContainedHTML) {
+      return await window.go.main.App.ExportToSelfContainedHTML(content, path, theme, template);
+    }
+    return null;
+  },
+
+  async exportToDOCX(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportToDOCX) {
+        await window.go.main.App.ExportToDOCX(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export to DOCX:', error);
+      return false;
+    }
+  },
+
+  async exportToLaTeX(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportToLaTeX) {
+        await window.go.main.App.ExportToLaTeX(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export to LaTeX:', error);
+      return false;
+    }
+  },
+
+  async exportToPlainText(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportToPlainText) {
+        await window.go.main.App.ExportToPlainText(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export to plain text:', error);
+      return false;
+    }
+  },
+
+  async convertToPlainText(content: string): Promise<string> {
+    try {
+      if (window.go?.main?.App?.ConvertToPlainText) {
+        return await window.go.main.App.ConvertToPlainText(content);
+      }
+      return content;
+    } catch (error) {
+      console.error('Failed to convert to plain text:', error);
+      return content;
+    }
+  },
+
+  async exportToCommonMark(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportToCommonMark) {
+        await window.go.main.App.ExportToCommonMark(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export to CommonMark:', error);
+      return false;
+    }
+  },
+
+  async convertToCommonMark(content: string): Promise<string> {
+    try {
+      if (window.go?.main?.App?.ConvertToCommonMark) {
+        return await window.go.main.App.ConvertToCommonMark(content);
+      }
+      return content;
+    } catch (error) {
+      console.error('Failed to convert to CommonMark:', error);
+      return content;
+    }
+  },
+
+  async exportToEPUB(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportToEPUB) {
+        await window.go.main.App.ExportToEPUB(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export to EPUB:', error);
+      return false;
+    }
+  },
+
+  async exportFolderToEPUB(folder: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportFolderToEPUB) {
+        await window.go.main.App.ExportFolderToEPUB(folder);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export folder to EPUB:', error);
+      return false;
+    }
+  },
+
+  async exportFolderToSite(folder: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportFolderToSite) {
+        await window.go.main.App.ExportFolderToSite(folder);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export folder as a site:', error);
+      return false;
+    }
+  },
+
+  async chooseDocxReference(): Promise<string> {
+    try {
+      return await window.go?.main?.App?.ChooseDocxReference?.() || '';
+    } catch (error) {
+      console.error('Failed to choose reference document:', error);
+      return '';
+    }
+  },
+
+  async chooseLatexTemplate(): Promise<string> {
+    try {
+      return await window.go?.main?.App?.ChooseLatexTemplate?.() || '';
+    } catch (error) {
+      console.error('Failed to choose LaTeX template:', error);
+      return '';
+    }
+  },
+
+  async chooseSiteTemplate(): Promise<string> {
+    try {
+      return await window.go?.main?.App?.ChooseSiteTemplate?.() || '';
+    } catch (error) {
+      console.error('Failed to choose site template:', error);
+      return '';
+    }
+  },
+
+  async getSlides(content: string): Promise<Slide[]> {
+    try {
+      if (window.go?.main?.App?.GetSlides) {
+        return await window.go.main.App.GetSlides(content) || [];
+      }
+      return [];
+    } catch (error) {
+      console.error('Failed to split slides:', error);
+      return [];
+    }
+  },
+
+  async exportSlidesToHTML(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportSlidesToHTML) {
+        await window.go.main.App.ExportSlidesToHTML(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export slides to HTML:', error);
+      return false;
+    }
+  },
+
+  async exportSlidesToPDF(content: string, path: string = ''): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.ExportSlidesToPDF) {
+        await window.go.main.App.ExportSlidesToPDF(content, path);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to export slides to PDF:', error);
+      return false;
+    }
+  },
+
+  async cancelExport(): Promise<void> {
+    await window.go?.main?.App?.CancelExport?.();
+  },
+
+  async getExportThemes(): Promise<ExportTheme[]> {
+    try {
+      if (window.go?.main?.App?.GetExportThemes) {
+        return await window.go.main.App.GetExportThemes() || [];
+      }
+      return [];
+    } catch (error) {
+      console.error('Failed to get export themes:', error);
+      return [];
+    }
+  },
+
+  // Resolves to null when the output folder dialog is cancelled. Each file
+  // is also reported as it finishes with an export:file event.
+  async exportBatch(req: BatchExportRequest): Promise<BatchResult[] | null> {
+    if (window.go?.main?.App?.ExportBatch) {
+      return await window.go.main.App.ExportBatch(req);
+    }
+    return null;
+  },
+
+  async getBatchFormats(): Promise<string[]> {
+    try {
+      if (window.go?.main?.App?.GetBatchFormats) {
+        return await window.go.main.App.GetBatchFormats() || [];
+      }
+      return [];
+    } catch (error) {
+      console.error('Failed to get batch formats:', error);
+      return [];
+    }
+  },
+
+  async getExportTemplates(): Promise<ExportTemplate[]> {
+    try {
+      if (window.go?.main?.App?.GetExportTemplates) {
+        return await window.go.main.App.GetExportTemplates() || [];
+      }
+      return [];
+    } catch (error) {
+      console.error('Failed to get export templates:', error);
+      return [];
+    }
+  },
+
   async getRecentFiles(): Promise<Array<{ path: string; name: string; lastOpened: Date }>> {
     try {
       if (window.go?.main?.App?.GetRecentFiles) {
@@ -162,6 +524,54 @@ export const wails = {
     }
   },
 
+  // Resolves to null when a dialog is cancelled; conversion errors are
+  // left to the caller to report.
+  async importDocument(): Promise<{ content: string; path: string; name: string } | null> {
+    if (window.go?.main?.App?.ImportDocument) {
+      const result = await window.go.main.App.ImportDocument();
+      return result ? result as { content: string; path: string; name: string } : null;
+    }
+    return null;
+  },
+
+  // Table conversions and edits leave their errors, such as no table at
+  // the cursor, to the caller to report. Dialogs resolve to null when
+  // cancelled.
+  async convertCSVToTable(text: string): Promise<string | null> {
+    if (window.go?.main?.App?.ConvertCSVToTable) {
+      return await window.go.main.App.ConvertCSVToTable(text);
+    }
+    return null;
+  },
+
+  async importCSVTable(): Promise<string | null> {
+    if (window.go?.main?.App?.ImportCSVTable) {
+      return (await window.go.main.App.ImportCSVTable()) || null;
+    }
+    return null;
+  },
+
+  async tableToCSV(content: string, line: number): Promise<string | null> {
+    if (window.go?.main?.App?.TableToCSV) {
+      return await window.go.main.App.TableToCSV(content, line);
+    }
+    return null;
+  },
+
+  async exportTableToCSV(content: string, line: number, path: string): Promise<string | null> {
+    if (window.go?.main?.App?.ExportTableToCSV) {
+      return (await window.go.main.App.ExportTableToCSV(content, line, path)) || null;
+    }
+    return null;
+  },
+
+  async editTable(content: string, pos: TextPosition, edit: TableEdit): Promise<FormatResult | null> {
+    if (window.go?.main?.App?.EditTable) {
+      return await window.go.main.App.EditTable(content, pos, edit);
+    }
+    return null;
+  },
+
   async openFileDialog(): Promise<string | null> {
     try {
       if (window.go?.main?.App?.OpenFileDialog) {
@@ -282,6 +692,18 @@ export const wails = {
     }
   },
 
+  async convertPastedHTML(htmlContent: string, documentPath: string): Promise<string | null> {
+    try {
+      if (window.go?.main?.App?.ConvertPastedHTML) {
+        return await window.go.main.App.ConvertPastedHTML(htmlContent, documentPath);
+      }
+      return null;
+    } catch (error) {
+      console.error('Failed to convert pasted HTML:', error);
+      return null;
+    }
+  },
+
   async copyImageToAssets(sourcePath: string, documentPath: string): Promise<string | null> {
     try {
       if (window.go?.main?.App?.CopyImageToAssets) {
@@ -354,6 +776,41 @@ export const wails = {
       console.error('Failed to stop watching:', error);
     }
   },
+
+  setActiveDocument(path: string): void {
+    try {
+      if (window.go?.main?.App?.SetActiveDocument) {
+        window.go.main.App.SetActiveDocument(path);
+      }
+    } catch (error) {
+      console.error('Failed to set active document:', error);
+    }
+  },
+
+  async isTrusted(path: string): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.IsTrusted) {
+        return await window.go.main.App.IsTrusted(path);
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to check folder trust:', error);
+      return false;
+    }
+  },
+
+  async trustFolder(folder: string): Promise<boolean> {
+    try {
+      if (window.go?.main?.App?.TrustFolder) {
+        await window.go.main.App.TrustFolder(folder);
+        return true;
+      }
+      return false;
+    } catch (error) {
+      console.error('Failed to trust folder:', error);
+      return false;
+    }
+  },
 };
 
 export default wails;
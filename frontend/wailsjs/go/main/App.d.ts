@@ -1,34 +1,108 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {analysis} from '../models';
+import {linkcheck} from '../models';
+import {markdown} from '../models';
+import {main} from '../models';
+import {exporter} from '../models';
 import {foldermanager} from '../models';
 import {filemanager} from '../models';
 import {settings} from '../models';
-import {markdown} from '../models';
+
+export function AnalyzeReadability(arg1:string):Promise<analysis.Report>;
+
+export function CancelExport():Promise<void>;
+
+export function CheckFolderLinks(arg1:string):Promise<Array<linkcheck.DocumentReport>>;
+
+export function CheckLinks(arg1:string,arg2:string):Promise<linkcheck.DocumentReport>;
+
+export function ChooseDocxReference():Promise<string>;
+
+export function ChooseLatexTemplate():Promise<string>;
+
+export function ChooseSiteTemplate():Promise<string>;
 
 export function ClearRecentFiles():Promise<void>;
 
+export function ConvertCSVToTable(arg1:string):Promise<string>;
+
+export function ConvertPastedHTML(arg1:string,arg2:string):Promise<string>;
+
+export function ConvertToCommonMark(arg1:string):Promise<string>;
+
+export function ConvertToPlainText(arg1:string):Promise<string>;
+
 export function CopyImageToAssets(arg1:string,arg2:string):Promise<string>;
 
-export function ExportContentToPDF(arg1:string):Promise<void>;
+export function EditTable(arg1:string,arg2:markdown.Position,arg3:markdown.TableEdit):Promise<markdown.FormatResult>;
+
+export function ExportBatch(arg1:main.BatchExportRequest):Promise<Array<exporter.BatchResult>>;
+
+export function ExportContentToPDF(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function ExportFolderToEPUB(arg1:string):Promise<void>;
+
+export function ExportFolderToSite(arg1:string):Promise<void>;
+
+export function ExportSlidesToHTML(arg1:string,arg2:string):Promise<void>;
+
+export function ExportSlidesToPDF(arg1:string,arg2:string):Promise<void>;
+
+export function ExportTableToCSV(arg1:string,arg2:number,arg3:string):Promise<string>;
+
+export function ExportToCommonMark(arg1:string,arg2:string):Promise<void>;
 
-export function ExportToHTML(arg1:string):Promise<void>;
+export function ExportToDOCX(arg1:string,arg2:string):Promise<void>;
 
-export function ExportToPDF(arg1:string):Promise<void>;
+export function ExportToEPUB(arg1:string,arg2:string):Promise<void>;
+
+export function ExportToHTML(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+
+export function ExportToLaTeX(arg1:string,arg2:string):Promise<void>;
+
+export function ExportToPDF(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function ExportToPlainText(arg1:string,arg2:string):Promise<void>;
+
+export function ExportToSelfContainedHTML(arg1:string,arg2:string,arg3:string,arg4:string):Promise<exporter.SizeReport>;
+
+export function FormatDocument(arg1:string):Promise<markdown.FormatResult>;
+
+export function GetBatchFormats():Promise<Array<string>>;
 
 export function GetCurrentFilePath():Promise<string>;
 
+export function GetExportTemplates():Promise<Array<exporter.ExportTemplate>>;
+
+export function GetExportThemes():Promise<Array<exporter.Theme>>;
+
 export function GetFolderTree(arg1:string):Promise<Array<foldermanager.FileNode>>;
 
 export function GetInitialFile():Promise<string>;
 
 export function GetRecentFiles():Promise<Array<filemanager.RecentFile>>;
 
+export function GetSelectionStats(arg1:string):Promise<markdown.Stats>;
+
 export function GetSettings():Promise<settings.UserSettings>;
 
+export function GetSlides(arg1:string):Promise<Array<markdown.Slide>>;
+
 export function GetTableOfContents(arg1:string):Promise<Array<markdown.TOCItem>>;
 
+export function GetTrustedFolders():Promise<Array<string>>;
+
 export function GetWordCount(arg1:string):Promise<markdown.Stats>;
 
+export function ImportCSVTable():Promise<string>;
+
+export function ImportDocument():Promise<Record<string, string>>;
+
+export function IsTrusted(arg1:string):Promise<boolean>;
+
+export function LintDocument(arg1:string,arg2:string):Promise<Array<markdown.Diagnostic>>;
+
 export function OpenFile():Promise<Record<string, string>>;
 
 export function OpenFolder():Promise<Array<foldermanager.FileNode>>;
@@ -39,7 +113,9 @@ export function ReadFileByPath(arg1:string):Promise<Record<string, string>>;
 
 export function ReadFileFromFolder(arg1:string):Promise<string>;
 
-export function RenderMarkdown(arg1:string):Promise<string>;
+export function RenderMarkdown(arg1:string,arg2:string):Promise<string>;
+
+export function RevokeTrust(arg1:string):Promise<void>;
 
 export function SaveFile(arg1:string,arg2:string):Promise<void>;
 
@@ -49,10 +125,16 @@ export function SavePastedImage(arg1:string,arg2:string):Promise<string>;
 
 export function SearchInDocument(arg1:string,arg2:string):Promise<Array<markdown.SearchResult>>;
 
+export function SetActiveDocument(arg1:string):Promise<void>;
+
 export function StartWatching(arg1:string):Promise<void>;
 
 export function StopWatching():Promise<void>;
 
+export function TableToCSV(arg1:string,arg2:number):Promise<string>;
+
 export function ToggleFullscreen():Promise<void>;
 
+export function TrustFolder(arg1:string):Promise<void>;
+
 export function UpdateSettings(arg1:settings.UserSettings):Promise<void>;
@@ -2,30 +2,142 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AnalyzeReadability(arg1) {
+  return window['go']['main']['App']['AnalyzeReadability'](arg1);
+}
+
+export function CancelExport() {
+  return window['go']['main']['App']['CancelExport']();
+}
+
+export function CheckFolderLinks(arg1) {
+  return window['go']['main']['App']['CheckFolderLinks'](arg1);
+}
+
+export function CheckLinks(arg1, arg2) {
+  return window['go']['main']['App']['CheckLinks'](arg1, arg2);
+}
+
+export function ChooseDocxReference() {
+  return window['go']['main']['App']['ChooseDocxReference']();
+}
+
+export function ChooseLatexTemplate() {
+  return window['go']['main']['App']['ChooseLatexTemplate']();
+}
+
+export function ChooseSiteTemplate() {
+  return window['go']['main']['App']['ChooseSiteTemplate']();
+}
+
 export function ClearRecentFiles() {
   return window['go']['main']['App']['ClearRecentFiles']();
 }
 
+export function ConvertCSVToTable(arg1) {
+  return window['go']['main']['App']['ConvertCSVToTable'](arg1);
+}
+
+export function ConvertPastedHTML(arg1, arg2) {
+  return window['go']['main']['App']['ConvertPastedHTML'](arg1, arg2);
+}
+
+export function ConvertToCommonMark(arg1) {
+  return window['go']['main']['App']['ConvertToCommonMark'](arg1);
+}
+
+export function ConvertToPlainText(arg1) {
+  return window['go']['main']['App']['ConvertToPlainText'](arg1);
+}
+
 export function CopyImageToAssets(arg1, arg2) {
   return window['go']['main']['App']['CopyImageToAssets'](arg1, arg2);
 }
 
-export function ExportContentToPDF(arg1) {
-  return window['go']['main']['App']['ExportContentToPDF'](arg1);
+export function EditTable(arg1, arg2, arg3) {
+  return window['go']['main']['App']['EditTable'](arg1, arg2, arg3);
+}
+
+export function ExportBatch(arg1) {
+  return window['go']['main']['App']['ExportBatch'](arg1);
+}
+
+export function ExportContentToPDF(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportContentToPDF'](arg1, arg2, arg3);
+}
+
+export function ExportFolderToEPUB(arg1) {
+  return window['go']['main']['App']['ExportFolderToEPUB'](arg1);
+}
+
+export function ExportFolderToSite(arg1) {
+  return window['go']['main']['App']['ExportFolderToSite'](arg1);
+}
+
+export function ExportSlidesToHTML(arg1, arg2) {
+  return window['go']['main']['App']['ExportSlidesToHTML'](arg1, arg2);
+}
+
+export function ExportSlidesToPDF(arg1, arg2) {
+  return window['go']['main']['App']['ExportSlidesToPDF'](arg1, arg2);
+}
+
+export function ExportTableToCSV(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportTableToCSV'](arg1, arg2, arg3);
+}
+
+export function ExportToCommonMark(arg1, arg2) {
+  return window['go']['main']['App']['ExportToCommonMark'](arg1, arg2);
+}
+
+export function ExportToDOCX(arg1, arg2) {
+  return window['go']['main']['App']['ExportToDOCX'](arg1, arg2);
+}
+
+export function ExportToEPUB(arg1, arg2) {
+  return window['go']['main']['App']['ExportToEPUB'](arg1, arg2);
+}
+
+export function ExportToHTML(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExportToHTML'](arg1, arg2, arg3, arg4);
+}
+
+export function ExportToLaTeX(arg1, arg2) {
+  return window['go']['main']['App']['ExportToLaTeX'](arg1, arg2);
+}
+
+export function ExportToPDF(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportToPDF'](arg1, arg2, arg3);
+}
+
+export function ExportToPlainText(arg1, arg2) {
+  return window['go']['main']['App']['ExportToPlainText'](arg1, arg2);
+}
+
+export function ExportToSelfContainedHTML(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExportToSelfContainedHTML'](arg1, arg2, arg3, arg4);
 }
 
-export function ExportToHTML(arg1) {
-  return window['go']['main']['App']['ExportToHTML'](arg1);
+export function FormatDocument(arg1) {
+  return window['go']['main']['App']['FormatDocument'](arg1);
 }
 
-export function ExportToPDF(arg1) {
-  return window['go']['main']['App']['ExportToPDF'](arg1);
+export function GetBatchFormats() {
+  return window['go']['main']['App']['GetBatchFormats']();
 }
 
 export function GetCurrentFilePath() {
   return window['go']['main']['App']['GetCurrentFilePath']();
 }
 
+export function GetExportTemplates() {
+  return window['go']['main']['App']['GetExportTemplates']();
+}
+
+export function GetExportThemes() {
+  return window['go']['main']['App']['GetExportThemes']();
+}
+
 export function GetFolderTree(arg1) {
   return window['go']['main']['App']['GetFolderTree'](arg1);
 }
@@ -38,18 +150,46 @@ export function GetRecentFiles() {
   return window['go']['main']['App']['GetRecentFiles']();
 }
 
+export function GetSelectionStats(arg1) {
+  return window['go']['main']['App']['GetSelectionStats'](arg1);
+}
+
 export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
 
+export function GetSlides(arg1) {
+  return window['go']['main']['App']['GetSlides'](arg1);
+}
+
 export function GetTableOfContents(arg1) {
   return window['go']['main']['App']['GetTableOfContents'](arg1);
 }
 
+export function GetTrustedFolders() {
+  return window['go']['main']['App']['GetTrustedFolders']();
+}
+
 export function GetWordCount(arg1) {
   return window['go']['main']['App']['GetWordCount'](arg1);
 }
 
+export function ImportCSVTable() {
+  return window['go']['main']['App']['ImportCSVTable']();
+}
+
+export function ImportDocument() {
+  return window['go']['main']['App']['ImportDocument']();
+}
+
+export function IsTrusted(arg1) {
+  return window['go']['main']['App']['IsTrusted'](arg1);
+}
+
+export function LintDocument(arg1, arg2) {
+  return window['go']['main']['App']['LintDocument'](arg1, arg2);
+}
+
 export function OpenFile() {
   return window['go']['main']['App']['OpenFile']();
 }
@@ -70,8 +210,12 @@ export function ReadFileFromFolder(arg1) {
   return window['go']['main']['App']['ReadFileFromFolder'](arg1);
 }
 
-export function RenderMarkdown(arg1) {
-  return window['go']['main']['App']['RenderMarkdown'](arg1);
+export function RenderMarkdown(arg1, arg2) {
+  return window['go']['main']['App']['RenderMarkdown'](arg1, arg2);
+}
+
+export function RevokeTrust(arg1) {
+  return window['go']['main']['App']['RevokeTrust'](arg1);
 }
 
 export function SaveFile(arg1, arg2) {
@@ -90,6 +234,10 @@ export function SearchInDocument(arg1, arg2) {
   return window['go']['main']['App']['SearchInDocument'](arg1, arg2);
 }
 
+export function SetActiveDocument(arg1) {
+  return window['go']['main']['App']['SetActiveDocument'](arg1);
+}
+
 export function StartWatching(arg1) {
   return window['go']['main']['App']['StartWatching'](arg1);
 }
@@ -98,10 +246,18 @@ export function StopWatching() {
   return window['go']['main']['App']['StopWatching']();
 }
 
+export function TableToCSV(arg1, arg2) {
+  return window['go']['main']['App']['TableToCSV'](arg1, arg2);
+}
+
 export function ToggleFullscreen() {
   return window['go']['main']['App']['ToggleFullscreen']();
 }
 
+export function TrustFolder(arg1) {
+  return window['go']['main']['App']['TrustFolder'](arg1);
+}
+
 export function UpdateSettings(arg1) {
   return window['go']['main']['App']['UpdateSettings'](arg1);
 }
@@ -1,3 +1,241 @@
+export namespace analysis {
+	
+	export class Issue {
+	    kind: string;
+	    message: string;
+	    range: markdown.Range;
+	
+	    static createFrom(source: any = {}) {
+	        return new Issue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.message = source["message"];
+	        this.range = this.convertValues(source["range"], markdown.Range);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Scores {
+	    words: number;
+	    sentences: number;
+	    syllables: number;
+	    complexWords: number;
+	    fleschReadingEase: number;
+	    fleschKincaidGrade: number;
+	    gunningFog: number;
+	    averageSentenceLength: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Scores(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.words = source["words"];
+	        this.sentences = source["sentences"];
+	        this.syllables = source["syllables"];
+	        this.complexWords = source["complexWords"];
+	        this.fleschReadingEase = source["fleschReadingEase"];
+	        this.fleschKincaidGrade = source["fleschKincaidGrade"];
+	        this.gunningFog = source["gunningFog"];
+	        this.averageSentenceLength = source["averageSentenceLength"];
+	    }
+	}
+	export class ParagraphReport {
+	    range: markdown.Range;
+	    scores: Scores;
+	    issues: Issue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ParagraphReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.range = this.convertValues(source["range"], markdown.Range);
+	        this.scores = this.convertValues(source["scores"], Scores);
+	        this.issues = this.convertValues(source["issues"], Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Report {
+	    scores: Scores;
+	    paragraphs: ParagraphReport[];
+	    issues: Issue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Report(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.scores = this.convertValues(source["scores"], Scores);
+	        this.paragraphs = this.convertValues(source["paragraphs"], ParagraphReport);
+	        this.issues = this.convertValues(source["issues"], Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace exporter {
+	
+	export class AssetReport {
+	    source: string;
+	    mimeType: string;
+	    originalBytes: number;
+	    embeddedBytes: number;
+	    downscaled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AssetReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.mimeType = source["mimeType"];
+	        this.originalBytes = source["originalBytes"];
+	        this.embeddedBytes = source["embeddedBytes"];
+	        this.downscaled = source["downscaled"];
+	    }
+	}
+	export class BatchResult {
+	    source: string;
+	    output: string;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.output = source["output"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ExportTemplate {
+	    name: string;
+	    builtin: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportTemplate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.builtin = source["builtin"];
+	    }
+	}
+	export class SizeReport {
+	    outputPath: string;
+	    totalBytes: number;
+	    assets: AssetReport[];
+	    external: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SizeReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.outputPath = source["outputPath"];
+	        this.totalBytes = source["totalBytes"];
+	        this.assets = this.convertValues(source["assets"], AssetReport);
+	        this.external = source["external"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Theme {
+	    name: string;
+	    builtin: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Theme(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.builtin = source["builtin"];
+	    }
+	}
+
+}
+
 export namespace filemanager {
 	
 	export class RecentFile {
@@ -79,8 +317,266 @@ export namespace foldermanager {
 
 }
 
+export namespace linkcheck {
+	
+	export class Issue {
+	    kind: string;
+	    problem: string;
+	    target: string;
+	    message: string;
+	    range: markdown.Range;
+	
+	    static createFrom(source: any = {}) {
+	        return new Issue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.problem = source["problem"];
+	        this.target = source["target"];
+	        this.message = source["message"];
+	        this.range = this.convertValues(source["range"], markdown.Range);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DocumentReport {
+	    path: string;
+	    checked: number;
+	    issues: Issue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DocumentReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.checked = source["checked"];
+	        this.issues = this.convertValues(source["issues"], Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class BatchExportRequest {
+	    paths: string[];
+	    folder: string;
+	    include: string[];
+	    exclude: string[];
+	    format: string;
+	    outputDir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchExportRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paths = source["paths"];
+	        this.folder = source["folder"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.format = source["format"];
+	        this.outputDir = source["outputDir"];
+	    }
+	}
+
+}
+
 export namespace markdown {
 	
+	export class Position {
+	    line: number;
+	    column: number;
+	    offset: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Position(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.offset = source["offset"];
+	    }
+	}
+	export class Range {
+	    start: Position;
+	    end: Position;
+	
+	    static createFrom(source: any = {}) {
+	        return new Range(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], Position);
+	        this.end = this.convertValues(source["end"], Position);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Diagnostic {
+	    ruleId: string;
+	    ruleAlias: string;
+	    message: string;
+	    severity: string;
+	    range: Range;
+	
+	    static createFrom(source: any = {}) {
+	        return new Diagnostic(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ruleId = source["ruleId"];
+	        this.ruleAlias = source["ruleAlias"];
+	        this.message = source["message"];
+	        this.severity = source["severity"];
+	        this.range = this.convertValues(source["range"], Range);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TextEdit {
+	    range: Range;
+	    newText: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TextEdit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.range = this.convertValues(source["range"], Range);
+	        this.newText = source["newText"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FormatResult {
+	    text: string;
+	    edits: TextEdit[];
+	    changed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FormatResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.edits = this.convertValues(source["edits"], TextEdit);
+	        this.changed = source["changed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
 	export class SearchResult {
 	    line: number;
 	    column: number;
@@ -101,11 +597,36 @@ export namespace markdown {
 	        this.matchEnd = source["matchEnd"];
 	    }
 	}
+	export class Slide {
+	    markdown: string;
+	    notes: string;
+	    settings: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Slide(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.markdown = source["markdown"];
+	        this.notes = source["notes"];
+	        this.settings = source["settings"];
+	    }
+	}
 	export class Stats {
 	    words: number;
+	    cjkCharacters: number;
 	    characters: number;
+	    charactersNoSpaces: number;
 	    lines: number;
 	    paragraphs: number;
+	    sentences: number;
+	    headings: number;
+	    links: number;
+	    images: number;
+	    codeBlocks: number;
+	    readingTime: number;
+	    speakingTime: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new Stats(source);
@@ -114,9 +635,18 @@ export namespace markdown {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.words = source["words"];
+	        this.cjkCharacters = source["cjkCharacters"];
 	        this.characters = source["characters"];
+	        this.charactersNoSpaces = source["charactersNoSpaces"];
 	        this.lines = source["lines"];
 	        this.paragraphs = source["paragraphs"];
+	        this.sentences = source["sentences"];
+	        this.headings = source["headings"];
+	        this.links = source["links"];
+	        this.images = source["images"];
+	        this.codeBlocks = source["codeBlocks"];
+	        this.readingTime = source["readingTime"];
+	        this.speakingTime = source["speakingTime"];
 	    }
 	}
 	export class TOCItem {
@@ -135,11 +665,57 @@ export namespace markdown {
 	        this.id = source["id"];
 	    }
 	}
+	export class TableEdit {
+	    op: string;
+	    descending: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TableEdit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.op = source["op"];
+	        this.descending = source["descending"];
+	    }
+	}
 
 }
 
 export namespace settings {
 	
+	export class PDFSettings {
+	    paperSize: string;
+	    landscape: boolean;
+	    marginTop: number;
+	    marginBottom: number;
+	    marginLeft: number;
+	    marginRight: number;
+	    scale: number;
+	    printBackground: boolean;
+	    headerFooter: boolean;
+	    headerTemplate: string;
+	    footerTemplate: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PDFSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paperSize = source["paperSize"];
+	        this.landscape = source["landscape"];
+	        this.marginTop = source["marginTop"];
+	        this.marginBottom = source["marginBottom"];
+	        this.marginLeft = source["marginLeft"];
+	        this.marginRight = source["marginRight"];
+	        this.scale = source["scale"];
+	        this.printBackground = source["printBackground"];
+	        this.headerFooter = source["headerFooter"];
+	        this.headerTemplate = source["headerTemplate"];
+	        this.footerTemplate = source["footerTemplate"];
+	    }
+	}
 	export class UserSettings {
 	    theme: string;
 	    fontSize: number;
@@ -155,6 +731,25 @@ export namespace settings {
 	    wordWrap: boolean;
 	    spellCheck: boolean;
 	    openInNewTab: boolean;
+	    statsIncludeCode: boolean;
+	    formatOnSave: boolean;
+	    formatProseWrap: string;
+	    formatLineWidth: number;
+	    checkExternalLinks: boolean;
+	    exportMaxImageWidth: number;
+	    docxReference: string;
+	    epubChapterLevel?: number;
+	    latexTemplate: string;
+	    latexMinted: boolean;
+	    siteTemplate: string;
+	    exportTemplate: string;
+	    templateFields: Record<string, string>;
+	    plainTextWidth?: number;
+	    commonMarkTables: boolean;
+	    commonMarkTaskLists: boolean;
+	    commonMarkFootnotes: boolean;
+	    plainPaste: boolean;
+	    pdf: PDFSettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new UserSettings(source);
@@ -176,7 +771,44 @@ export namespace settings {
 	        this.wordWrap = source["wordWrap"];
 	        this.spellCheck = source["spellCheck"];
 	        this.openInNewTab = source["openInNewTab"];
+	        this.statsIncludeCode = source["statsIncludeCode"];
+	        this.formatOnSave = source["formatOnSave"];
+	        this.formatProseWrap = source["formatProseWrap"];
+	        this.formatLineWidth = source["formatLineWidth"];
+	        this.checkExternalLinks = source["checkExternalLinks"];
+	        this.exportMaxImageWidth = source["exportMaxImageWidth"];
+	        this.docxReference = source["docxReference"];
+	        this.epubChapterLevel = source["epubChapterLevel"];
+	        this.latexTemplate = source["latexTemplate"];
+	        this.latexMinted = source["latexMinted"];
+	        this.siteTemplate = source["siteTemplate"];
+	        this.exportTemplate = source["exportTemplate"];
+	        this.templateFields = source["templateFields"];
+	        this.plainTextWidth = source["plainTextWidth"];
+	        this.commonMarkTables = source["commonMarkTables"];
+	        this.commonMarkTaskLists = source["commonMarkTaskLists"];
+	        this.commonMarkFootnotes = source["commonMarkFootnotes"];
+	        this.plainPaste = source["plainPaste"];
+	        this.pdf = this.convertValues(source["pdf"], PDFSettings);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
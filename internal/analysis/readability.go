@@ -0,0 +1,390 @@
+package analysis
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"markviewpro/internal/markdown"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	IssueLongSentence  = "long-sentence"
+	IssueLongParagraph = "long-paragraph"
+	IssuePassiveVoice  = "passive-voice"
+)
+
+type Options struct {
+	MaxSentenceWords  int `json:"maxSentenceWords"`
+	MaxParagraphWords int `json:"maxParagraphWords"`
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxSentenceWords:  25,
+		MaxParagraphWords: 150,
+	}
+}
+
+// Scores holds the readability formulas for a run of prose.
+type Scores struct {
+	Words                 int     `json:"words"`
+	Sentences             int     `json:"sentences"`
+	Syllables             int     `json:"syllables"`
+	ComplexWords          int     `json:"complexWords"`
+	FleschReadingEase     float64 `json:"fleschReadingEase"`
+	FleschKincaidGrade    float64 `json:"fleschKincaidGrade"`
+	GunningFog            float64 `json:"gunningFog"`
+	AverageSentenceLength float64 `json:"averageSentenceLength"`
+}
+
+type Issue struct {
+	Kind    string         `json:"kind"`
+	Message string         `json:"message"`
+	Range   markdown.Range `json:"range"`
+}
+
+type ParagraphReport struct {
+	Range  markdown.Range `json:"range"`
+	Scores Scores         `json:"scores"`
+	Issues []Issue        `json:"issues"`
+}
+
+type Report struct {
+	Scores     Scores            `json:"scores"`
+	Paragraphs []ParagraphReport `json:"paragraphs"`
+	Issues     []Issue           `json:"issues"`
+}
+
+type Analyzer struct {
+	renderer *markdown.Renderer
+	options  Options
+}
+
+func NewAnalyzer(renderer *markdown.Renderer) *Analyzer {
+	return &Analyzer{
+		renderer: renderer,
+		options:  DefaultOptions(),
+	}
+}
+
+// Analyze scores every prose paragraph of the document. Headings, code,
+// tables and raw HTML are skipped so that they do not skew the formulas.
+func (a *Analyzer) Analyze(content string) Report {
+	source := []byte(content)
+	doc := a.renderer.Parse(source)
+	index := markdown.NewLineIndex(source)
+
+	report := Report{
+		Paragraphs: make([]ParagraphReport, 0),
+		Issues:     make([]Issue, 0),
+	}
+	var total counts
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindParagraph, ast.KindTextBlock:
+		case ast.KindHeading, ast.KindFencedCodeBlock, ast.KindCodeBlock, ast.KindHTMLBlock:
+			return ast.WalkSkipChildren, nil
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		rng, ok := index.BlockRange(n)
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		prose := readProse(n, source)
+		sentences := splitSentences(prose.text)
+		c := countProse(sentences)
+		if c.words == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		total.add(c)
+
+		paragraph := ParagraphReport{
+			Range:  rng,
+			Scores: c.scores(),
+			Issues: a.paragraphIssues(prose, index, rng, sentences, c),
+		}
+		report.Paragraphs = append(report.Paragraphs, paragraph)
+		report.Issues = append(report.Issues, paragraph.Issues...)
+		return ast.WalkSkipChildren, nil
+	})
+
+	report.Scores = total.scores()
+	return report
+}
+
+func (a *Analyzer) paragraphIssues(prose *prose, index *markdown.LineIndex, rng markdown.Range, sentences []sentence, c counts) []Issue {
+	issues := make([]Issue, 0)
+
+	if a.options.MaxParagraphWords > 0 && c.words > a.options.MaxParagraphWords {
+		issues = append(issues, Issue{
+			Kind:    IssueLongParagraph,
+			Message: fmt.Sprintf("Paragraph has %d words (limit %d)", c.words, a.options.MaxParagraphWords),
+			Range:   rng,
+		})
+	}
+
+	if a.options.MaxSentenceWords > 0 {
+		for _, sentence := range sentences {
+			words := len(tokenize(sentence.text))
+			if words > a.options.MaxSentenceWords {
+				issues = append(issues, Issue{
+					Kind:    IssueLongSentence,
+					Message: fmt.Sprintf("Sentence has %d words (limit %d): %q", words, a.options.MaxSentenceWords, preview(sentence.text)),
+					Range:   prose.sourceRange(index, sentence.start, sentence.stop),
+				})
+			}
+		}
+	}
+
+	for _, m := range passiveRegex.FindAllStringSubmatchIndex(prose.text, -1) {
+		if !isParticiple(strings.ToLower(prose.text[m[4]:m[5]])) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Kind:    IssuePassiveVoice,
+			Message: fmt.Sprintf("Possible passive voice: %q", prose.text[m[0]:m[1]]),
+			Range:   prose.sourceRange(index, m[0], m[1]),
+		})
+	}
+
+	return issues
+}
+
+// prose is the text a reader sees in a paragraph, as markdown.PlainText
+// builds it, with the source span behind every byte. Bytes that are not
+// copied from the source, such as the space standing in for a line break,
+// have an empty span at the end of the text before them.
+type prose struct {
+	text         string
+	starts, ends []int
+}
+
+func readProse(block ast.Node, source []byte) *prose {
+	var sb strings.Builder
+	p := &prose{}
+	last := 0
+	if block.Lines().Len() > 0 {
+		last = block.Lines().At(0).Start
+	}
+	add := func(text []byte, start int, fromSource bool) {
+		sb.Write(text)
+		for i := range text {
+			if fromSource {
+				p.starts = append(p.starts, start+i)
+				p.ends = append(p.ends, start+i+1)
+				last = start + i + 1
+			} else {
+				p.starts = append(p.starts, last)
+				p.ends = append(p.ends, last)
+			}
+		}
+	}
+
+	ast.Walk(block, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n == block {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Text:
+			add(source[node.Segment.Start:node.Segment.Stop], node.Segment.Start, true)
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				add([]byte{' '}, 0, false)
+			}
+		case *ast.String:
+			add([]byte(html.UnescapeString(string(node.Value))), 0, false)
+		case *ast.CodeSpan, *ast.Image, *ast.AutoLink, *ast.RawHTML, *east.FootnoteLink, *east.TaskCheckBox:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	p.text = sb.String()
+	return p
+}
+
+// sourceRange returns the source range behind text[start:stop].
+func (p *prose) sourceRange(index *markdown.LineIndex, start, stop int) markdown.Range {
+	return index.Range(p.starts[start], p.ends[stop-1])
+}
+
+var passiveRegex = regexp.MustCompile(`(?i)\b(am|is|are|was|were|be|been|being)\s+(?:\w+ly\s+)?([a-z]+)\b`)
+
+var irregularParticiples = map[string]bool{
+	"been": true, "begun": true, "bitten": true, "broken": true, "brought": true,
+	"built": true, "bought": true, "caught": true, "chosen": true, "done": true,
+	"drawn": true, "driven": true, "eaten": true, "fallen": true, "found": true,
+	"forgotten": true, "given": true, "gone": true, "grown": true, "held": true,
+	"hidden": true, "kept": true, "known": true, "laid": true, "led": true,
+	"left": true, "lost": true, "made": true, "meant": true, "met": true,
+	"paid": true, "put": true, "read": true, "run": true, "said": true,
+	"seen": true, "sent": true, "set": true, "shown": true, "shut": true,
+	"sold": true, "spent": true, "spoken": true, "stolen": true, "taken": true,
+	"taught": true, "thought": true, "thrown": true, "told": true, "understood": true,
+	"won": true, "worn": true, "written": true,
+}
+
+// isParticiple guesses whether word is a past participle. Adjectives such
+// as "need" or "red" are excluded by requiring a longer "-ed" form.
+func isParticiple(word string) bool {
+	if irregularParticiples[word] {
+		return true
+	}
+	return len(word) > 4 && strings.HasSuffix(word, "ed")
+}
+
+type counts struct {
+	words     int
+	sentences int
+	syllables int
+	complex   int
+}
+
+func (c *counts) add(o counts) {
+	c.words += o.words
+	c.sentences += o.sentences
+	c.syllables += o.syllables
+	c.complex += o.complex
+}
+
+func countProse(sentences []sentence) counts {
+	var c counts
+	for _, sentence := range sentences {
+		words := tokenize(sentence.text)
+		if len(words) == 0 {
+			continue
+		}
+		c.sentences++
+		for _, w := range words {
+			c.words++
+			syllables := countSyllables(w)
+			c.syllables += syllables
+			if isComplex(w, syllables) {
+				c.complex++
+			}
+		}
+	}
+	return c
+}
+
+func (c counts) scores() Scores {
+	s := Scores{
+		Words:        c.words,
+		Sentences:    c.sentences,
+		Syllables:    c.syllables,
+		ComplexWords: c.complex,
+	}
+	if c.words == 0 || c.sentences == 0 {
+		return s
+	}
+	wordsPerSentence := float64(c.words) / float64(c.sentences)
+	syllablesPerWord := float64(c.syllables) / float64(c.words)
+	complexRatio := float64(c.complex) / float64(c.words)
+
+	s.AverageSentenceLength = round(wordsPerSentence)
+	s.FleschReadingEase = round(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+	s.FleschKincaidGrade = round(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+	s.GunningFog = round(0.4 * (wordsPerSentence + 100*complexRatio))
+	return s
+}
+
+// sentence is a trimmed sentence of prose and its byte offsets there.
+type sentence struct {
+	text        string
+	start, stop int
+}
+
+// splitSentences splits prose on terminal punctuation followed by a space.
+func splitSentences(prose string) []sentence {
+	var sentences []sentence
+	add := func(start, stop int) {
+		text := strings.TrimLeftFunc(prose[start:stop], unicode.IsSpace)
+		start = stop - len(text)
+		text = strings.TrimRightFunc(text, unicode.IsSpace)
+		if text != "" {
+			sentences = append(sentences, sentence{text, start, start + len(text)})
+		}
+	}
+	start := 0
+	for i, r := range prose {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		if next, _ := utf8.DecodeRuneInString(prose[i+1:]); i+1 < len(prose) && !unicode.IsSpace(next) {
+			continue
+		}
+		add(start, i+1)
+		start = i + 1
+	}
+	add(start, len(prose))
+	return sentences
+}
+
+func tokenize(sentence string) []string {
+	return strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’' && r != '-'
+	})
+}
+
+// countSyllables estimates syllables by counting vowel groups, ignoring a
+// silent trailing "e".
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	return max(count, 1)
+}
+
+// isComplex reports whether a word counts as complex for Gunning Fog:
+// three or more syllables, not counting common inflections, and not a
+// capitalised proper noun.
+func isComplex(word string, syllables int) bool {
+	if syllables < 3 {
+		return false
+	}
+	if r := []rune(word)[0]; unicode.IsUpper(r) {
+		return false
+	}
+	lower := strings.ToLower(word)
+	for _, suffix := range []string{"es", "ed", "ing"} {
+		if strings.HasSuffix(lower, suffix) && countSyllables(strings.TrimSuffix(lower, suffix)) < 3 {
+			return false
+		}
+	}
+	return true
+}
+
+func preview(sentence string) string {
+	runes := []rune(sentence)
+	if len(runes) <= 60 {
+		return sentence
+	}
+	return string(runes[:60]) + "..."
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchFile is one document of a batch export and where it is written.
+type BatchFile struct {
+	Source string `json:"source"`
+	Output string `json:"output"`
+}
+
+// BatchResult is how the export of one file went. Error is empty when it
+// succeeded.
+type BatchResult struct {
+	Source string `json:"source"`
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+// maxBatchWorkers bounds parallel conversions, since PDF exports each run
+// their own Chrome.
+const maxBatchWorkers = 4
+
+var batchExtensions = map[string]string{
+	"html":        ".html",
+	"html-single": ".html",
+	"pdf":         ".pdf",
+	"docx":        ".docx",
+	"epub":        ".epub",
+	"latex":       ".tex",
+	"text":        ".txt",
+	"commonmark":  ".md",
+}
+
+// BatchFormats lists the formats a batch export can write.
+func BatchFormats() []string {
+	formats := make([]string, 0, len(batchExtensions))
+	for format := range batchExtensions {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// BatchPlan places each source under outputDir at the same path relative
+// to root, with the extension of format. An empty root is the deepest
+// folder holding every source.
+func BatchPlan(root string, sources []string, format, outputDir string) ([]BatchFile, error) {
+	ext, ok := batchExtensions[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported batch export format %q", format)
+	}
+	if root == "" {
+		root = commonDir(sources)
+	}
+
+	files := make([]BatchFile, 0, len(sources))
+	for _, source := range sources {
+		rel, err := filepath.Rel(root, source)
+		if err != nil || isOutside(rel) {
+			rel = filepath.Base(source)
+		}
+		files = append(files, BatchFile{
+			Source: source,
+			Output: filepath.Join(outputDir, strings.TrimSuffix(rel, filepath.Ext(rel))+ext),
+		})
+	}
+	return files, nil
+}
+
+// commonDir is the deepest folder that contains every path.
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for dir != filepath.Dir(dir) {
+			rel, err := filepath.Rel(dir, p)
+			if err == nil && !isOutside(rel) {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// isOutside reports whether a path relative to a folder leaves it.
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// RunBatch converts files with a bounded number of workers and calls done
+// from the worker after each file, successful or not. Files not started
+// before ctx is cancelled are skipped. The results are in the order of
+// files.
+func RunBatch(ctx context.Context, files []BatchFile, convert func(ctx context.Context, file BatchFile) error, done func(BatchResult)) []BatchResult {
+	workers := runtime.NumCPU()
+	if workers > maxBatchWorkers {
+		workers = maxBatchWorkers
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]BatchResult, len(files))
+		jobs    = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				file := files[i]
+				result := BatchResult{Source: file.Source, Output: file.Output}
+				var err error
+				if filepath.Clean(file.Output) == filepath.Clean(file.Source) {
+					err = errors.New("the output would replace the source file")
+				} else {
+					err = os.MkdirAll(filepath.Dir(file.Output), 0755)
+				}
+				if err == nil {
+					err = convert(ctx, file)
+				}
+				if err != nil {
+					result.Error = err.Error()
+				}
+				results[i] = result
+				done(result)
+			}
+		}()
+	}
+
+	started := 0
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- i:
+			started++
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results[:started]
+}
@@ -0,0 +1,891 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"markviewpro/internal/localfiles"
+	"markviewpro/internal/markdown"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/go-pdf/fpdf"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	bodyFontSize = 10.5 // points
+	lineSpacing  = 1.45
+	ptToMM       = 25.4 / 72
+)
+
+var headingSizes = [7]float64{0, 22, 17, 14, 12, 11, 10.5}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// ToBuiltinPDF lays out a parsed Markdown document as a PDF without a
+// browser, using the embedded Go fonts or, for text they cannot print, an
+// installed font; see pdfFonts. It covers the common elements and
+// honours the page setup in opts.PDF, but not the export theme.
+func (e *Exporter) ToBuiltinPDF(ctx context.Context, source []byte, doc ast.Node, outputPath string, opts Options) error {
+	fonts, err := pdfFonts(documentText(doc, source, opts))
+	if err != nil {
+		return err
+	}
+	l, err := newPDFLayout(source, opts, fonts)
+	if err != nil {
+		return err
+	}
+	opts.progress("Laying out pages", 10)
+	l.collectTargets(doc)
+	l.pdf.AddPage()
+	blocks, last := doc.ChildCount(), 10
+	for i, n := 0, doc.FirstChild(); n != nil; i, n = i+1, n.NextSibling() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.block(n)
+		if percent := 10 + 80*(i+1)/blocks; percent != last {
+			opts.progress("Laying out pages", percent)
+			last = percent
+		}
+	}
+	opts.progress("Writing file", 95)
+	if err := l.pdf.Error(); err != nil {
+		return fmt.Errorf("PDF export failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := l.pdf.Output(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// textStyle is the inline formatting in effect while writing text.
+type textStyle struct {
+	bold, italic, code, strike bool
+	size                       float64
+	color                      [3]int
+	linkID                     int
+	linkURL                    string
+}
+
+type pdfLayout struct {
+	pdf    *fpdf.Fpdf
+	source []byte
+	opts   Options
+	scale  float64
+	// targets maps heading ids and "fn:N" footnotes to internal links.
+	targets map[string]int
+	// headingStack holds the levels of the enclosing headings, for nesting
+	// bookmarks without gaps.
+	headingStack []int
+	listDepth    int
+}
+
+func newPDFLayout(source []byte, opts Options, fonts []pdfFont) (*pdfLayout, error) {
+	size, ok := paperSizes[strings.ToLower(opts.PDF.PaperSize)]
+	if opts.PDF.PaperSize == "" {
+		size, ok = paperSizes["a4"], true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown paper size %q", opts.PDF.PaperSize)
+	}
+	scale := opts.PDF.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	orientation := "P"
+	if opts.PDF.Landscape {
+		orientation = "L"
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: orientation,
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: size[0] * mmPerInch, Ht: size[1] * mmPerInch},
+	})
+	for _, f := range fonts {
+		pdf.AddUTF8FontFromBytes(f.family, f.style, f.ttf)
+	}
+	pdf.SetMargins(opts.PDF.MarginLeft, opts.PDF.MarginTop, opts.PDF.MarginRight)
+	pdf.SetAutoPageBreak(true, opts.PDF.MarginBottom)
+	pdf.SetCellMargin(0)
+	pdf.SetTitle(opts.Title, true)
+	pdf.SetCreator("MarkViewPro", true)
+	pdf.SetFont("sans", "", bodyFontSize*scale)
+
+	l := &pdfLayout{pdf: pdf, source: source, opts: opts, scale: scale, targets: make(map[string]int)}
+	if opts.PDF.HeaderFooter {
+		pdf.AliasNbPages("{nb}")
+		pdf.SetHeaderFuncMode(func() { l.pageMarginText(opts.PDF.HeaderTemplate, true) }, true)
+		pdf.SetFooterFunc(func() { l.pageMarginText(opts.PDF.FooterTemplate, false) })
+	}
+	return l, nil
+}
+
+// pageMarginText prints a header or footer template in the page margin.
+func (l *pdfLayout) pageMarginText(tmpl string, top bool) {
+	if tmpl == "" {
+		return
+	}
+	text := strings.NewReplacer(
+		"{title}", l.opts.Title,
+		"{date}", time.Now().Format("January 2, 2006"),
+		"{page}", strconv.Itoa(l.pdf.PageNo()),
+		"{pages}", "{nb}",
+		"{url}", "",
+	).Replace(tmpl)
+	text = html.UnescapeString(htmlTagRegex.ReplaceAllString(text, ""))
+
+	left, topMargin, right, bottom := l.pdf.GetMargins()
+	pageW, pageH := l.pdf.GetPageSize()
+	y := topMargin/2 - 2
+	if !top {
+		y = pageH - bottom/2 - 2
+	}
+	l.pdf.SetFont("sans", "", 8)
+	l.pdf.SetTextColor(102, 102, 102)
+	l.pdf.SetXY(left, y)
+	l.pdf.CellFormat(pageW-left-right, 4, text, "", 0, "C", false, 0, "")
+}
+
+// collectTargets creates link destinations for every heading and footnote
+// up front, so links can point forward in the document.
+func (l *pdfLayout) collectTargets(doc ast.Node) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			if id := headingID(n); id != "" {
+				l.targets[id] = l.pdf.AddLink()
+			}
+		case *east.Footnote:
+			l.targets[footnoteTarget(n.Index)] = l.pdf.AddLink()
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func headingID(n *ast.Heading) string {
+	if id, ok := n.AttributeString("id"); ok {
+		if b, ok := id.([]byte); ok {
+			return string(b)
+		}
+	}
+	return ""
+}
+
+func footnoteTarget(index int) string {
+	return "fn:" + strconv.Itoa(index)
+}
+
+func (l *pdfLayout) lineHeight(size float64) float64 {
+	return size * ptToMM * lineSpacing
+}
+
+func (l *pdfLayout) bodySize() float64 {
+	return bodyFontSize * l.scale
+}
+
+func (l *pdfLayout) contentWidth() float64 {
+	left, _, right, _ := l.pdf.GetMargins()
+	pageW, _ := l.pdf.GetPageSize()
+	return pageW - left - right
+}
+
+// ensureSpace starts a new page unless h millimetres fit on this one.
+func (l *pdfLayout) ensureSpace(h float64) {
+	_, pageH := l.pdf.GetPageSize()
+	_, _, _, bottom := l.pdf.GetMargins()
+	if l.pdf.GetY()+h > pageH-bottom {
+		l.pdf.AddPage()
+	}
+}
+
+func (l *pdfLayout) newLine() {
+	left, _, _, _ := l.pdf.GetMargins()
+	if l.pdf.GetX() > left+0.01 {
+		l.pdf.Ln(l.lineHeight(l.bodySize()))
+	}
+}
+
+func (l *pdfLayout) gap(factor float64) {
+	l.pdf.Ln(l.lineHeight(l.bodySize()) * factor)
+}
+
+func (l *pdfLayout) blocks(parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		l.block(n)
+	}
+}
+
+func (l *pdfLayout) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		l.heading(n)
+	case *ast.Paragraph:
+		l.inlines(n, textStyle{size: l.bodySize()})
+		l.newLine()
+		l.gap(0.5)
+	case *ast.TextBlock:
+		l.inlines(n, textStyle{size: l.bodySize()})
+		l.newLine()
+		if n.NextSibling() != nil {
+			l.gap(0.25)
+		}
+	case *ast.List:
+		l.list(n)
+	case *ast.Blockquote:
+		l.blockquote(n)
+	case *ast.FencedCodeBlock:
+		l.codeBlock(n.Lines(), string(n.Language(l.source)))
+	case *ast.CodeBlock:
+		l.codeBlock(n.Lines(), "")
+	case *markdown.MathBlock:
+		// Math prints as its TeX source.
+		l.codeBlock(n.Lines(), "latex")
+	case *ast.ThematicBreak:
+		l.rule()
+	case *ast.HTMLBlock:
+		// Raw HTML has no layout outside a browser.
+	case *east.Table:
+		l.table(n)
+	case *east.FootnoteList:
+		l.footnotes(n)
+	default:
+		l.blocks(n)
+	}
+}
+
+func (l *pdfLayout) heading(n *ast.Heading) {
+	size := headingSizes[n.Level] * l.scale
+	lh := l.lineHeight(size)
+	if n.PreviousSibling() != nil {
+		l.pdf.Ln(lh * 0.5)
+	}
+	// Keep a heading with at least a couple of lines of what follows.
+	l.ensureSpace(lh + 3*l.lineHeight(l.bodySize()))
+
+	for len(l.headingStack) > 0 && l.headingStack[len(l.headingStack)-1] >= n.Level {
+		l.headingStack = l.headingStack[:len(l.headingStack)-1]
+	}
+	l.pdf.SetFont("sans", "B", size)
+	l.pdf.Bookmark(inlineText(n, l.source), len(l.headingStack), -1)
+	l.headingStack = append(l.headingStack, n.Level)
+	if link, ok := l.targets[headingID(n)]; ok {
+		l.pdf.SetLink(link, -1, -1)
+	}
+
+	l.inlines(n, textStyle{bold: true, size: size})
+	l.pdf.Ln(lh)
+	if n.Level <= 2 {
+		left, _, _, _ := l.pdf.GetMargins()
+		y := l.pdf.GetY()
+		l.pdf.SetDrawColor(216, 222, 228)
+		l.pdf.SetLineWidth(0.3)
+		l.pdf.Line(left, y, left+l.contentWidth(), y)
+		l.pdf.Ln(2)
+	}
+	l.gap(0.25)
+}
+
+func (l *pdfLayout) list(n *ast.List) {
+	bullets := []string{"•", "◦", "▪"}
+	left, _, _, _ := l.pdf.GetMargins()
+	size := l.bodySize()
+	number := n.Start
+	if number == 0 {
+		number = 1
+	}
+
+	l.listDepth++
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := bullets[min(l.listDepth-1, len(bullets)-1)]
+		if n.IsOrdered() {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+		l.ensureSpace(l.lineHeight(size))
+		l.pdf.SetFont("sans", "", size)
+		l.pdf.SetTextColor(36, 41, 47)
+		l.pdf.SetX(left)
+		l.pdf.CellFormat(6, l.lineHeight(size), marker, "", 0, "L", false, 0, "")
+
+		l.pdf.SetLeftMargin(left + 6)
+		l.blocks(item)
+		l.pdf.SetLeftMargin(left)
+		l.pdf.SetX(left)
+	}
+	l.listDepth--
+	if l.listDepth == 0 {
+		l.gap(0.5)
+	}
+}
+
+func (l *pdfLayout) blockquote(n *ast.Blockquote) {
+	left, _, _, _ := l.pdf.GetMargins()
+	startPage, startY := l.pdf.PageNo(), l.pdf.GetY()
+
+	l.pdf.SetLeftMargin(left + 5)
+	l.pdf.SetX(left + 5)
+	l.blocks(n)
+	l.pdf.SetLeftMargin(left)
+	l.pdf.SetX(left)
+
+	// The bar is drawn on the page where the quote ends.
+	_, top, _, _ := l.pdf.GetMargins()
+	if l.pdf.PageNo() != startPage {
+		startY = top
+	}
+	l.pdf.SetDrawColor(208, 215, 222)
+	l.pdf.SetLineWidth(1)
+	l.pdf.Line(left+1, startY, left+1, l.pdf.GetY()-l.lineHeight(l.bodySize())*0.5)
+}
+
+func (l *pdfLayout) rule() {
+	left, _, _, _ := l.pdf.GetMargins()
+	l.gap(0.25)
+	y := l.pdf.GetY()
+	l.pdf.SetDrawColor(216, 222, 228)
+	l.pdf.SetLineWidth(0.5)
+	l.pdf.Line(left, y, left+l.contentWidth(), y)
+	l.gap(0.75)
+}
+
+// inlines writes the inline children of n as flowing text.
+func (l *pdfLayout) inlines(n ast.Node, style textStyle) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		l.inline(c, style)
+	}
+}
+
+func (l *pdfLayout) inline(n ast.Node, style textStyle) {
+	switch n := n.(type) {
+	case *ast.Text:
+		l.write(string(n.Segment.Value(l.source)), style)
+		if n.HardLineBreak() || n.SoftLineBreak() {
+			// The HTML renderer uses hard wraps, so soft breaks are kept too.
+			l.write("\n", style)
+		}
+	case *ast.String:
+		l.write(stringValue(n), style)
+	case *ast.CodeSpan:
+		style.code = true
+		l.write(inlineText(n, l.source), style)
+	case *markdown.MathInline:
+		style.code = true
+		l.write(mathText(n), style)
+	case *ast.Emphasis:
+		if n.Level >= 2 {
+			style.bold = true
+		} else {
+			style.italic = true
+		}
+		l.inlines(n, style)
+	case *east.Strikethrough:
+		style.strike = true
+		l.inlines(n, style)
+	case *ast.Link:
+		l.inlines(n, l.linkStyle(style, string(n.Destination)))
+	case *ast.AutoLink:
+		url := string(n.URL(l.source))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(url, "mailto:") {
+			url = "mailto:" + url
+		}
+		l.write(string(n.Label(l.source)), l.linkStyle(style, url))
+	case *ast.Image:
+		l.image(n, style)
+	case *ast.RawHTML:
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			if strings.HasPrefix(strings.ToLower(string(seg.Value(l.source))), "<br") {
+				l.write("\n", style)
+			}
+		}
+	case *east.TaskCheckBox:
+		box := "[ ] "
+		if n.IsChecked {
+			box = "[x] "
+		}
+		style.code = true
+		l.write(box, style)
+	case *east.FootnoteLink:
+		style.size *= 0.7
+		style.color = [3]int{9, 105, 218}
+		style.linkID = l.targets[footnoteTarget(n.Index)]
+		l.write(strconv.Itoa(n.Index), style)
+	case *east.FootnoteBacklink:
+	default:
+		l.inlines(n, style)
+	}
+}
+
+// linkStyle points text at an in-document target or an external URL.
+// Relative links to other files have no meaning in a PDF and stay plain.
+func (l *pdfLayout) linkStyle(style textStyle, dest string) textStyle {
+	if strings.HasPrefix(dest, "#") {
+		if link, ok := l.targets[strings.TrimPrefix(dest, "#")]; ok {
+			style.linkID = link
+			style.color = [3]int{9, 105, 218}
+		}
+		return style
+	}
+	if i := strings.Index(dest, ":"); i > 0 && !strings.ContainsAny(dest[:i], "/?#") {
+		style.linkURL = dest
+		style.color = [3]int{9, 105, 218}
+	}
+	return style
+}
+
+func (l *pdfLayout) write(text string, style textStyle) {
+	if text == "" {
+		return
+	}
+	family, fontStyle := "sans", ""
+	if style.code {
+		family = "mono"
+	}
+	if style.bold {
+		fontStyle += "B"
+	}
+	if style.italic {
+		fontStyle += "I"
+	}
+	if style.strike {
+		fontStyle += "S"
+	}
+	if style.linkID != 0 || style.linkURL != "" {
+		fontStyle += "U"
+	}
+	size := style.size
+	if style.code {
+		size *= 0.9
+	}
+	l.pdf.SetFont(family, fontStyle, size)
+	if style.color == [3]int{} {
+		l.pdf.SetTextColor(36, 41, 47)
+	} else {
+		l.pdf.SetTextColor(style.color[0], style.color[1], style.color[2])
+	}
+
+	// Lines keep the height of the surrounding text, whatever the font.
+	lh := l.lineHeight(l.bodySize())
+	if style.size > l.bodySize() {
+		lh = l.lineHeight(style.size)
+	}
+	switch {
+	case style.linkID != 0:
+		l.pdf.WriteLinkID(lh, text, style.linkID)
+	case style.linkURL != "":
+		l.pdf.WriteLinkString(lh, text, style.linkURL)
+	default:
+		l.pdf.Write(lh, text)
+	}
+}
+
+// inlineText returns the plain text of the inline children of n.
+func inlineText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.WriteString(stringValue(c))
+		case *markdown.MathInline:
+			b.WriteString(mathText(c))
+		case *east.TaskCheckBox:
+			if c.IsChecked {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// stringValue returns the text of a String node. The typographer stores
+// its substitutions as HTML entities.
+func stringValue(n *ast.String) string {
+	if n.IsCode() {
+		return html.UnescapeString(string(n.Value))
+	}
+	return string(n.Value)
+}
+
+// mathText returns inline math as written, with its delimiters.
+func mathText(n *markdown.MathInline) string {
+	if n.Display {
+		return "$$" + string(n.Literal) + "$$"
+	}
+	return "$" + string(n.Literal) + "$"
+}
+
+type codeSpan struct {
+	text  string
+	color [3]int
+	bold  bool
+}
+
+// highlightLines splits code into lines of colored spans using the light
+// GitHub style, which prints better than the preview's dark one.
+func highlightLines(code, language string) [][]codeSpan {
+	lexer := lexers.Get(language)
+	if language == "" || lexer == nil {
+		lexer = lexers.Fallback
+	}
+	style := styles.Get("github")
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+
+	lines := [][]codeSpan{nil}
+	if err != nil {
+		for i, line := range strings.Split(code, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			lines[i] = append(lines[i], codeSpan{text: line, color: [3]int{36, 41, 47}})
+		}
+		return lines
+	}
+	for _, tok := range iterator.Tokens() {
+		entry := style.Get(tok.Type)
+		span := codeSpan{color: [3]int{36, 41, 47}, bold: entry.Bold == chroma.Yes}
+		if entry.Colour.IsSet() {
+			span.color = [3]int{int(entry.Colour.Red()), int(entry.Colour.Green()), int(entry.Colour.Blue())}
+		}
+		for i, part := range strings.Split(tok.Value, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				span.text = part
+				lines[len(lines)-1] = append(lines[len(lines)-1], span)
+			}
+		}
+	}
+	return lines
+}
+
+func (l *pdfLayout) codeBlock(segments *text.Segments, language string) {
+	var code strings.Builder
+	for i := 0; i < segments.Len(); i++ {
+		seg := segments.At(i)
+		code.Write(seg.Value(l.source))
+	}
+	text := strings.TrimSuffix(strings.ReplaceAll(code.String(), "\t", "    "), "\n")
+
+	size := l.bodySize() * 0.85
+	lh := l.lineHeight(size) * 0.9
+	const pad = 3.0
+	left, _, _, _ := l.pdf.GetMargins()
+	width := l.contentWidth()
+	l.pdf.SetFont("mono", "", size)
+	perLine := max(int((width-2*pad)/l.pdf.GetStringWidth("M")), 10)
+
+	// Wrap long lines by character count; the font is monospaced.
+	var rows [][]codeSpan
+	for _, line := range highlightLines(text, language) {
+		row, count := []codeSpan(nil), 0
+		for _, span := range line {
+			runes := []rune(span.text)
+			for len(runes) > 0 {
+				if count == perLine {
+					rows = append(rows, row)
+					row, count = nil, 0
+				}
+				n := min(len(runes), perLine-count)
+				part := span
+				part.text = string(runes[:n])
+				row = append(row, part)
+				count += n
+				runes = runes[n:]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	l.newLine()
+	l.pdf.SetFillColor(246, 248, 250)
+	l.ensureSpace(lh + 2*pad)
+	l.pdf.Rect(left, l.pdf.GetY(), width, pad, "F")
+	l.pdf.SetY(l.pdf.GetY() + pad)
+	for _, row := range rows {
+		l.ensureSpace(lh + pad)
+		y := l.pdf.GetY()
+		l.pdf.Rect(left, y, width, lh, "F")
+		l.pdf.SetXY(left+pad, y)
+		for _, span := range row {
+			fontStyle := ""
+			if span.bold {
+				fontStyle = "B"
+			}
+			l.pdf.SetFont("mono", fontStyle, size)
+			l.pdf.SetTextColor(span.color[0], span.color[1], span.color[2])
+			l.pdf.CellFormat(l.pdf.GetStringWidth(span.text), lh, span.text, "", 0, "L", false, 0, "")
+		}
+		l.pdf.SetXY(left, y+lh)
+	}
+	l.pdf.Rect(left, l.pdf.GetY(), width, pad, "F")
+	l.pdf.SetY(l.pdf.GetY() + pad)
+	l.pdf.SetX(left)
+	l.gap(0.5)
+}
+
+// image places an image on its own line, scaled to fit the text column.
+// Formats a PDF cannot hold, and remote images, print their alt text.
+func (l *pdfLayout) image(n *ast.Image, style textStyle) {
+	alt := inlineText(n, l.source)
+	name, data, ok := l.loadImage(string(n.Destination))
+	if !ok {
+		style.italic = true
+		style.color = [3]int{102, 102, 102}
+		l.write("["+alt+"]", style)
+		return
+	}
+
+	info := l.pdf.GetImageInfo(name)
+	if info == nil {
+		imageType := "PNG"
+		if http.DetectContentType(data) == "image/jpeg" {
+			imageType = "JPG"
+		}
+		info = l.pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+		if info == nil || l.pdf.Err() {
+			return
+		}
+	}
+
+	// Images print at 96 dpi, like in a browser, but never wider than the
+	// column or taller than a page.
+	_, top, _, bottom := l.pdf.GetMargins()
+	_, pageH := l.pdf.GetPageSize()
+	w := info.Width() * 25.4 / 96
+	h := info.Height() * 25.4 / 96
+	if maxW := l.contentWidth(); w > maxW {
+		h, w = h*maxW/w, maxW
+	}
+	if maxH := pageH - top - bottom; h > maxH {
+		w, h = w*maxH/h, maxH
+	}
+
+	l.newLine()
+	l.ensureSpace(h)
+	left, _, _, _ := l.pdf.GetMargins()
+	y := l.pdf.GetY()
+	l.pdf.ImageOptions(name, left, y, w, h, false, fpdf.ImageOptions{}, style.linkID, style.linkURL)
+	l.pdf.SetXY(left, y+h+1)
+}
+
+// loadImage reads a local image from the document's folder or the
+// workspace for embedding. JPEG files are used as they are; other formats
+// are decoded and re-encoded as 8-bit PNG, which fpdf reads reliably.
+func (l *pdfLayout) loadImage(dest string) (string, []byte, bool) {
+	path, ok := localfiles.Resolve(l.opts.BaseDir, dest)
+	if !ok || !withinRoots(path, l.opts.BaseDir, l.opts.Workspace) {
+		return "", nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, false
+	}
+	if http.DetectContentType(data) == "image/jpeg" {
+		return path, data, true
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, false
+	}
+	rgba := image.NewNRGBA(src.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgba); err != nil {
+		return "", nil, false
+	}
+	return path, buf.Bytes(), true
+}
+
+func (l *pdfLayout) table(n *east.Table) {
+	const pad = 1.5
+	size := l.bodySize() * 0.9
+	lh := l.lineHeight(size)
+	left, _, _, _ := l.pdf.GetMargins()
+	width := l.contentWidth()
+
+	var rows [][]string
+	var header []string
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, inlineText(cell, l.source))
+		}
+		if _, ok := row.(*east.TableHeader); ok {
+			header = cells
+		} else {
+			rows = append(rows, cells)
+		}
+	}
+	columns := len(n.Alignments)
+	if columns == 0 {
+		return
+	}
+
+	// Columns get their natural width when everything fits; otherwise the
+	// space is shared in proportion, with a floor so no column vanishes.
+	natural := make([]float64, columns)
+	measure := func(cells []string, bold bool) {
+		fontStyle := ""
+		if bold {
+			fontStyle = "B"
+		}
+		l.pdf.SetFont("sans", fontStyle, size)
+		for i, c := range cells {
+			if i < columns {
+				natural[i] = max(natural[i], l.pdf.GetStringWidth(c)+2*pad+0.5)
+			}
+		}
+	}
+	measure(header, true)
+	for _, r := range rows {
+		measure(r, false)
+	}
+	total := 0.0
+	for _, w := range natural {
+		total += w
+	}
+	widths := natural
+	if total > width {
+		floor := min(width/float64(columns), 15)
+		widths = make([]float64, columns)
+		rest := width - floor*float64(columns)
+		for i, w := range natural {
+			widths[i] = floor + rest*w/total
+		}
+	}
+
+	drawRow := func(cells []string, bold bool) {
+		fontStyle := ""
+		if bold {
+			fontStyle = "B"
+		}
+		l.pdf.SetFont("sans", fontStyle, size)
+		lines := make([][]string, columns)
+		height := 0
+		for i := 0; i < columns; i++ {
+			text := ""
+			if i < len(cells) {
+				text = cells[i]
+			}
+			lines[i] = l.pdf.SplitText(text, widths[i]-2*pad)
+			height = max(height, len(lines[i]))
+		}
+		rowH := float64(max(height, 1))*lh + 2*pad
+		l.ensureSpace(rowH)
+
+		y := l.pdf.GetY()
+		x := left
+		l.pdf.SetDrawColor(208, 215, 222)
+		l.pdf.SetLineWidth(0.2)
+		l.pdf.SetTextColor(36, 41, 47)
+		for i := 0; i < columns; i++ {
+			if bold {
+				l.pdf.SetFillColor(246, 248, 250)
+				l.pdf.Rect(x, y, widths[i], rowH, "FD")
+			} else {
+				l.pdf.Rect(x, y, widths[i], rowH, "D")
+			}
+			align := "L"
+			switch n.Alignments[i] {
+			case east.AlignCenter:
+				align = "C"
+			case east.AlignRight:
+				align = "R"
+			}
+			for j, line := range lines[i] {
+				l.pdf.SetXY(x+pad, y+pad+float64(j)*lh)
+				l.pdf.CellFormat(widths[i]-2*pad, lh, line, "", 0, align, false, 0, "")
+			}
+			x += widths[i]
+		}
+		l.pdf.SetXY(left, y+rowH)
+	}
+
+	l.newLine()
+	drawRow(header, true)
+	for _, r := range rows {
+		page := l.pdf.PageNo()
+		l.ensureSpace(lh + 2*pad)
+		if l.pdf.PageNo() != page {
+			// Repeat the header on every page the table spans.
+			drawRow(header, true)
+		}
+		drawRow(r, false)
+	}
+	l.gap(0.5)
+}
+
+func (l *pdfLayout) footnotes(n *east.FootnoteList) {
+	l.gap(0.5)
+	left, _, _, _ := l.pdf.GetMargins()
+	y := l.pdf.GetY()
+	l.pdf.SetDrawColor(216, 222, 228)
+	l.pdf.SetLineWidth(0.3)
+	l.pdf.Line(left, y, left+l.contentWidth()/3, y)
+	l.gap(0.5)
+
+	saved := l.scale
+	l.scale *= 0.85
+	for fn := n.FirstChild(); fn != nil; fn = fn.NextSibling() {
+		note, ok := fn.(*east.Footnote)
+		if !ok {
+			continue
+		}
+		l.ensureSpace(l.lineHeight(l.bodySize()))
+		if link, ok := l.targets[footnoteTarget(note.Index)]; ok {
+			l.pdf.SetLink(link, -1, -1)
+		}
+		l.pdf.SetFont("sans", "", l.bodySize())
+		l.pdf.SetTextColor(36, 41, 47)
+		l.pdf.SetX(left)
+		l.pdf.CellFormat(6, l.lineHeight(l.bodySize()), strconv.Itoa(note.Index)+".", "", 0, "L", false, 0, "")
+		l.pdf.SetLeftMargin(left + 6)
+		l.blocks(note)
+		l.pdf.SetLeftMargin(left)
+		l.pdf.SetX(left)
+	}
+	l.scale = saved
+}
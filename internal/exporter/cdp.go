@@ -0,0 +1,340 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// cdpTimeout bounds browser start-up and every DevTools round trip.
+const cdpTimeout = 60 * time.Second
+
+var devToolsURLRegex = regexp.MustCompile(`DevTools listening on (ws://\S+)`)
+
+// browser is a headless Chrome process driven over the DevTools protocol.
+type browser struct {
+	cmd    *exec.Cmd
+	conn   *cdpConn
+	wsURL  *url.URL
+	stderr *outputTail
+}
+
+// outputTail keeps the last lines a process wrote, for error messages.
+type outputTail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+const outputTailLines = 10
+
+func (t *outputTail) add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > outputTailLines {
+		t.lines = t.lines[len(t.lines)-outputTailLines:]
+	}
+}
+
+func (t *outputTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.lines, "\n")
+}
+
+// launchBrowser starts Chrome with remote debugging on a free port and
+// connects to it. profileDir must be a fresh, writable folder. Chrome is
+// killed when ctx is done.
+func launchBrowser(ctx context.Context, chromePath, profileDir string) (*browser, error) {
+	cmd := exec.CommandContext(ctx, chromePath,
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--remote-debugging-port=0",
+		"--user-data-dir="+profileDir,
+		"about:blank",
+	)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start Chrome: %w", err)
+	}
+
+	b := &browser{cmd: cmd, stderr: &outputTail{}}
+	found := make(chan string, 1)
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if m := devToolsURLRegex.FindStringSubmatch(line); m != nil {
+				select {
+				case found <- m[1]:
+				default:
+				}
+				continue
+			}
+			b.stderr.add(line)
+		}
+		// Keep draining so Chrome never blocks on a full pipe.
+		io.Copy(io.Discard, stderr)
+	}()
+
+	kill := func(err error) (*browser, error) {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, b.fail(ctx, err)
+	}
+
+	var wsURL string
+	select {
+	case wsURL = <-found:
+	case <-exited:
+		return kill(fmt.Errorf("Chrome exited during start-up"))
+	case <-ctx.Done():
+		return kill(ctx.Err())
+	case <-time.After(cdpTimeout):
+		return kill(fmt.Errorf("Chrome did not start within %s", cdpTimeout))
+	}
+
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return kill(err)
+	}
+	if b.conn, err = dialCDP(ctx, wsURL); err != nil {
+		return kill(err)
+	}
+	b.wsURL = u
+	return b, nil
+}
+
+// fail adds what Chrome last wrote to stderr to err, which usually says why
+// it crashed or refused to start. Cancellation is reported as it is.
+func (b *browser) fail(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if out := b.stderr.String(); out != "" {
+		return fmt.Errorf("%w\nChrome output:\n%s", err, out)
+	}
+	return err
+}
+
+func (b *browser) Close() {
+	b.conn.call("Browser.close", nil, nil)
+	b.conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		b.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		b.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// openPage creates a tab, loads pageURL in it and waits for the load event.
+func (b *browser) openPage(ctx context.Context, pageURL string) (*cdpConn, error) {
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := b.conn.call("Target.createTarget", map[string]interface{}{"url": "about:blank"}, &target); err != nil {
+		return nil, err
+	}
+
+	pageWS := url.URL{Scheme: "ws", Host: b.wsURL.Host, Path: "/devtools/page/" + target.TargetID}
+	page, err := dialCDP(ctx, pageWS.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := page.call("Page.enable", nil, nil); err != nil {
+		page.Close()
+		return nil, err
+	}
+	var nav struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := page.call("Page.navigate", map[string]interface{}{"url": pageURL}, &nav); err != nil {
+		page.Close()
+		return nil, err
+	}
+	if nav.ErrorText != "" {
+		page.Close()
+		return nil, fmt.Errorf("failed to load page: %s", nav.ErrorText)
+	}
+	if err := page.waitEvent("Page.loadEventFired"); err != nil {
+		page.Close()
+		return nil, err
+	}
+	return page, nil
+}
+
+// printToPDF loads htmlPath in headless Chrome and prints it with opts.
+func printToPDF(ctx context.Context, chromePath, htmlPath, profileDir string, opts Options) ([]byte, error) {
+	params, err := opts.PDF.printParams()
+	if err != nil {
+		return nil, err
+	}
+
+	opts.progress("Starting browser", 20)
+	b, err := launchBrowser(ctx, chromePath, profileDir)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	opts.progress("Loading document", 40)
+	page, err := b.openPage(ctx, pathToFileURL(htmlPath))
+	if err != nil {
+		return nil, b.fail(ctx, err)
+	}
+	defer page.Close()
+
+	opts.progress("Printing", 60)
+	var result struct {
+		Data string `json:"data"`
+	}
+	if err := page.call("Page.printToPDF", params, &result); err != nil {
+		return nil, b.fail(ctx, fmt.Errorf("PDF export failed: %w", err))
+	}
+	return base64.StdEncoding.DecodeString(result.Data)
+}
+
+// cdpConn is a DevTools protocol session over a websocket. Calls are made
+// one at a time; events that arrive meanwhile are kept for waitEvent. The
+// websocket is closed when ctx is done, which fails any pending call.
+type cdpConn struct {
+	ws     *websocket.Conn
+	ctx    context.Context
+	stop   func() bool
+	nextID int
+	events []string
+}
+
+type cdpMessage struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func dialCDP(ctx context.Context, wsURL string) (*cdpConn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: cdpTimeout}
+	ws, _, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to connect to Chrome: %w", err)
+	}
+	c := &cdpConn{ws: ws, ctx: ctx}
+	c.stop = context.AfterFunc(ctx, func() { ws.Close() })
+	return c, nil
+}
+
+func (c *cdpConn) Close() error {
+	c.stop()
+	return c.ws.Close()
+}
+
+func (c *cdpConn) call(method string, params interface{}, result interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	if params == nil {
+		params = struct{}{}
+	}
+	c.ws.SetWriteDeadline(time.Now().Add(cdpTimeout))
+	if err := c.ws.WriteJSON(map[string]interface{}{"id": id, "method": method, "params": params}); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		if msg.ID != id {
+			if msg.Method != "" {
+				c.events = append(c.events, msg.Method)
+			}
+			continue
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, result)
+		}
+		return nil
+	}
+}
+
+func (c *cdpConn) waitEvent(method string) error {
+	for i, e := range c.events {
+		if e == method {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return nil
+		}
+	}
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return fmt.Errorf("waiting for %s: %w", method, err)
+		}
+		if msg.Method == method {
+			return nil
+		}
+	}
+}
+
+func (c *cdpConn) read() (cdpMessage, error) {
+	var msg cdpMessage
+	c.ws.SetReadDeadline(time.Now().Add(cdpTimeout))
+	err := c.ws.ReadJSON(&msg)
+	if err != nil && c.ctx.Err() != nil {
+		return msg, c.ctx.Err()
+	}
+	return msg, err
+}
+
+// removeTempDir deletes an export's temporary folder, retrying briefly
+// because Chrome may still hold profile files open right after exiting.
+func removeTempDir(dir string) {
+	for i := 0; i < 5; i++ {
+		if err := os.RemoveAll(dir); err == nil {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
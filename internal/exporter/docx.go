@@ -0,0 +1,810 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"markviewpro/internal/localfiles"
+	"markviewpro/internal/markdown"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	twipsPerInch = 1440
+	emuPerPixel  = 9525 // at 96 dpi
+	emuPerTwip   = 635
+	docxIndent   = 720 // twips per list level
+)
+
+var styleIDRegex = regexp.MustCompile(`w:styleId="([^"]*)"`)
+
+// ToDOCX writes a parsed Markdown document as a Word document. Page size and
+// margins follow opts.PDF. When opts.ReferenceDocx is set, its styles and
+// theme are used, so the output matches a house template.
+func (e *Exporter) ToDOCX(ctx context.Context, source []byte, doc ast.Node, outputPath string, opts Options) error {
+	w, err := newDocxWriter(source, opts)
+	if err != nil {
+		return err
+	}
+	var styles, theme []byte
+	if opts.ReferenceDocx != "" {
+		opts.progress("Reading reference document", 5)
+		if styles, theme, err = referenceStyles(opts.ReferenceDocx); err != nil {
+			return fmt.Errorf("failed to read reference document: %w", err)
+		}
+	}
+
+	opts.progress("Converting document", 10)
+	w.collectBookmarks(doc)
+	blocks, last := doc.ChildCount(), 10
+	for i, n := 0, doc.FirstChild(); n != nil; i, n = i+1, n.NextSibling() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w.block(n, docxContext{})
+		if percent := 10 + 80*(i+1)/blocks; percent != last {
+			opts.progress("Converting document", percent)
+			last = percent
+		}
+	}
+
+	opts.progress("Writing file", 95)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := w.writePackage(f, styles, theme); err != nil {
+		f.Close()
+		return fmt.Errorf("DOCX export failed: %w", err)
+	}
+	return f.Close()
+}
+
+// referenceStyles returns the styles and theme parts of a reference .docx.
+// The theme is nil when the document has none.
+func referenceStyles(path string) ([]byte, []byte, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	var styles, theme []byte
+	for _, f := range r.File {
+		switch f.Name {
+		case "word/styles.xml":
+			styles, err = readZipFile(f)
+		case "word/theme/theme1.xml":
+			theme, err = readZipFile(f)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if styles == nil {
+		return nil, nil, fmt.Errorf("%s has no styles", filepath.Base(path))
+	}
+	return styles, theme, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// docxContext is the block formatting inherited from enclosing blocks.
+type docxContext struct {
+	style  string // paragraph style, Normal when empty
+	indent int    // left indent in twips
+	tight  bool   // paragraphs of a tight list
+}
+
+// docxRun is the inline formatting in effect while writing text.
+type docxRun struct {
+	bold, italic, strike, code bool
+	link                       bool
+	color                      string
+}
+
+type docxRel struct {
+	id, relType, target string
+	external            bool
+}
+
+type docxMedia struct {
+	name string
+	data []byte
+}
+
+type docxNum struct {
+	abstract, start int
+}
+
+type docxWriter struct {
+	source []byte
+	opts   Options
+	body   strings.Builder
+	notes  strings.Builder
+	// out is where paragraphs are written: the body, or a footnote.
+	out *strings.Builder
+	// pending is markup for the start of the next paragraph, such as its
+	// list numbering or a footnote's reference mark.
+	pendingPPr, pendingRuns string
+
+	rels   []docxRel
+	media  []docxMedia
+	images map[string]string // file path to relationship id
+	nums   []docxNum
+	// bookmarks maps heading ids to Word bookmark names.
+	bookmarks map[string]string
+	nextID    int
+
+	// Page size and margins in twips; margins are top, right, bottom, left.
+	pageWidth, pageHeight int
+	margins               [4]int
+}
+
+func newDocxWriter(source []byte, opts Options) (*docxWriter, error) {
+	size, ok := paperSizes[strings.ToLower(opts.PDF.PaperSize)]
+	if opts.PDF.PaperSize == "" {
+		size, ok = paperSizes["a4"], true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown paper size %q", opts.PDF.PaperSize)
+	}
+	if opts.PDF.Landscape {
+		size[0], size[1] = size[1], size[0]
+	}
+	w := &docxWriter{
+		source:    source,
+		opts:      opts,
+		images:    make(map[string]string),
+		bookmarks: make(map[string]string),
+	}
+	w.out = &w.body
+	w.pageWidth = int(size[0] * twipsPerInch)
+	w.pageHeight = int(size[1] * twipsPerInch)
+	for i, mm := range []float64{opts.PDF.MarginTop, opts.PDF.MarginRight, opts.PDF.MarginBottom, opts.PDF.MarginLeft} {
+		w.margins[i] = int(mm / mmPerInch * twipsPerInch)
+	}
+	return w, nil
+}
+
+func (w *docxWriter) contentWidth() int {
+	return w.pageWidth - w.margins[1] - w.margins[3]
+}
+
+func (w *docxWriter) addRel(relType, target string, external bool) string {
+	id := "rId" + strconv.Itoa(len(w.rels)+10)
+	w.rels = append(w.rels, docxRel{id: id, relType: relType, target: target, external: external})
+	return id
+}
+
+func (w *docxWriter) id() int {
+	w.nextID++
+	return w.nextID
+}
+
+// collectBookmarks names a bookmark for every heading up front, so links
+// can point forward in the document. Word limits bookmark names to 40
+// letters, digits and underscores, starting with a letter.
+func (w *docxWriter) collectBookmarks(doc ast.Node) {
+	used := make(map[string]bool)
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id := headingID(h)
+		if id == "" {
+			return ast.WalkSkipChildren, nil
+		}
+		name := []byte(id)
+		for i, c := range name {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				name[i] = '_'
+			}
+		}
+		if c := name[0]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			name = append([]byte("h_"), name...)
+		}
+		base := string(name[:min(len(name), 36)])
+		unique := base
+		for i := 2; used[unique]; i++ {
+			unique = base + "_" + strconv.Itoa(i)
+		}
+		used[unique] = true
+		w.bookmarks[id] = unique
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+func (w *docxWriter) blocks(parent ast.Node, ctx docxContext) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		w.block(n, ctx)
+	}
+}
+
+func (w *docxWriter) block(n ast.Node, ctx docxContext) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		w.heading(n)
+	case *ast.Paragraph, *ast.TextBlock:
+		style := ctx.style
+		if ctx.tight && style == "" {
+			style = "Compact"
+		}
+		w.startParagraph(style, ctx.indent, "")
+		w.inlines(n, docxRun{})
+		w.out.WriteString("</w:p>")
+	case *ast.List:
+		w.list(n, ctx)
+	case *ast.Blockquote:
+		ctx.style = "BlockText"
+		if ctx.indent > 0 {
+			ctx.indent += 360
+		}
+		w.blocks(n, ctx)
+	case *ast.FencedCodeBlock:
+		w.codeBlock(n.Lines(), string(n.Language(w.source)), ctx)
+	case *ast.CodeBlock:
+		w.codeBlock(n.Lines(), "", ctx)
+	case *markdown.MathBlock:
+		// Math is shown as its TeX source.
+		w.codeBlock(n.Lines(), "latex", ctx)
+	case *ast.ThematicBreak:
+		w.out.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="D0D7DE"/></w:pBdr></w:pPr></w:p>`)
+	case *east.Table:
+		w.table(n, ctx)
+	case *east.FootnoteList:
+		w.footnotes(n)
+	case *ast.HTMLBlock:
+		// Raw HTML has no Word equivalent.
+	default:
+		w.blocks(n, ctx)
+	}
+}
+
+// startParagraph opens a paragraph, taking any pending numbering and runs.
+func (w *docxWriter) startParagraph(style string, indent int, extra string) {
+	var ppr strings.Builder
+	if style != "" {
+		fmt.Fprintf(&ppr, `<w:pStyle w:val="%s"/>`, style)
+	}
+	ppr.WriteString(w.pendingPPr)
+	ppr.WriteString(extra)
+	if indent > 0 && w.pendingPPr == "" {
+		fmt.Fprintf(&ppr, `<w:ind w:left="%d"/>`, indent)
+	}
+	w.out.WriteString("<w:p>")
+	if ppr.Len() > 0 {
+		w.out.WriteString("<w:pPr>" + ppr.String() + "</w:pPr>")
+	}
+	w.out.WriteString(w.pendingRuns)
+	w.pendingPPr, w.pendingRuns = "", ""
+}
+
+func (w *docxWriter) heading(n *ast.Heading) {
+	level := min(max(n.Level, 1), 6)
+	w.startParagraph("Heading"+strconv.Itoa(level), 0, "")
+	name, ok := w.bookmarks[headingID(n)]
+	id := w.id()
+	if ok {
+		fmt.Fprintf(w.out, `<w:bookmarkStart w:id="%d" w:name="%s"/>`, id, name)
+	}
+	w.inlines(n, docxRun{})
+	if ok {
+		fmt.Fprintf(w.out, `<w:bookmarkEnd w:id="%d"/>`, id)
+	}
+	w.out.WriteString("</w:p>")
+}
+
+// list gives every list its own numbering instance, so ordered lists start
+// where the Markdown says and do not continue from the previous one.
+func (w *docxWriter) list(n *ast.List, ctx docxContext) {
+	abstract, start := docxBulletNum, 1
+	if n.IsOrdered() {
+		abstract, start = docxDecimalNum, n.Start
+	}
+	w.nums = append(w.nums, docxNum{abstract: abstract, start: start})
+	numID := len(w.nums)
+	level := ctx.indent / docxIndent
+
+	ctx.indent = docxIndent * (level + 1)
+	ctx.tight = n.IsTight
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		w.pendingPPr = fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, min(level, 8), numID)
+		w.blocks(item, ctx)
+		// An item without paragraphs still gets its bullet.
+		if w.pendingPPr != "" {
+			w.startParagraph("Compact", 0, "")
+			w.out.WriteString("</w:p>")
+		}
+	}
+}
+
+func (w *docxWriter) codeBlock(segments *text.Segments, language string, ctx docxContext) {
+	var code strings.Builder
+	for i := 0; i < segments.Len(); i++ {
+		seg := segments.At(i)
+		code.Write(seg.Value(w.source))
+	}
+	src := strings.TrimSuffix(strings.ReplaceAll(code.String(), "\t", "    "), "\n")
+
+	w.pendingPPr = ""
+	w.startParagraph("SourceCode", ctx.indent, "")
+	for i, line := range highlightLines(src, language) {
+		if i > 0 {
+			w.out.WriteString("<w:r><w:br/></w:r>")
+		}
+		for _, span := range line {
+			var rpr string
+			if span.bold {
+				rpr += "<w:b/>"
+			}
+			rpr += fmt.Sprintf(`<w:color w:val="%02X%02X%02X"/>`, span.color[0], span.color[1], span.color[2])
+			fmt.Fprintf(w.out, `<w:r><w:rPr>%s</w:rPr><w:t xml:space="preserve">%s</w:t></w:r>`, rpr, xmlText(span.text))
+		}
+	}
+	w.out.WriteString("</w:p>")
+}
+
+func (w *docxWriter) inlines(n ast.Node, run docxRun) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		w.inline(c, run)
+	}
+}
+
+func (w *docxWriter) inline(n ast.Node, run docxRun) {
+	switch n := n.(type) {
+	case *ast.Text:
+		w.text(string(n.Segment.Value(w.source)), run)
+		if n.HardLineBreak() || n.SoftLineBreak() {
+			// The HTML renderer uses hard wraps, so soft breaks are kept too.
+			w.out.WriteString("<w:r><w:br/></w:r>")
+		}
+	case *ast.String:
+		w.text(stringValue(n), run)
+	case *ast.CodeSpan:
+		run.code = true
+		w.text(inlineText(n, w.source), run)
+	case *markdown.MathInline:
+		run.code = true
+		w.text(mathText(n), run)
+	case *ast.Emphasis:
+		if n.Level >= 2 {
+			run.bold = true
+		} else {
+			run.italic = true
+		}
+		w.inlines(n, run)
+	case *east.Strikethrough:
+		run.strike = true
+		w.inlines(n, run)
+	case *ast.Link:
+		w.link(string(n.Destination), run, func(run docxRun) { w.inlines(n, run) })
+	case *ast.AutoLink:
+		dest := string(n.URL(w.source))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(dest, "mailto:") {
+			dest = "mailto:" + dest
+		}
+		w.link(dest, run, func(run docxRun) { w.text(string(n.Label(w.source)), run) })
+	case *ast.Image:
+		w.image(n, run)
+	case *ast.RawHTML:
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			if strings.HasPrefix(strings.ToLower(string(seg.Value(w.source))), "<br") {
+				w.out.WriteString("<w:r><w:br/></w:r>")
+			}
+		}
+	case *east.TaskCheckBox:
+		box := "☐ "
+		if n.IsChecked {
+			box = "☒ "
+		}
+		w.text(box, run)
+	case *east.FootnoteLink:
+		fmt.Fprintf(w.out, `<w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteReference w:id="%d"/></w:r>`, n.Index)
+	case *east.FootnoteBacklink:
+	default:
+		w.inlines(n, run)
+	}
+}
+
+// link wraps the runs written by content in a hyperlink to a heading or an
+// external URL. Relative links to other files have no meaning in a Word
+// document and stay plain.
+func (w *docxWriter) link(dest string, run docxRun, content func(docxRun)) {
+	if fragment, ok := sameDocument(w.opts.Source, w.opts.BaseDir, dest); ok {
+		dest = fragment
+	}
+	if strings.HasPrefix(dest, "#") {
+		id := strings.TrimPrefix(dest, "#")
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+		name, ok := w.bookmarks[id]
+		if !ok {
+			content(run)
+			return
+		}
+		fmt.Fprintf(w.out, `<w:hyperlink w:anchor="%s" w:history="1">`, name)
+	} else if i := strings.Index(dest, ":"); i > 0 && !strings.ContainsAny(dest[:i], "/?#") {
+		rel := w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", dest, true)
+		fmt.Fprintf(w.out, `<w:hyperlink r:id="%s" w:history="1">`, rel)
+	} else {
+		content(run)
+		return
+	}
+	run.link = true
+	content(run)
+	w.out.WriteString("</w:hyperlink>")
+}
+
+func (w *docxWriter) text(s string, run docxRun) {
+	if s == "" {
+		return
+	}
+	var rpr strings.Builder
+	switch {
+	case run.link:
+		rpr.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	case run.code:
+		rpr.WriteString(`<w:rStyle w:val="VerbatimChar"/>`)
+	}
+	if run.bold {
+		rpr.WriteString("<w:b/><w:bCs/>")
+	}
+	if run.italic {
+		rpr.WriteString("<w:i/><w:iCs/>")
+	}
+	if run.strike {
+		rpr.WriteString("<w:strike/>")
+	}
+	if run.color != "" {
+		fmt.Fprintf(&rpr, `<w:color w:val="%s"/>`, run.color)
+	}
+	w.out.WriteString("<w:r>")
+	if rpr.Len() > 0 {
+		w.out.WriteString("<w:rPr>" + rpr.String() + "</w:rPr>")
+	}
+	fmt.Fprintf(w.out, `<w:t xml:space="preserve">%s</w:t></w:r>`, xmlText(s))
+}
+
+// image embeds a local image at 96 dpi, no wider than the text column.
+// Remote images and formats Word cannot show print their alt text.
+func (w *docxWriter) image(n *ast.Image, run docxRun) {
+	alt := inlineText(n, w.source)
+	rel, width, height, ok := w.loadImage(string(n.Destination))
+	if !ok {
+		run.italic = true
+		run.color = "666666"
+		w.text("["+alt+"]", run)
+		return
+	}
+
+	cx, cy := width*emuPerPixel, height*emuPerPixel
+	if maxCX := w.contentWidth() * emuPerTwip; cx > maxCX {
+		cx, cy = maxCX, cy*maxCX/cx
+	}
+	id := w.id()
+	fmt.Fprintf(w.out, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`+
+		`<wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/>`+
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="Picture %d"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, id, id, xmlText(alt), id, id, rel, cx, cy)
+}
+
+// loadImage adds a local image from the document's folder or the workspace
+// to the package once, returning its relationship id and pixel size. PNG, JPEG and GIF files are stored as
+// they are; other decodable formats are converted to PNG.
+func (w *docxWriter) loadImage(dest string) (string, int, int, bool) {
+	path, ok := localfiles.Resolve(w.opts.BaseDir, dest)
+	if !ok || !withinRoots(path, w.opts.BaseDir, w.opts.Workspace) {
+		return "", 0, 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return "", 0, 0, false
+	}
+	if rel, ok := w.images[path]; ok {
+		return rel, cfg.Width, cfg.Height, true
+	}
+
+	var ext string
+	switch http.DetectContentType(data) {
+	case "image/png":
+		ext = "png"
+	case "image/jpeg":
+		ext = "jpeg"
+	case "image/gif":
+		ext = "gif"
+	default:
+		src, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", 0, 0, false
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, src); err != nil {
+			return "", 0, 0, false
+		}
+		data, ext = buf.Bytes(), "png"
+	}
+	name := fmt.Sprintf("image%d.%s", len(w.media)+1, ext)
+	w.media = append(w.media, docxMedia{name: name, data: data})
+	rel := w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "media/"+name, false)
+	w.images[path] = rel
+	return rel, cfg.Width, cfg.Height, true
+}
+
+func (w *docxWriter) table(n *east.Table, ctx docxContext) {
+	columns := len(n.Alignments)
+	if columns == 0 {
+		return
+	}
+	width := w.contentWidth() - ctx.indent
+	colW := width / columns
+	// A table cannot carry list numbering.
+	w.pendingPPr = ""
+
+	w.out.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="Table"/>`)
+	fmt.Fprintf(w.out, `<w:tblW w:w="%d" w:type="dxa"/>`, width)
+	if ctx.indent > 0 {
+		fmt.Fprintf(w.out, `<w:tblInd w:w="%d" w:type="dxa"/>`, ctx.indent)
+	}
+	w.out.WriteString(`<w:tblLook w:val="0020" w:firstRow="1" w:lastRow="0" w:firstColumn="0" w:lastColumn="0" w:noHBand="1" w:noVBand="1"/></w:tblPr><w:tblGrid>`)
+	for i := 0; i < columns; i++ {
+		fmt.Fprintf(w.out, `<w:gridCol w:w="%d"/>`, colW)
+	}
+	w.out.WriteString("</w:tblGrid>")
+
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		_, header := row.(*east.TableHeader)
+		w.out.WriteString("<w:tr>")
+		if header {
+			// Repeat the header row on every page the table spans.
+			w.out.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		cell := row.FirstChild()
+		for i := 0; i < columns; i++ {
+			fmt.Fprintf(w.out, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, colW)
+			if header {
+				w.out.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/>`)
+			}
+			w.out.WriteString("</w:tcPr>")
+			jc := ""
+			switch n.Alignments[i] {
+			case east.AlignCenter:
+				jc = `<w:jc w:val="center"/>`
+			case east.AlignRight:
+				jc = `<w:jc w:val="right"/>`
+			}
+			w.startParagraph("Compact", 0, jc)
+			if cell != nil {
+				w.inlines(cell, docxRun{bold: header})
+				cell = cell.NextSibling()
+			}
+			w.out.WriteString("</w:p></w:tc>")
+		}
+		w.out.WriteString("</w:tr>")
+	}
+	w.out.WriteString("</w:tbl>")
+	// Word merges tables that touch; an empty paragraph keeps them apart.
+	w.out.WriteString(`<w:p><w:pPr><w:pStyle w:val="Compact"/></w:pPr></w:p>`)
+}
+
+// footnotes writes the notes to the footnotes part, where Word numbers them
+// and places each at the foot of the page that refers to it.
+func (w *docxWriter) footnotes(n *east.FootnoteList) {
+	w.out = &w.notes
+	defer func() { w.out = &w.body }()
+	for fn := n.FirstChild(); fn != nil; fn = fn.NextSibling() {
+		note, ok := fn.(*east.Footnote)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w.out, `<w:footnote w:id="%d">`, note.Index)
+		w.pendingRuns = `<w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteRef/></w:r><w:r><w:t xml:space="preserve"> </w:t></w:r>`
+		w.blocks(note, docxContext{style: "FootnoteText"})
+		if w.pendingRuns != "" {
+			w.startParagraph("FootnoteText", 0, "")
+			w.out.WriteString("</w:p>")
+		}
+		w.out.WriteString("</w:footnote>")
+	}
+}
+
+func (w *docxWriter) writePackage(out io.Writer, refStyles, theme []byte) error {
+	z := zip.NewWriter(out)
+	add := func(name, content string) error {
+		f, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, content)
+		return err
+	}
+
+	themeType := ""
+	if theme != nil {
+		themeType = docxThemeContentType
+		w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme", "theme/theme1.xml", false)
+	}
+	created := time.Now().UTC().Format(time.RFC3339)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", fmt.Sprintf(docxContentTypes, themeType)},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCoreProps, xmlText(w.opts.Title), created)},
+		{"word/document.xml", w.document()},
+		{"word/styles.xml", docxStylesPart(refStyles)},
+		{"word/numbering.xml", w.numbering()},
+		{"word/footnotes.xml", docxFootnotesStart + w.notes.String() + "</w:footnotes>"},
+		{"word/settings.xml", docxSettings},
+		{"word/_rels/document.xml.rels", w.documentRels()},
+	}
+	if theme != nil {
+		parts = append(parts, struct{ name, content string }{"word/theme/theme1.xml", string(theme)})
+	}
+	for _, m := range w.media {
+		parts = append(parts, struct{ name, content string }{"word/media/" + m.name, string(m.data)})
+	}
+	for _, p := range parts {
+		if err := add(p.name, p.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func (w *docxWriter) document() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString("<w:document " + docxNamespaces + "><w:body>")
+	b.WriteString(w.body.String())
+	orient := ""
+	if w.opts.PDF.Landscape {
+		orient = ` w:orient="landscape"`
+	}
+	fmt.Fprintf(&b, `<w:sectPr><w:pgSz w:w="%d" w:h="%d"%s/>`, w.pageWidth, w.pageHeight, orient)
+	fmt.Fprintf(&b, `<w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`,
+		w.margins[0], w.margins[1], w.margins[2], w.margins[3])
+	b.WriteString("</w:body></w:document>")
+	return b.String()
+}
+
+// docxStylesPart returns our styles, or the reference document's styles
+// with ours added for any it does not define.
+func docxStylesPart(refStyles []byte) string {
+	var b strings.Builder
+	if refStyles == nil {
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+		b.WriteString(`<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+		for _, s := range docxStyles {
+			b.WriteString(s.XML)
+		}
+		b.WriteString("</w:styles>")
+		return b.String()
+	}
+
+	ref := string(refStyles)
+	end := strings.LastIndex(ref, "</w:styles>")
+	if end < 0 {
+		return docxStylesPart(nil)
+	}
+	defined := make(map[string]bool)
+	for _, m := range styleIDRegex.FindAllStringSubmatch(ref, -1) {
+		defined[m[1]] = true
+	}
+	b.WriteString(ref[:end])
+	for _, s := range docxStyles {
+		if !defined[s.ID] {
+			b.WriteString(s.XML)
+		}
+	}
+	b.WriteString(ref[end:])
+	return b.String()
+}
+
+func (w *docxWriter) numbering() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+	bullets := []string{"•", "◦", "▪"}
+	for _, abstract := range []int{docxBulletNum, docxDecimalNum} {
+		fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for lvl := 0; lvl < 9; lvl++ {
+			format, label := "decimal", "%"+strconv.Itoa(lvl+1)+"."
+			if abstract == docxBulletNum {
+				format, label = "bullet", bullets[lvl%len(bullets)]
+			}
+			fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/>`+
+				`<w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`, lvl, format, label, docxIndent*(lvl+1))
+		}
+		b.WriteString("</w:abstractNum>")
+	}
+	for i, num := range w.nums {
+		fmt.Fprintf(&b, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, i+1, num.abstract)
+		if num.abstract == docxDecimalNum {
+			for lvl := 0; lvl < 9; lvl++ {
+				fmt.Fprintf(&b, `<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride>`, lvl, num.start)
+			}
+		}
+		b.WriteString("</w:num>")
+	}
+	b.WriteString("</w:numbering>")
+	return b.String()
+}
+
+func (w *docxWriter) documentRels() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	fixed := []docxRel{
+		{"rId1", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles", "styles.xml", false},
+		{"rId2", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering", "numbering.xml", false},
+		{"rId3", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes", "footnotes.xml", false},
+		{"rId4", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings", "settings.xml", false},
+	}
+	for _, r := range append(fixed, w.rels...) {
+		mode := ""
+		if r.external {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&b, `<Relationship Id="%s" Type="%s" Target="%s"%s/>`, r.id, r.relType, xmlText(r.target), mode)
+	}
+	b.WriteString("</Relationships>")
+	return b.String()
+}
+
+// xmlText escapes s for XML text and attributes, dropping the control
+// characters XML 1.0 does not allow.
+func xmlText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"':
+			b.WriteString("&quot;")
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r', r == 0xfffe, r == 0xffff:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
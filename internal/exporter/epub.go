@@ -0,0 +1,630 @@
+package exporter
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"markviewpro/internal/localfiles"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// EPUBDocument is one source document of an EPUB, already rendered.
+type EPUBDocument struct {
+	// Path is the source file, used to resolve images and links between
+	// documents. It may be empty for unsaved content.
+	Path  string
+	Title string
+	HTML  string
+}
+
+type epubChapter struct {
+	file   string
+	title  string
+	titled bool // title comes from a heading rather than the document
+	doc    int
+	nodes  []*html.Node
+	// svg and mathml record content that the manifest must declare.
+	svg, mathml bool
+}
+
+type epubImage struct {
+	id, name, mediaType string
+	data                []byte
+}
+
+// epubMediaTypes are the image types reading systems must support.
+var epubMediaTypes = map[string]string{
+	"image/png":  "image/png",
+	"image/jpeg": "image/jpeg",
+	"image/gif":  "image/gif",
+	"image/webp": "image/webp",
+}
+
+// epubDroppedElements have no place in a reading system, or need manifest
+// properties an exported book does not declare.
+var epubDroppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Noscript: true, atom.Style: true, atom.Link: true,
+	atom.Meta: true, atom.Base: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Template: true, atom.Video: true, atom.Audio: true,
+	atom.Xmp: true, atom.Noembed: true, atom.Noframes: true, atom.Plaintext: true,
+}
+
+var xmlNameRegex = regexp.MustCompile(`^[A-Za-z_][-A-Za-z0-9_.]*$`)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ToEPUB packages one or more rendered documents as an EPUB 3 book. Each
+// document starts a new chapter, and opts.ChapterLevel splits documents
+// further at headings of that level and above. The navigation document is
+// built from the headings, and local images are packaged with the book.
+func (e *Exporter) ToEPUB(ctx context.Context, docs []EPUBDocument, outputPath string, opts Options) error {
+	if len(docs) == 0 {
+		return fmt.Errorf("nothing to export")
+	}
+	b := &epubBuilder{
+		opts:      opts,
+		docs:      docs,
+		docIndex:  make(map[string]int),
+		ids:       make([]map[string]string, len(docs)),
+		imageByID: make(map[string]*epubImage),
+	}
+
+	opts.progress("Splitting chapters", 5)
+	for i, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := b.addDocument(i, doc); err != nil {
+			return fmt.Errorf("failed to read %s: %w", doc.Title, err)
+		}
+	}
+
+	parts := make(map[string]string)
+	for i, ch := range b.chapters {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		body, err := b.chapterBody(ch)
+		if err != nil {
+			return err
+		}
+		parts["OEBPS/text/"+ch.file] = epubPage(ch.title, opts.language(), "../style.css", body)
+		opts.progress("Writing chapters", 10+80*(i+1)/len(b.chapters))
+	}
+
+	opts.progress("Writing file", 95)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := b.writePackage(f, parts); err != nil {
+		f.Close()
+		return fmt.Errorf("EPUB export failed: %w", err)
+	}
+	return f.Close()
+}
+
+func (o Options) language() string {
+	if o.Language == "" {
+		return "en"
+	}
+	return o.Language
+}
+
+type epubBuilder struct {
+	opts     Options
+	docs     []EPUBDocument
+	chapters []*epubChapter
+	// docIndex maps source paths to documents, for links between them.
+	docIndex map[string]int
+	// ids maps each document's element ids to the chapter file holding them.
+	ids       []map[string]string
+	images    []*epubImage
+	imageByID map[string]*epubImage // keyed by source path
+	headings  []epubHeading
+}
+
+type epubHeading struct {
+	heading
+	file string
+}
+
+// addDocument parses a rendered document and splits it into chapters at
+// top-level headings.
+func (b *epubBuilder) addDocument(index int, doc EPUBDocument) error {
+	nodes, err := html.ParseFragment(strings.NewReader(doc.HTML), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return err
+	}
+	if doc.Path != "" {
+		b.docIndex[filepath.Clean(doc.Path)] = index
+	}
+	b.ids[index] = make(map[string]string)
+
+	var current *epubChapter
+	newChapter := func() {
+		current = &epubChapter{
+			file:  fmt.Sprintf("ch%03d.xhtml", len(b.chapters)+1),
+			title: doc.Title,
+			doc:   index,
+		}
+		b.chapters = append(b.chapters, current)
+	}
+	newChapter()
+	for _, n := range nodes {
+		level := 0
+		if n.Type == html.ElementNode {
+			level = headingLevels[n.Data]
+		}
+		if level > 0 && level <= b.opts.ChapterLevel && hasContent(current.nodes) {
+			newChapter()
+		}
+		if level > 0 && !current.titled {
+			current.title, current.titled = nodeText(n), true
+		}
+		current.nodes = append(current.nodes, n)
+	}
+
+	for _, ch := range b.chapters {
+		if ch.doc != index {
+			continue
+		}
+		for _, n := range ch.nodes {
+			walkElements(n, func(el *html.Node) {
+				id := getAttr(el, "id")
+				if id == "" {
+					return
+				}
+				if _, seen := b.ids[index][id]; !seen {
+					b.ids[index][id] = ch.file
+				}
+				if level := headingLevels[el.Data]; level > 0 {
+					b.headings = append(b.headings, epubHeading{
+						heading: heading{Level: level, Title: nodeText(el), ID: id},
+						file:    ch.file,
+					})
+				}
+			})
+		}
+	}
+	return nil
+}
+
+// hasContent reports whether nodes hold more than whitespace.
+func hasContent(nodes []*html.Node) bool {
+	for _, n := range nodes {
+		if n.Type != html.TextNode || strings.TrimSpace(n.Data) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkElements(c, fn)
+	}
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// chapterBody serializes a chapter as XHTML, with links and images
+// pointing into the book.
+func (b *epubBuilder) chapterBody(ch *epubChapter) (string, error) {
+	var out strings.Builder
+	for _, n := range ch.nodes {
+		if !b.clean(n, ch) {
+			continue
+		}
+		if err := html.Render(&out, n); err != nil {
+			return "", err
+		}
+	}
+	return out.String(), nil
+}
+
+// clean rewrites n in place for XHTML and reports whether to keep it.
+func (b *epubBuilder) clean(n *html.Node, ch *epubChapter) bool {
+	switch n.Type {
+	case html.CommentNode, html.DoctypeNode:
+		return false
+	case html.ElementNode:
+	default:
+		return true
+	}
+	if epubDroppedElements[n.DataAtom] {
+		return false
+	}
+
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !xmlNameRegex.MatchString(a.Key) || strings.HasPrefix(key, "on") {
+			continue
+		}
+		if key == "align" {
+			// Obsolete in HTML5; goldmark writes it for table alignment.
+			attrs = append(attrs, html.Attribute{Key: "style", Val: "text-align: " + a.Val})
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+
+	switch {
+	case n.Namespace == "svg" && n.Data == "svg":
+		n.Attr = append(n.Attr, html.Attribute{Key: "xmlns", Val: "http://www.w3.org/2000/svg"})
+		ch.svg = true
+	case n.Namespace == "math" && n.Data == "math":
+		n.Attr = append(n.Attr, html.Attribute{Key: "xmlns", Val: "http://www.w3.org/1998/Math/MathML"})
+		ch.mathml = true
+	case n.DataAtom == atom.A:
+		b.rewriteLink(n, ch)
+	case n.DataAtom == atom.Img:
+		if !b.rewriteImage(n, ch) {
+			// Fall back to the alt text.
+			alt := getAttr(n, "alt")
+			n.Type, n.Data, n.DataAtom = html.TextNode, "["+alt+"]", 0
+			n.Attr = nil
+			return alt != ""
+		}
+	}
+
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if !b.clean(c, ch) {
+			n.RemoveChild(c)
+		}
+		c = next
+	}
+	return true
+}
+
+// rewriteLink points links within and between the book's documents at the
+// chapter holding their target. Links to files outside the book lose their
+// href, as a reading system cannot follow them.
+func (b *epubBuilder) rewriteLink(n *html.Node, ch *epubChapter) {
+	if strings.Contains(getAttr(n, "class"), "footnote-ref") {
+		n.Attr = append(n.Attr, html.Attribute{Key: "epub:type", Val: "noteref"})
+	}
+	for i, a := range n.Attr {
+		if a.Key != "href" {
+			continue
+		}
+		href, ok := b.resolveLink(a.Val, ch)
+		if !ok {
+			n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+		} else {
+			n.Attr[i].Val = href
+		}
+		return
+	}
+}
+
+func (b *epubBuilder) resolveLink(ref string, ch *epubChapter) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme != "" {
+		return ref, true
+	}
+
+	doc := ch.doc
+	if u.Path != "" {
+		target, ok := localfiles.Resolve(filepath.Dir(b.docs[ch.doc].Path), ref)
+		if !ok {
+			return "", false
+		}
+		if doc, ok = b.docIndex[filepath.Clean(target)]; !ok {
+			return "", false
+		}
+	}
+	if u.Fragment == "" {
+		for _, c := range b.chapters {
+			if c.doc == doc {
+				return c.file, true
+			}
+		}
+		return "", false
+	}
+	file, ok := b.ids[doc][u.Fragment]
+	if !ok {
+		return "", false
+	}
+	if file == ch.file {
+		return "#" + u.EscapedFragment(), true
+	}
+	return file + "#" + u.EscapedFragment(), true
+}
+
+// rewriteImage packages a local image once and points n at it. Remote
+// images, images outside the document's folder and the workspace, and
+// unsupported formats are reported as false.
+func (b *epubBuilder) rewriteImage(n *html.Node, ch *epubChapter) bool {
+	src := getAttr(n, "src")
+	if strings.HasPrefix(src, "data:") {
+		return true
+	}
+	dir := filepath.Dir(b.docs[ch.doc].Path)
+	path, ok := localfiles.Resolve(dir, src)
+	if b.docs[ch.doc].Path == "" || !ok || !withinRoots(path, dir, b.opts.Workspace) {
+		return false
+	}
+	img, ok := b.imageByID[path]
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		mediaType, ok := epubMediaTypes[strings.Split(http.DetectContentType(data), ";")[0]]
+		if strings.EqualFold(filepath.Ext(path), ".svg") {
+			mediaType, ok = "image/svg+xml", true
+		}
+		if !ok {
+			return false
+		}
+		base := strings.Trim(unsafeFileChars.ReplaceAllString(filepath.Base(path), "-"), "-")
+		name := fmt.Sprintf("%03d-%s", len(b.images)+1, base)
+		img = &epubImage{id: fmt.Sprintf("img%d", len(b.images)+1), name: name, mediaType: mediaType, data: data}
+		b.images = append(b.images, img)
+		b.imageByID[path] = img
+	}
+	for i, a := range n.Attr {
+		if a.Key == "src" {
+			n.Attr[i].Val = "../images/" + img.name
+		}
+	}
+	if getAttr(n, "alt") == "" {
+		// alt is required in EPUB content documents.
+		n.Attr = append(n.Attr, html.Attribute{Key: "alt", Val: ""})
+	}
+	return true
+}
+
+// epubPage wraps body markup in an XHTML content document.
+func epubPage(title, lang, stylesheet, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="` + lang + `" xml:lang="` + lang + `">
+<head>
+<meta charset="UTF-8"/>
+<title>` + html.EscapeString(title) + `</title>
+<link rel="stylesheet" type="text/css" href="` + stylesheet + `"/>
+</head>
+<body>
+` + body + `
+</body>
+</html>
+`
+}
+
+type navItem struct {
+	epubHeading
+	children []*navItem
+}
+
+// nav builds the navigation document from the headings, nested by level.
+// Books without headings list their chapters instead.
+func (b *epubBuilder) nav() string {
+	headings := b.headings
+	if len(headings) == 0 {
+		for _, ch := range b.chapters {
+			headings = append(headings, epubHeading{heading: heading{Level: 1, Title: ch.title}, file: ch.file})
+		}
+	}
+
+	root := &navItem{}
+	stack := []*navItem{root}
+	for _, h := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		item := &navItem{epubHeading: h}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, item)
+		stack = append(stack, item)
+	}
+
+	var out strings.Builder
+	var list func(items []*navItem)
+	list = func(items []*navItem) {
+		out.WriteString("<ol>\n")
+		for _, it := range items {
+			href := "text/" + it.file
+			if it.ID != "" {
+				href += "#" + url.PathEscape(it.ID)
+			}
+			title := it.Title
+			if title == "" {
+				title = it.ID
+			}
+			fmt.Fprintf(&out, `<li><a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(title))
+			if len(it.children) > 0 {
+				out.WriteString("\n")
+				list(it.children)
+			}
+			out.WriteString("</li>\n")
+		}
+		out.WriteString("</ol>\n")
+	}
+	out.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>Contents</h1>\n")
+	list(root.children)
+	out.WriteString("</nav>")
+	return epubPage(b.opts.Title, b.opts.language(), "style.css", out.String())
+}
+
+func (b *epubBuilder) writePackage(w io.Writer, parts map[string]string) error {
+	z := zip.NewWriter(w)
+	// The mimetype must come first and be stored uncompressed.
+	mt, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mt, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	add := func(name string, data []byte) error {
+		f, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	if err := add("META-INF/container.xml", []byte(epubContainer)); err != nil {
+		return err
+	}
+	if err := add("OEBPS/content.opf", []byte(b.packageDocument())); err != nil {
+		return err
+	}
+	if err := add("OEBPS/nav.xhtml", []byte(b.nav())); err != nil {
+		return err
+	}
+	if err := add("OEBPS/style.css", []byte(epubCSS)); err != nil {
+		return err
+	}
+	for _, ch := range b.chapters {
+		name := "OEBPS/text/" + ch.file
+		if err := add(name, []byte(parts[name])); err != nil {
+			return err
+		}
+	}
+	for _, img := range b.images {
+		if err := add("OEBPS/images/"+img.name, img.data); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func (b *epubBuilder) packageDocument() string {
+	var manifest, spine strings.Builder
+	manifest.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	manifest.WriteString(`<item id="css" href="style.css" media-type="text/css"/>` + "\n")
+	for _, ch := range b.chapters {
+		id := strings.TrimSuffix(ch.file, ".xhtml")
+		var props []string
+		if ch.svg {
+			props = append(props, "svg")
+		}
+		if ch.mathml {
+			props = append(props, "mathml")
+		}
+		propAttr := ""
+		if len(props) > 0 {
+			propAttr = ` properties="` + strings.Join(props, " ") + `"`
+		}
+		fmt.Fprintf(&manifest, `<item id="%s" href="text/%s" media-type="application/xhtml+xml"%s/>`+"\n", id, ch.file, propAttr)
+		fmt.Fprintf(&spine, `<itemref idref="%s"/>`+"\n", id)
+	}
+	for _, img := range b.images {
+		fmt.Fprintf(&manifest, `<item id="%s" href="images/%s" media-type="%s"/>`+"\n", img.id, img.name, img.mediaType)
+	}
+
+	title := b.opts.Title
+	if title == "" {
+		title = b.docs[0].Title
+	}
+	var creators strings.Builder
+	for _, author := range b.opts.Authors {
+		fmt.Fprintf(&creators, "<dc:creator>%s</dc:creator>\n", html.EscapeString(author))
+	}
+	return fmt.Sprintf(epubPackage,
+		newUUID(), html.EscapeString(title), b.opts.language(), creators.String(),
+		time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		manifest.String(), spine.String())
+}
+
+func newUUID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+const epubPackage = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">urn:uuid:%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+%s<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+%s</manifest>
+<spine>
+%s</spine>
+</package>
+`
+
+// epubCSS is a light stylesheet that leaves fonts and margins to the
+// reading system.
+const epubCSS = `body { line-height: 1.5; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; margin: 1.2em 0 0.5em; page-break-after: avoid; }
+p { margin: 0 0 0.8em; }
+a { color: #0969da; }
+img { max-width: 100%; }
+code { font-family: monospace; font-size: 0.9em; }
+pre { font-family: monospace; font-size: 0.85em; padding: 0.6em; white-space: pre-wrap; border-radius: 4px; }
+blockquote { margin: 0 0 0.8em; padding-left: 1em; border-left: 3px solid #d0d7de; color: #57606a; }
+table { border-collapse: collapse; margin: 0 0 0.8em; }
+th, td { border: 1px solid #d0d7de; padding: 0.3em 0.6em; }
+th { background: #f6f8fa; }
+hr { border: 0; border-top: 1px solid #d0d7de; }
+.footnotes { font-size: 0.9em; }
+`
@@ -1,22 +1,122 @@
 package exporter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
+	"time"
 )
 
 type Exporter struct{}
 
+// ErrChromeNotFound is returned by ToPDF when no Chrome, Chromium or Edge is
+// installed. ToBuiltinPDF works without one.
+var ErrChromeNotFound = errors.New("Chrome/Chromium not found")
+
+// pdfTimeout bounds a whole Chrome export, on top of the per-call cdpTimeout.
+const pdfTimeout = 3 * time.Minute
+
+// Progress reports the stage an export has reached and a rough percentage.
+type Progress func(stage string, percent int)
+
+// Options describes the document being exported.
+type Options struct {
+	// BaseDir is the folder of the source document. Relative images and
+	// links are resolved against it; when empty they are left as they are.
+	BaseDir string
+	// Source is the path of the source document, if it has one. Links to it
+	// become links within the PDF.
+	Source string
+	// Workspace is the open folder. Self-contained exports embed files
+	// from it and from BaseDir only.
+	Workspace string
+	// MaxImageWidth downscales wider images in self-contained exports.
+	// Zero keeps images at their original size.
+	MaxImageWidth int
+	// Theme names the stylesheet to embed; see Themes.
+	Theme string
+	// Title is the document title, also available to PDF headers.
+	Title string
+	PDF   PDFOptions
+	// ReferenceDocx is a Word document whose styles DOCX exports reuse.
+	ReferenceDocx string
+	// ChapterLevel splits EPUB documents into chapters at headings of this
+	// level and above. Zero gives one chapter per document.
+	ChapterLevel int
+	// Language is the BCP 47 language of EPUB exports, "en" when empty.
+	Language string
+	// Authors and Date come from the document's front matter.
+	Authors []string
+	Date    string
+	// LaTeXTemplate is a file whose contents replace the LaTeX preamble.
+	LaTeXTemplate string
+	// LaTeXMinted typesets LaTeX code blocks with minted, not listings.
+	LaTeXMinted bool
+	// Template names the page layout of HTML and PDF exports; see
+	// Templates. FrontMatter and Fields are available to it.
+	Template    string
+	FrontMatter map[string]string
+	Fields      map[string]string
+	// TextWidth wraps plain text exports at this many columns. Zero does
+	// not wrap.
+	TextWidth int
+	// SiteTemplate is an html/template file that lays out static site
+	// pages; see SiteTemplateData.
+	SiteTemplate string
+	// Progress, if set, is called as the export moves through its stages.
+	Progress Progress
+}
+
+func (o Options) progress(stage string, percent int) {
+	if o.Progress != nil {
+		o.Progress(stage, percent)
+	}
+}
+
 func NewExporter() *Exporter {
 	return &Exporter{}
 }
 
-func (e *Exporter) ToHTML(htmlContent, outputPath string) error {
-	fullHTML := wrapHTML(htmlContent)
+// ToHTML writes a standalone HTML file. Relative references are rewritten to
+// point from the output location back to the source document's assets.
+func (e *Exporter) ToHTML(ctx context.Context, htmlContent, outputPath string, opts Options) error {
+	absOutputPath, err := filepath.Abs(outputPath)
+	if err != nil {
+		return err
+	}
+	outDir := filepath.Dir(absOutputPath)
+
+	opts.progress("Rewriting links", 30)
+	htmlContent, err = rewriteURLs(htmlContent, func(ref string) string {
+		return relativeTo(opts.BaseDir, outDir, ref)
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	opts.progress("Writing file", 80)
+	return e.writeHTML(htmlContent, outputPath, opts)
+}
+
+func (e *Exporter) writeHTML(htmlContent, outputPath string, opts Options) error {
+	css, err := e.ThemeCSS(opts.Theme)
+	if err != nil {
+		return err
+	}
+	page, err := e.page(htmlContent, css, opts)
+	if err != nil {
+		return err
+	}
+	return writeDocument(page, outputPath)
+}
 
+func writeDocument(fullHTML, outputPath string) error {
 	dir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
@@ -25,21 +125,41 @@ func (e *Exporter) ToHTML(htmlContent, outputPath string) error {
 	return os.WriteFile(outputPath, []byte(fullHTML), 0644)
 }
 
-func (e *Exporter) ToPDF(htmlContent, outputPath string) error {
+// ToPDF prints the HTML with headless Chrome. The page is loaded from a
+// temporary folder, so relative references become absolute file URLs. The
+// PDF gets an outline built from the document's headings. Chrome is killed
+// if ctx is cancelled or the export takes longer than pdfTimeout.
+func (e *Exporter) ToPDF(ctx context.Context, htmlContent, outputPath string, opts Options) error {
+	ctx, cancel := context.WithTimeout(ctx, pdfTimeout)
+	defer cancel()
+
+	opts.progress("Preparing document", 5)
 	tempDir, err := os.MkdirTemp("", "markviewpro-export-*")
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tempDir)
+	defer removeTempDir(tempDir)
+
+	htmlContent, err = rewriteURLs(htmlContent, func(ref string) string {
+		if fragment, ok := sameDocument(opts.Source, opts.BaseDir, ref); ok {
+			return fragment
+		}
+		return fileURL(opts.BaseDir, ref)
+	})
+	if err != nil {
+		return err
+	}
+	headings := collectHeadings(htmlContent)
+	htmlContent += destinationLinks(headings)
 
 	tempHTML := filepath.Join(tempDir, "temp.html")
-	if err := e.ToHTML(htmlContent, tempHTML); err != nil {
+	if err := e.writeHTML(htmlContent, tempHTML, opts); err != nil {
 		return err
 	}
 
 	chromePath := findChrome()
 	if chromePath == "" {
-		return fmt.Errorf("Chrome/Chromium not found. Please install Chrome or Chromium for PDF export")
+		return ErrChromeNotFound
 	}
 
 	absOutputPath, err := filepath.Abs(outputPath)
@@ -52,20 +172,19 @@ func (e *Exporter) ToPDF(htmlContent, outputPath string) error {
 		return err
 	}
 
-	cmd := exec.Command(chromePath,
-		"--headless",
-		"--disable-gpu",
-		"--no-sandbox",
-		"--print-to-pdf="+absOutputPath,
-		"--print-to-pdf-no-header",
-		tempHTML,
-	)
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("PDF export failed: %w", err)
+	pdf, err := printToPDF(ctx, chromePath, tempHTML, filepath.Join(tempDir, "profile"), opts)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return fmt.Errorf("PDF export timed out after %s", pdfTimeout)
 	}
-
-	return nil
+	if err != nil {
+		return err
+	}
+	opts.progress("Adding bookmarks", 85)
+	// The outline is a convenience; keep the plain PDF if it cannot be added.
+	if outlined, err := addOutline(pdf, headings); err == nil {
+		pdf = outlined
+	}
+	return os.WriteFile(absOutputPath, pdf, 0644)
 }
 
 func findChrome() string {
@@ -107,94 +226,3 @@ func findChrome() string {
 
 	return ""
 }
-
-func wrapHTML(content string) string {
-	return fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>MarkViewPro Export</title>
-    <style>
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif;
-            line-height: 1.6;
-            max-width: 900px;
-            margin: 0 auto;
-            padding: 2rem;
-            color: #333;
-        }
-        h1, h2, h3, h4, h5, h6 {
-            margin-top: 1.5em;
-            margin-bottom: 0.5em;
-            font-weight: 600;
-        }
-        h1 { font-size: 2em; border-bottom: 1px solid #eee; padding-bottom: 0.3em; }
-        h2 { font-size: 1.5em; border-bottom: 1px solid #eee; padding-bottom: 0.3em; }
-        code {
-            background: #f4f4f4;
-            padding: 0.2em 0.4em;
-            border-radius: 3px;
-            font-family: 'JetBrains Mono', Consolas, monospace;
-            font-size: 0.9em;
-        }
-        pre {
-            background: #2d2d2d;
-            color: #f8f8f2;
-            padding: 1em;
-            border-radius: 5px;
-            overflow-x: auto;
-        }
-        pre code {
-            background: none;
-            padding: 0;
-            color: inherit;
-        }
-        blockquote {
-            border-left: 4px solid #ddd;
-            margin: 0;
-            padding-left: 1em;
-            color: #666;
-        }
-        table {
-            border-collapse: collapse;
-            width: 100%%;
-            margin: 1em 0;
-        }
-        th, td {
-            border: 1px solid #ddd;
-            padding: 0.5em;
-            text-align: left;
-        }
-        th {
-            background: #f4f4f4;
-        }
-        img {
-            max-width: 100%%;
-            height: auto;
-        }
-        a {
-            color: #0366d6;
-            text-decoration: none;
-        }
-        a:hover {
-            text-decoration: underline;
-        }
-        hr {
-            border: none;
-            border-top: 1px solid #eee;
-            margin: 2em 0;
-        }
-        .task-list-item {
-            list-style: none;
-        }
-        .task-list-item input {
-            margin-right: 0.5em;
-        }
-    </style>
-</head>
-<body>
-%s
-</body>
-</html>`, content)
-}
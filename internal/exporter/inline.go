@@ -0,0 +1,366 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	_ "image/gif"
+
+	"markviewpro/internal/localfiles"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/net/html"
+)
+
+// AssetReport describes one file embedded in a self-contained export.
+type AssetReport struct {
+	Source        string `json:"source"`
+	MimeType      string `json:"mimeType"`
+	OriginalBytes int64  `json:"originalBytes"`
+	EmbeddedBytes int64  `json:"embeddedBytes"`
+	Downscaled    bool   `json:"downscaled"`
+}
+
+// SizeReport summarizes a self-contained export. External lists references
+// that could not be embedded, such as remote images or missing files, and
+// will not work once the file leaves this machine.
+type SizeReport struct {
+	OutputPath string        `json:"outputPath"`
+	TotalBytes int64         `json:"totalBytes"`
+	Assets     []AssetReport `json:"assets"`
+	External   []string      `json:"external"`
+}
+
+// mediaAttrs lists the attributes whose files are embedded, by element.
+var mediaAttrs = map[string]string{
+	"img":    "src",
+	"source": "src",
+	"video":  "poster",
+	"audio":  "src",
+	"input":  "src",
+}
+
+var cssURLRegex = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+
+// ToSelfContainedHTML writes a single HTML file with every local image,
+// stylesheet and font it references embedded as data URIs. Highlighted code
+// already carries inline styles. Images wider than opts.MaxImageWidth are
+// downscaled first. Only files in the document's folder or the workspace
+// are embedded, and only when their content is an image, a font or, for
+// stylesheets, CSS; other references are left as they are.
+func (e *Exporter) ToSelfContainedHTML(ctx context.Context, htmlContent, outputPath string, opts Options) (SizeReport, error) {
+	in := newInliner(ctx, opts, outputPath)
+
+	opts.progress("Embedding images", 20)
+	body, err := in.inlineHTML(htmlContent)
+	if err != nil {
+		return in.report, err
+	}
+	if err := ctx.Err(); err != nil {
+		return in.report, err
+	}
+	css, err := e.ThemeCSS(opts.Theme)
+	if err != nil {
+		return in.report, err
+	}
+	// User themes may reference fonts next to them in the themes folder.
+	css = in.inlineCSS(css, ThemesDir())
+	page, err := e.page(body, css, opts)
+	if err != nil {
+		return in.report, err
+	}
+	opts.progress("Writing file", 90)
+	if err := writeDocument(page, outputPath); err != nil {
+		return in.report, err
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return in.report, err
+	}
+	in.report.TotalBytes = info.Size()
+	return in.report, nil
+}
+
+type inliner struct {
+	ctx    context.Context
+	opts   Options
+	roots  []string
+	report SizeReport
+}
+
+// newInliner embeds files from the document's folder, the workspace and
+// the themes folder, whose user themes may bring fonts.
+func newInliner(ctx context.Context, opts Options, outputPath string) *inliner {
+	return &inliner{
+		ctx:   ctx,
+		opts:  opts,
+		roots: []string{opts.BaseDir, opts.Workspace, ThemesDir()},
+		report: SizeReport{
+			OutputPath: outputPath,
+			Assets:     make([]AssetReport, 0),
+			External:   make([]string, 0),
+		},
+	}
+}
+
+func (in *inliner) inlineHTML(htmlContent string) (string, error) {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(htmlContent))
+	inStyle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return "", err
+			}
+			return b.String(), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(z.Raw())
+			tok := z.Token()
+			inStyle = tok.Data == "style" && tt == html.StartTagToken
+
+			if tok.Data == "link" && isStylesheet(tok) {
+				if css, ok := in.inlineStylesheet(attrValue(tok, "href")); ok {
+					b.WriteString("<style>\n" + css + "\n</style>")
+					continue
+				}
+			}
+
+			attr, ok := mediaAttrs[tok.Data]
+			if !ok {
+				b.WriteString(raw)
+				continue
+			}
+			changed := false
+			for i, a := range tok.Attr {
+				if a.Key != attr || strings.HasPrefix(a.Val, "data:") {
+					continue
+				}
+				if uri, ok := in.dataURI(a.Val, true); ok {
+					tok.Attr[i].Val = uri
+					changed = true
+				}
+			}
+			if changed {
+				b.WriteString(tok.String())
+			} else {
+				b.WriteString(raw)
+			}
+		case html.TextToken:
+			if inStyle {
+				b.WriteString(in.inlineCSS(string(z.Text()), in.opts.BaseDir))
+			} else {
+				b.WriteString(string(z.Raw()))
+			}
+		case html.EndTagToken:
+			inStyle = false
+			b.WriteString(string(z.Raw()))
+		default:
+			b.WriteString(string(z.Raw()))
+		}
+	}
+}
+
+func isStylesheet(tok html.Token) bool {
+	for _, rel := range strings.Fields(strings.ToLower(attrValue(tok, "rel"))) {
+		if rel == "stylesheet" {
+			return true
+		}
+	}
+	return false
+}
+
+func attrValue(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// inlineStylesheet reads a local stylesheet and embeds the fonts and images
+// it references, which resolve relative to the stylesheet itself.
+func (in *inliner) inlineStylesheet(ref string) (string, bool) {
+	path, ok := in.resolve(ref)
+	if !ok {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		in.report.External = append(in.report.External, ref)
+		return "", false
+	}
+	if _, ok := embeddableType(path, data, true); !ok {
+		in.report.External = append(in.report.External, ref)
+		return "", false
+	}
+	in.report.Assets = append(in.report.Assets, AssetReport{
+		Source:        ref,
+		MimeType:      "text/css",
+		OriginalBytes: int64(len(data)),
+		EmbeddedBytes: int64(len(data)),
+	})
+	return in.inlineCSS(string(data), filepath.Dir(path)), true
+}
+
+func (in *inliner) inlineCSS(css, baseDir string) string {
+	saved := in.opts.BaseDir
+	in.opts.BaseDir = baseDir
+	defer func() { in.opts.BaseDir = saved }()
+
+	return cssURLRegex.ReplaceAllStringFunc(css, func(m string) string {
+		ref := cssURLRegex.FindStringSubmatch(m)[2]
+		if strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return m
+		}
+		if uri, ok := in.dataURI(ref, false); ok {
+			return `url("` + uri + `")`
+		}
+		return m
+	})
+}
+
+// resolve maps a reference to a local file in the document's folder or
+// the workspace. Other references are recorded as external.
+func (in *inliner) resolve(ref string) (string, bool) {
+	path := ""
+	if u, err := url.Parse(ref); err == nil && u.Scheme == "file" {
+		p := u.Path
+		if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+			// file:///C:/... on Windows
+			p = p[1:]
+		}
+		path = filepath.FromSlash(p)
+	} else if filepath.IsAbs(ref) {
+		path = ref
+	} else if resolved, ok := localfiles.Resolve(in.opts.BaseDir, ref); ok {
+		path = resolved
+	}
+	if path == "" || !withinRoots(path, in.roots...) {
+		in.report.External = append(in.report.External, ref)
+		return "", false
+	}
+	return path, true
+}
+
+// withinRoots reports whether path, with symlinks followed, lies inside one
+// of roots. Empty roots are skipped.
+func withinRoots(path string, roots ...string) bool {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		if rel, err := filepath.Rel(root, path); err == nil && !isOutside(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddableType returns the type to embed a file as. Images and fonts are
+// judged by their content, so that a renamed file is not embedded; a
+// stylesheet must be a .css text file.
+func embeddableType(path string, data []byte, stylesheet bool) (string, bool) {
+	byExt, _, _ := strings.Cut(mime.TypeByExtension(strings.ToLower(filepath.Ext(path))), ";")
+	sniffed, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	switch {
+	case stylesheet:
+		return "text/css", byExt == "text/css" && sniffed == "text/plain"
+	case strings.HasPrefix(sniffed, "image/"), strings.HasPrefix(sniffed, "font/"), sniffed == "application/vnd.ms-fontobject":
+		return sniffed, true
+	case byExt == "image/svg+xml" && (sniffed == "text/plain" || sniffed == "text/xml") && bytes.Contains(data, []byte("<svg")):
+		// Content sniffing does not recognise SVG.
+		return byExt, true
+	}
+	return "", false
+}
+
+func (in *inliner) dataURI(ref string, downscale bool) (string, bool) {
+	if in.ctx.Err() != nil {
+		return "", false
+	}
+	path, ok := in.resolve(ref)
+	if !ok {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		in.report.External = append(in.report.External, ref)
+		return "", false
+	}
+	mimeType, ok := embeddableType(path, data, false)
+	if !ok {
+		in.report.External = append(in.report.External, ref)
+		return "", false
+	}
+
+	asset := AssetReport{
+		Source:        ref,
+		MimeType:      mimeType,
+		OriginalBytes: int64(len(data)),
+	}
+	if downscale && in.opts.MaxImageWidth > 0 {
+		if scaled, ok := downscaleImage(data, mimeType, in.opts.MaxImageWidth); ok {
+			data = scaled
+			asset.Downscaled = true
+		}
+	}
+	asset.EmbeddedBytes = int64(len(data))
+	in.report.Assets = append(in.report.Assets, asset)
+
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data)), true
+}
+
+// downscaleImage resizes PNG and JPEG images wider than maxWidth, keeping
+// the aspect ratio. Other formats, and results that would be larger than
+// the original, are left alone.
+func downscaleImage(data []byte, mimeType string, maxWidth int) ([]byte, bool) {
+	if mimeType != "image/png" && mimeType != "image/jpeg" {
+		return nil, false
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	bounds := src.Bounds()
+	if bounds.Dx() <= maxWidth {
+		return nil, false
+	}
+
+	height := bounds.Dy() * maxWidth / bounds.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, maxWidth, max(height, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if mimeType == "image/png" {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	}
+	if err != nil || buf.Len() >= len(data) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
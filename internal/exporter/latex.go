@@ -0,0 +1,565 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"markviewpro/internal/localfiles"
+	"markviewpro/internal/markdown"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// longtableRows is the row count above which tables may break across pages.
+const longtableRows = 15
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`^`, `\textasciicircum{}`,
+	`~`, `\textasciitilde{}`,
+	"\u00a0", `~`,
+)
+
+// latexTypography maps the typographer's entities to TeX ligatures.
+var latexTypography = map[string]string{
+	"&ldquo;": "``", "&rdquo;": "''", "&lsquo;": "`", "&rsquo;": "'",
+	"&ndash;": "--", "&mdash;": "---", "&hellip;": `\ldots{}`,
+	"&laquo;": `\guillemotleft{}`, "&raquo;": `\guillemotright{}`,
+}
+
+// listingsLanguages maps code block languages to the names the listings
+// package knows. Other languages are typeset without highlighting, as
+// listings stops with an error on a language it does not know.
+var listingsLanguages = map[string]string{
+	"bash": "bash", "sh": "bash", "shell": "bash", "c": "C", "cpp": "C++",
+	"c++": "C++", "csharp": "[Sharp]C", "cs": "[Sharp]C", "fortran": "Fortran",
+	"haskell": "Haskell", "html": "HTML", "java": "Java", "latex": "[LaTeX]TeX",
+	"tex": "[LaTeX]TeX", "lisp": "Lisp", "lua": "Lua", "make": "make",
+	"makefile": "make", "matlab": "Matlab", "perl": "Perl", "php": "PHP",
+	"python": "Python", "py": "Python", "r": "R", "ruby": "Ruby", "scala": "Scala",
+	"sql": "SQL", "xml": "XML",
+}
+
+var sectionCommands = [7]string{"", "section", "subsection", "subsubsection", "paragraph", "subparagraph", "subparagraph"}
+
+// ToLaTeX writes a parsed Markdown document as a LaTeX article. Front
+// matter reaches it through opts.Title, opts.Authors and opts.Date; without
+// a title, a lone leading H1 becomes the title. opts.LaTeXTemplate replaces
+// the built-in preamble.
+func (e *Exporter) ToLaTeX(ctx context.Context, source []byte, doc ast.Node, outputPath string, opts Options) error {
+	absOutput, err := filepath.Abs(outputPath)
+	if err != nil {
+		return err
+	}
+	w := &latexWriter{
+		source:    source,
+		opts:      opts,
+		outDir:    filepath.Dir(absOutput),
+		footnotes: make(map[int]*east.Footnote),
+		noted:     make(map[int]bool),
+	}
+
+	preamble, err := w.preamble()
+	if err != nil {
+		return err
+	}
+
+	opts.progress("Converting document", 10)
+	title := latexEscaper.Replace(opts.Title)
+	var titleHeading ast.Node
+	if h, ok := doc.FirstChild().(*ast.Heading); ok && title == "" && h.Level == 1 && countH1(doc) == 1 {
+		title = w.inlineString(h)
+		titleHeading = h
+		w.shift = 1
+	}
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if fn, ok := n.(*east.Footnote); ok && entering {
+			w.footnotes[fn.Index] = fn
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var body strings.Builder
+	w.out = &body
+	blocks, i, last := doc.ChildCount(), 0, 10
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		i++
+		if n != titleHeading {
+			w.block(n)
+		}
+		if percent := 10 + 80*i/blocks; percent != last {
+			opts.progress("Converting document", percent)
+			last = percent
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(preamble)
+	if !strings.HasSuffix(preamble, "\n") {
+		out.WriteString("\n")
+	}
+	if title != "" {
+		fmt.Fprintf(&out, "\n\\title{%s}\n", title)
+		authors := make([]string, len(opts.Authors))
+		for i, a := range opts.Authors {
+			authors[i] = latexEscaper.Replace(a)
+		}
+		fmt.Fprintf(&out, "\\author{%s}\n", strings.Join(authors, ` \and `))
+		if opts.Date != "" {
+			fmt.Fprintf(&out, "\\date{%s}\n", latexEscaper.Replace(opts.Date))
+		}
+	}
+	out.WriteString("\n\\begin{document}\n")
+	if title != "" {
+		out.WriteString("\\maketitle\n")
+	}
+	out.WriteString("\n" + strings.TrimSpace(body.String()) + "\n\n\\end{document}\n")
+
+	opts.progress("Writing file", 95)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, []byte(out.String()), 0644)
+}
+
+func countH1(doc ast.Node) int {
+	count := 0
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if h, ok := n.(*ast.Heading); ok && h.Level == 1 {
+			count++
+		}
+	}
+	return count
+}
+
+type latexWriter struct {
+	source []byte
+	opts   Options
+	outDir string
+	out    *strings.Builder
+	// shift promotes headings by one level when the H1 became the title.
+	shift     int
+	footnotes map[int]*east.Footnote
+	// noted records footnotes already typeset, so repeated references
+	// point back to the first one.
+	noted map[int]bool
+}
+
+// preamble returns the template's contents, or the built-in preamble with
+// the PDF page setup applied.
+func (w *latexWriter) preamble() (string, error) {
+	if w.opts.LaTeXTemplate != "" {
+		data, err := os.ReadFile(w.opts.LaTeXTemplate)
+		if err != nil {
+			return "", fmt.Errorf("failed to read LaTeX template: %w", err)
+		}
+		return string(data), nil
+	}
+
+	paper := "a4paper"
+	switch p := strings.ToLower(w.opts.PDF.PaperSize); p {
+	case "a3", "a5", "letter", "legal":
+		paper = p + "paper"
+	case "tabloid":
+		paper = "paperwidth=11in,paperheight=17in"
+	}
+	geometry := paper
+	if w.opts.PDF.Landscape {
+		geometry += ",landscape"
+	}
+	margins := []float64{w.opts.PDF.MarginTop, w.opts.PDF.MarginBottom, w.opts.PDF.MarginLeft, w.opts.PDF.MarginRight}
+	for i, name := range []string{"top", "bottom", "left", "right"} {
+		if margins[i] > 0 {
+			geometry += fmt.Sprintf(",%s=%smm", name, strconv.FormatFloat(margins[i], 'f', -1, 64))
+		}
+	}
+
+	code := "\\usepackage{listings}\n\\lstset{basicstyle=\\ttfamily\\small, breaklines=true, frame=single, columns=fullflexible}"
+	if w.opts.LaTeXMinted {
+		code = "\\usepackage{minted}\n\\setminted{fontsize=\\small, breaklines=true, frame=single}"
+	}
+	return `\documentclass[11pt]{article}
+\usepackage[` + geometry + `]{geometry}
+\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage{lmodern}
+\usepackage{amsmath, amssymb}
+\usepackage{graphicx}
+\usepackage{longtable, booktabs}
+\usepackage{enumitem}
+\usepackage[normalem]{ulem}
+\usepackage{xcolor}
+` + code + `
+\usepackage{hyperref}
+\hypersetup{colorlinks=true, linkcolor=blue, urlcolor=blue}
+% Images keep their size unless they are wider than the text.
+\makeatletter
+\def\maxwidth{\ifdim\Gin@nat@width>\linewidth\linewidth\else\Gin@nat@width\fi}
+\makeatother
+\setlength{\parindent}{0pt}
+\setlength{\parskip}{0.6em}
+`, nil
+}
+
+func (w *latexWriter) blocks(parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		w.block(n)
+	}
+}
+
+func (w *latexWriter) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		level := min(max(n.Level-w.shift, 1), 6)
+		fmt.Fprintf(w.out, "\\%s{%s}", sectionCommands[level], w.inlineString(n))
+		if id := headingID(n); id != "" {
+			fmt.Fprintf(w.out, "\\label{%s}", latexLabel(id))
+		}
+		w.out.WriteString("\n\n")
+	case *ast.Paragraph:
+		if img, ok := soleImage(n); ok {
+			w.figure(img)
+			return
+		}
+		w.inlines(n)
+		w.out.WriteString("\n\n")
+	case *ast.TextBlock:
+		w.inlines(n)
+		w.out.WriteString("\n")
+	case *ast.List:
+		w.list(n)
+	case *ast.Blockquote:
+		w.out.WriteString("\\begin{quote}\n")
+		w.blocks(n)
+		w.out.WriteString("\\end{quote}\n\n")
+	case *ast.FencedCodeBlock:
+		w.codeBlock(n.Lines(), string(n.Language(w.source)))
+	case *ast.CodeBlock:
+		w.codeBlock(n.Lines(), "")
+	case *markdown.MathBlock:
+		tex := strings.TrimSpace(string(n.Literal(w.source)))
+		if strings.HasPrefix(tex, `\begin{`) {
+			// Environments such as align bring their own display mode.
+			w.out.WriteString(tex + "\n\n")
+		} else {
+			w.out.WriteString("\\[\n" + tex + "\n\\]\n\n")
+		}
+	case *ast.ThematicBreak:
+		w.out.WriteString("\\begin{center}\\rule{0.5\\linewidth}{0.4pt}\\end{center}\n\n")
+	case *east.Table:
+		w.table(n)
+	case *east.FootnoteList:
+		// Footnotes are typeset where they are referenced.
+	case *ast.HTMLBlock:
+		// Raw HTML has no LaTeX equivalent.
+	default:
+		w.blocks(n)
+	}
+}
+
+// soleImage reports whether a paragraph holds nothing but an image, which
+// then becomes a figure.
+func soleImage(p *ast.Paragraph) (*ast.Image, bool) {
+	img, ok := p.FirstChild().(*ast.Image)
+	return img, ok && p.ChildCount() == 1
+}
+
+func (w *latexWriter) list(n *ast.List) {
+	env := "itemize"
+	if n.IsOrdered() {
+		env = "enumerate"
+	}
+	var options []string
+	if n.IsOrdered() && n.Start != 1 {
+		options = append(options, "start="+strconv.Itoa(n.Start))
+	}
+	if n.IsTight {
+		options = append(options, "noitemsep")
+	}
+	w.out.WriteString("\\begin{" + env + "}")
+	if len(options) > 0 {
+		w.out.WriteString("[" + strings.Join(options, ", ") + "]")
+	}
+	w.out.WriteString("\n")
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		w.out.WriteString("\\item")
+		if box := taskBox(item); box != nil {
+			if box.IsChecked {
+				w.out.WriteString("[$\\boxtimes$]")
+			} else {
+				w.out.WriteString("[$\\square$]")
+			}
+		}
+		w.out.WriteString(" ")
+		w.blocks(item)
+	}
+	w.out.WriteString("\\end{" + env + "}\n\n")
+}
+
+// taskBox returns the checkbox that starts a task list item, if any.
+func taskBox(item ast.Node) *east.TaskCheckBox {
+	if first := item.FirstChild(); first != nil {
+		if box, ok := first.FirstChild().(*east.TaskCheckBox); ok {
+			return box
+		}
+	}
+	return nil
+}
+
+func (w *latexWriter) codeBlock(segments *text.Segments, language string) {
+	var code strings.Builder
+	for i := 0; i < segments.Len(); i++ {
+		seg := segments.At(i)
+		code.Write(seg.Value(w.source))
+	}
+	body := strings.TrimRight(code.String(), "\n") + "\n"
+
+	if w.opts.LaTeXMinted {
+		lexer := strings.ToLower(language)
+		if lexer == "" || strings.ContainsAny(lexer, "{}[]\\ ") {
+			lexer = "text"
+		}
+		w.out.WriteString("\\begin{minted}{" + lexer + "}\n" + body + "\\end{minted}\n\n")
+		return
+	}
+	w.out.WriteString("\\begin{lstlisting}")
+	if lang, ok := listingsLanguages[strings.ToLower(language)]; ok {
+		w.out.WriteString("[language=" + lang + "]")
+	}
+	w.out.WriteString("\n" + body + "\\end{lstlisting}\n\n")
+}
+
+// table typesets short tables as tabular, and long ones as longtable so
+// they can break across pages with the header repeated.
+func (w *latexWriter) table(n *east.Table) {
+	columns := len(n.Alignments)
+	if columns == 0 {
+		return
+	}
+	var spec strings.Builder
+	for _, a := range n.Alignments {
+		switch a {
+		case east.AlignCenter:
+			spec.WriteByte('c')
+		case east.AlignRight:
+			spec.WriteByte('r')
+		default:
+			spec.WriteByte('l')
+		}
+	}
+
+	var header string
+	var rows []string
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, w.inlineString(cell))
+		}
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		line := strings.Join(cells[:columns], " & ") + ` \\`
+		if _, ok := row.(*east.TableHeader); ok {
+			header = line
+		} else {
+			rows = append(rows, line)
+		}
+	}
+
+	if len(rows) > longtableRows {
+		fmt.Fprintf(w.out, "\\begin{longtable}{%s}\n\\toprule\n%s\n\\midrule\n\\endhead\n\\bottomrule\n\\endfoot\n", spec.String(), header)
+		w.out.WriteString(strings.Join(rows, "\n") + "\n\\end{longtable}\n\n")
+		return
+	}
+	fmt.Fprintf(w.out, "\\begin{center}\n\\begin{tabular}{%s}\n\\toprule\n%s\n\\midrule\n", spec.String(), header)
+	for _, r := range rows {
+		w.out.WriteString(r + "\n")
+	}
+	w.out.WriteString("\\bottomrule\n\\end{tabular}\n\\end{center}\n\n")
+}
+
+// figure places a paragraph's only image as a figure, captioned with its
+// title or alt text.
+func (w *latexWriter) figure(img *ast.Image) {
+	path, ok := w.imagePath(string(img.Destination))
+	if !ok {
+		w.inlines(img)
+		w.out.WriteString("\n\n")
+		return
+	}
+	caption := latexEscaper.Replace(string(img.Title))
+	if caption == "" {
+		caption = w.inlineString(img)
+	}
+	w.out.WriteString("\\begin{figure}[htbp]\n\\centering\n")
+	fmt.Fprintf(w.out, "\\includegraphics[width=\\maxwidth]{%s}\n", path)
+	if caption != "" {
+		fmt.Fprintf(w.out, "\\caption{%s}\n", caption)
+	}
+	w.out.WriteString("\\end{figure}\n\n")
+}
+
+// imagePath returns a local image's path relative to the output file, with
+// forward slashes as TeX expects. Remote images are reported as false.
+func (w *latexWriter) imagePath(dest string) (string, bool) {
+	path := dest
+	if !filepath.IsAbs(path) {
+		var ok bool
+		if path, ok = localfiles.Resolve(w.opts.BaseDir, dest); !ok {
+			return "", false
+		}
+	}
+	if rel, err := filepath.Rel(w.outDir, path); err == nil {
+		path = rel
+	}
+	path = filepath.ToSlash(path)
+	if strings.ContainsAny(path, "{}%#\\") {
+		return "", false
+	}
+	return path, true
+}
+
+func (w *latexWriter) inlines(n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		w.inline(c)
+	}
+}
+
+// inlineString returns the LaTeX of n's inline children.
+func (w *latexWriter) inlineString(n ast.Node) string {
+	saved := w.out
+	var b strings.Builder
+	w.out = &b
+	w.inlines(n)
+	w.out = saved
+	return strings.TrimSpace(b.String())
+}
+
+func (w *latexWriter) inline(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Text:
+		w.out.WriteString(latexEscaper.Replace(string(n.Segment.Value(w.source))))
+		switch {
+		case n.HardLineBreak():
+			w.out.WriteString("\\\\\n")
+		case n.SoftLineBreak():
+			// Prose reflows in LaTeX, as in a printed paper.
+			w.out.WriteString("\n")
+		}
+	case *ast.String:
+		if sub, ok := latexTypography[string(n.Value)]; ok && n.IsCode() {
+			w.out.WriteString(sub)
+		} else {
+			w.out.WriteString(latexEscaper.Replace(stringValue(n)))
+		}
+	case *ast.CodeSpan:
+		w.out.WriteString("\\texttt{" + latexEscaper.Replace(inlineText(n, w.source)) + "}")
+	case *ast.Emphasis:
+		cmd := "emph"
+		if n.Level >= 2 {
+			cmd = "textbf"
+		}
+		w.out.WriteString("\\" + cmd + "{" + w.inlineString(n) + "}")
+	case *east.Strikethrough:
+		w.out.WriteString("\\sout{" + w.inlineString(n) + "}")
+	case *ast.Link:
+		w.link(string(n.Destination), w.inlineString(n))
+	case *ast.AutoLink:
+		dest := string(n.URL(w.source))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(dest, "mailto:") {
+			dest = "mailto:" + dest
+		}
+		w.link(dest, latexEscaper.Replace(string(n.Label(w.source))))
+	case *ast.Image:
+		if path, ok := w.imagePath(string(n.Destination)); ok {
+			fmt.Fprintf(w.out, "\\includegraphics[width=\\maxwidth]{%s}", path)
+		} else {
+			w.out.WriteString("[" + w.inlineString(n) + "]")
+		}
+	case *markdown.MathInline:
+		if n.Display {
+			w.out.WriteString("\\[" + string(n.Literal) + "\\]")
+		} else {
+			w.out.WriteString("$" + string(n.Literal) + "$")
+		}
+	case *ast.RawHTML:
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			if strings.HasPrefix(strings.ToLower(string(seg.Value(w.source))), "<br") {
+				w.out.WriteString("\\\\\n")
+			}
+		}
+	case *east.TaskCheckBox:
+		// Typeset as the item label by list.
+	case *east.FootnoteLink:
+		w.footnote(n.Index)
+	case *east.FootnoteBacklink:
+	default:
+		w.inlines(n)
+	}
+}
+
+// link points text at a section of the document or an external URL.
+// Relative links to other files have no meaning in the output and stay
+// plain text.
+func (w *latexWriter) link(dest, label string) {
+	if fragment, ok := sameDocument(w.opts.Source, w.opts.BaseDir, dest); ok {
+		dest = fragment
+	}
+	if strings.HasPrefix(dest, "#") {
+		fmt.Fprintf(w.out, "\\hyperref[%s]{%s}", latexLabel(dest[1:]), label)
+		return
+	}
+	if i := strings.Index(dest, ":"); i > 0 && !strings.ContainsAny(dest[:i], "/?#") {
+		url := strings.NewReplacer(`\`, `\\`, `#`, `\#`, `%`, `\%`, `{`, `\{`, `}`, `\}`).Replace(dest)
+		fmt.Fprintf(w.out, "\\href{%s}{%s}", url, label)
+		return
+	}
+	w.out.WriteString(label)
+}
+
+// footnote typesets a note at its first reference. Later references
+// repeat its number.
+func (w *latexWriter) footnote(index int) {
+	label := "fn:" + strconv.Itoa(index)
+	if w.noted[index] {
+		fmt.Fprintf(w.out, "\\textsuperscript{\\ref{%s}}", label)
+		return
+	}
+	w.noted[index] = true
+	fn, ok := w.footnotes[index]
+	if !ok {
+		return
+	}
+	var paras []string
+	for c := fn.FirstChild(); c != nil; c = c.NextSibling() {
+		paras = append(paras, w.inlineString(c))
+	}
+	fmt.Fprintf(w.out, "\\footnote{\\label{%s}%s}", label, strings.Join(paras, `\par `))
+}
+
+// latexLabel makes a heading id safe to use as a label.
+func latexLabel(id string) string {
+	return "sec:" + strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\{}%#$&^_~ `, r) {
+			return '-'
+		}
+		return r
+	}, id)
+}
@@ -0,0 +1,273 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/net/html"
+)
+
+// heading is an entry of the PDF outline, taken from the exported HTML.
+type heading struct {
+	Level int
+	Title string
+	ID    string
+}
+
+var headingLevels = map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6}
+
+// collectHeadings returns the headings that carry an id, in document order.
+func collectHeadings(htmlContent string) []heading {
+	var headings []heading
+	var current *heading
+	var title strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(htmlContent))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return nil
+			}
+			return headings
+		case html.StartTagToken:
+			tok := z.Token()
+			level, ok := headingLevels[tok.Data]
+			if !ok || current != nil {
+				continue
+			}
+			if id := attrValue(tok, "id"); id != "" {
+				current = &heading{Level: level, ID: id}
+				title.Reset()
+			}
+		case html.TextToken:
+			if current != nil {
+				title.Write(z.Text())
+			}
+		case html.EndTagToken:
+			tok := z.Token()
+			if current != nil && headingLevels[tok.Data] == current.Level {
+				current.Title = strings.Join(strings.Fields(title.String()), " ")
+				headings = append(headings, *current)
+				current = nil
+			}
+		}
+	}
+}
+
+// destinationLinks returns hidden links to every heading. Chrome only writes
+// named destinations for elements that are linked to from the document, and
+// the outline is built from those destinations.
+func destinationLinks(headings []heading) string {
+	var b strings.Builder
+	b.WriteString(`<nav style="display: none">`)
+	for _, h := range headings {
+		fmt.Fprintf(&b, `<a href="#%s"></a>`, html.EscapeString(h.ID))
+	}
+	b.WriteString(`</nav>`)
+	return b.String()
+}
+
+type outlineItem struct {
+	heading
+	ref      pdfRef
+	parent   *outlineItem
+	children []*outlineItem
+}
+
+// descendants counts the items under it; every item starts expanded.
+func (it *outlineItem) descendants() int {
+	n := len(it.children)
+	for _, c := range it.children {
+		n += c.descendants()
+	}
+	return n
+}
+
+// addOutline adds a bookmark tree mirroring the headings to a PDF printed by
+// Chrome, pointing each bookmark at its heading's named destination.
+func addOutline(pdf []byte, headings []heading) ([]byte, error) {
+	f, err := readPDF(pdf)
+	if err != nil {
+		return nil, err
+	}
+	rootRef, ok := f.trailer["Root"].(pdfRef)
+	if !ok {
+		return nil, fmt.Errorf("%w: no document catalog", errUnsupportedPDF)
+	}
+	v, err := f.object(rootRef)
+	if err != nil {
+		return nil, err
+	}
+	catalog, ok := v.(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("%w: bad document catalog", errUnsupportedPDF)
+	}
+	dests, err := f.namedDestinations(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	u := newPDFUpdate(f)
+	root := &outlineItem{ref: u.alloc()}
+	var all []*outlineItem
+	stack := []*outlineItem{root}
+	for _, h := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		item := &outlineItem{heading: h, ref: u.alloc(), parent: parent}
+		parent.children = append(parent.children, item)
+		stack = append(stack, item)
+		all = append(all, item)
+	}
+	if len(all) == 0 {
+		return pdf, nil
+	}
+
+	for _, item := range all {
+		d := pdfDict{
+			"Title":  outlineTitle(item.Title),
+			"Parent": item.parent.ref,
+		}
+		siblings := item.parent.children
+		for i, s := range siblings {
+			if s != item {
+				continue
+			}
+			if i > 0 {
+				d["Prev"] = siblings[i-1].ref
+			}
+			if i < len(siblings)-1 {
+				d["Next"] = siblings[i+1].ref
+			}
+		}
+		if len(item.children) > 0 {
+			d["First"] = item.children[0].ref
+			d["Last"] = item.children[len(item.children)-1].ref
+			d["Count"] = item.descendants()
+		}
+		if dest, ok := dests[item.ID]; ok {
+			d["Dest"] = dest
+		}
+		u.put(item.ref, d)
+	}
+	u.put(root.ref, pdfDict{
+		"Type":  pdfName("Outlines"),
+		"First": root.children[0].ref,
+		"Last":  root.children[len(root.children)-1].ref,
+		"Count": root.descendants(),
+	})
+
+	catalog["Outlines"] = root.ref
+	catalog["PageMode"] = pdfName("UseOutlines")
+	u.put(rootRef, catalog)
+	return u.finish(), nil
+}
+
+// namedDestinations returns the explicit destination of every named one,
+// from either the catalog's Dests dictionary or its Names tree. Names are
+// also indexed unescaped, as Chrome names them by the link's URL fragment.
+func (f *pdfFile) namedDestinations(catalog pdfDict) (map[string]interface{}, error) {
+	dests := make(map[string]interface{})
+	add := func(name string, v interface{}) error {
+		v, err := f.resolve(v)
+		if err != nil {
+			return err
+		}
+		if d, ok := v.(pdfDict); ok {
+			v = d["D"]
+		}
+		if _, ok := v.(pdfArray); !ok {
+			return nil
+		}
+		dests[name] = v
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			if _, exists := dests[unescaped]; !exists {
+				dests[unescaped] = v
+			}
+		}
+		return nil
+	}
+
+	if v, ok := catalog["Dests"]; ok {
+		d, err := f.resolve(v)
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := d.(pdfDict); ok {
+			for name, dest := range d {
+				if err := add(string(name), dest); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if v, ok := catalog["Names"]; ok {
+		names, err := f.resolve(v)
+		if err != nil {
+			return nil, err
+		}
+		if names, ok := names.(pdfDict); ok && names["Dests"] != nil {
+			if err := f.walkNameTree(names["Dests"], add, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dests, nil
+}
+
+func (f *pdfFile) walkNameTree(v interface{}, fn func(string, interface{}) error, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("%w: name tree too deep", errUnsupportedPDF)
+	}
+	v, err := f.resolve(v)
+	if err != nil {
+		return err
+	}
+	node, ok := v.(pdfDict)
+	if !ok {
+		return nil
+	}
+	if kids, ok := node["Kids"].(pdfArray); ok {
+		for _, kid := range kids {
+			if err := f.walkNameTree(kid, fn, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if names, ok := node["Names"].(pdfArray); ok {
+		for i := 0; i+1 < len(names); i += 2 {
+			if key, ok := names[i].(pdfString); ok {
+				if err := fn(string(key), names[i+1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// outlineTitle encodes a bookmark title, as UTF-16 when it is not ASCII.
+func outlineTitle(title string) pdfString {
+	ascii := true
+	for _, r := range title {
+		if r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return pdfString(title)
+	}
+	s := pdfString{0xfe, 0xff}
+	for _, c := range utf16.Encode([]rune(title)) {
+		s = append(s, byte(c>>8), byte(c))
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PDFOptions is the page setup passed to Chrome's Page.printToPDF. Margins
+// are in millimetres.
+type PDFOptions struct {
+	PaperSize       string  `json:"paperSize"`
+	Landscape       bool    `json:"landscape"`
+	MarginTop       float64 `json:"marginTop"`
+	MarginBottom    float64 `json:"marginBottom"`
+	MarginLeft      float64 `json:"marginLeft"`
+	MarginRight     float64 `json:"marginRight"`
+	Scale           float64 `json:"scale"`
+	PrintBackground bool    `json:"printBackground"`
+	// HeaderFooter prints HeaderTemplate and FooterTemplate on every page.
+	// Templates may use {title}, {date}, {page} and {pages}, as well as
+	// Chrome's own template classes.
+	HeaderFooter   bool   `json:"headerFooter"`
+	HeaderTemplate string `json:"headerTemplate"`
+	FooterTemplate string `json:"footerTemplate"`
+}
+
+// paperSizes holds width and height in inches, portrait.
+var paperSizes = map[string][2]float64{
+	"a3":      {11.69, 16.54},
+	"a4":      {8.27, 11.69},
+	"a5":      {5.83, 8.27},
+	"letter":  {8.5, 11},
+	"legal":   {8.5, 14},
+	"tabloid": {11, 17},
+	// slidePaperSize, for presentations only.
+	slidePaperSize: {13.333, 7.5},
+}
+
+const mmPerInch = 25.4
+
+var templateFields = strings.NewReplacer(
+	"{title}", `<span class="title"></span>`,
+	"{date}", `<span class="date"></span>`,
+	"{page}", `<span class="pageNumber"></span>`,
+	"{pages}", `<span class="totalPages"></span>`,
+	"{url}", `<span class="url"></span>`,
+)
+
+// headerFooterTemplate expands placeholders and applies a readable default
+// style; Chrome renders templates at a near-invisible size otherwise.
+func headerFooterTemplate(tmpl string) string {
+	if tmpl == "" {
+		// An empty template would make Chrome print its default one.
+		return "<span></span>"
+	}
+	return `<div style="width: 100%; font-size: 9px; color: #666; text-align: center; padding: 0 10mm;">` +
+		templateFields.Replace(tmpl) + `</div>`
+}
+
+// printParams builds the Page.printToPDF parameters.
+func (o PDFOptions) printParams() (map[string]interface{}, error) {
+	size, ok := paperSizes[strings.ToLower(o.PaperSize)]
+	if o.PaperSize == "" {
+		size, ok = paperSizes["a4"], true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown paper size %q", o.PaperSize)
+	}
+	scale := o.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if scale < 0.1 || scale > 2 {
+		return nil, fmt.Errorf("scale must be between 0.1 and 2, got %g", scale)
+	}
+
+	params := map[string]interface{}{
+		"paperWidth":        size[0],
+		"paperHeight":       size[1],
+		"landscape":         o.Landscape,
+		"marginTop":         o.MarginTop / mmPerInch,
+		"marginBottom":      o.MarginBottom / mmPerInch,
+		"marginLeft":        o.MarginLeft / mmPerInch,
+		"marginRight":       o.MarginRight / mmPerInch,
+		"scale":             scale,
+		"printBackground":   o.PrintBackground,
+		"preferCSSPageSize": false,
+	}
+	if o.HeaderFooter {
+		params["displayHeaderFooter"] = true
+		params["headerTemplate"] = headerFooterTemplate(o.HeaderTemplate)
+		params["footerTemplate"] = headerFooterTemplate(o.FooterTemplate)
+	}
+	return params, nil
+}
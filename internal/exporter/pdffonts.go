@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"unicode"
+
+	"markviewpro/internal/markdown"
+
+	"github.com/yuin/goldmark/ast"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// pdfFont is a TrueType font registered with fpdf under a family and style.
+type pdfFont struct {
+	family, style string
+	ttf           []byte
+}
+
+var goFonts = []pdfFont{
+	{"sans", "", goregular.TTF},
+	{"sans", "B", gobold.TTF},
+	{"sans", "I", goitalic.TTF},
+	{"sans", "BI", gobolditalic.TTF},
+	{"mono", "", gomono.TTF},
+	{"mono", "B", gomonobold.TTF},
+	{"mono", "I", gomonoitalic.TTF},
+	{"mono", "BI", gomonobolditalic.TTF},
+}
+
+// pdfFonts picks the fonts the built-in PDF renderer draws text with. The
+// Go fonts cover Latin, Greek and Cyrillic only; for other scripts, such as
+// Chinese or Japanese, an installed TrueType font with a glyph for every
+// character of text replaces them in every family and style. Without one,
+// the export fails rather than printing blank boxes.
+func pdfFonts(text string) ([]pdfFont, error) {
+	var missing []rune
+	for _, ttf := range [][]byte{goregular.TTF, gomono.TTF} {
+		font, err := sfnt.Parse(ttf)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range missingGlyphs(font, text) {
+			if !strings.ContainsRune(string(missing), r) {
+				missing = append(missing, r)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return goFonts, nil
+	}
+
+	ttf := fallbackFont(text)
+	if ttf == nil {
+		sample := string(missing[:min(len(missing), 10)])
+		return nil, fmt.Errorf("no installed font can print %q; install a TrueType font for it, or Chrome or Chromium", sample)
+	}
+	fonts := make([]pdfFont, len(goFonts))
+	for i, f := range goFonts {
+		fonts[i] = pdfFont{f.family, f.style, ttf}
+	}
+	return fonts, nil
+}
+
+// missingGlyphs returns the characters of text that font has no glyph for,
+// each once. Spaces and control characters are not drawn and are skipped.
+func missingGlyphs(font *sfnt.Font, text string) []rune {
+	var buf sfnt.Buffer
+	var missing []rune
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if seen[r] || unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		seen[r] = true
+		if i, err := font.GlyphIndex(&buf, r); err != nil || i == 0 {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// fallbackFont returns the first installed TrueType font that has a glyph
+// for every character of text, or nil.
+func fallbackFont(text string) []byte {
+	var found []byte
+	for _, dir := range fontDirs() {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".ttf") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			font, err := sfnt.Parse(data)
+			if err != nil || len(missingGlyphs(font, text)) > 0 {
+				return nil
+			}
+			found = data
+			return filepath.SkipAll
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// fontDirs lists the folders that system and user fonts are installed in.
+func fontDirs() []string {
+	home, _ := os.UserHomeDir()
+	switch goruntime.GOOS {
+	case "windows":
+		return []string{
+			filepath.Join(os.Getenv("WINDIR"), "Fonts"),
+			filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Fonts"),
+		}
+	case "darwin":
+		return []string{
+			filepath.Join(home, "Library", "Fonts"),
+			"/Library/Fonts",
+			"/System/Library/Fonts",
+		}
+	default:
+		return []string{
+			filepath.Join(home, ".local", "share", "fonts"),
+			filepath.Join(home, ".fonts"),
+			"/usr/local/share/fonts",
+			"/usr/share/fonts",
+		}
+	}
+}
+
+// documentText returns the text the built-in renderer prints for doc,
+// including code, and headers and footers when they are shown.
+func documentText(doc ast.Node, source []byte, opts Options) string {
+	var b strings.Builder
+	if opts.PDF.HeaderFooter {
+		b.WriteString(opts.Title + opts.PDF.HeaderTemplate + opts.PDF.FooterTemplate)
+	}
+	b.WriteString(inlineText(doc, source))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch n.Kind() {
+		case ast.KindFencedCodeBlock, ast.KindCodeBlock, markdown.KindMathBlock:
+			if entering {
+				lines := n.Lines()
+				for i := 0; i < lines.Len(); i++ {
+					line := lines.At(i)
+					b.Write(line.Value(source))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
@@ -0,0 +1,479 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// A minimal reader and writer for the PDF object syntax, enough to inspect
+// the document catalog of a printed PDF and append an incremental update.
+
+type pdfName string
+
+type pdfRef struct {
+	Num, Gen int
+}
+
+type pdfDict map[pdfName]interface{}
+
+type pdfArray []interface{}
+
+type pdfString []byte
+
+// pdfRaw holds numbers, booleans and null verbatim.
+type pdfRaw string
+
+var errUnsupportedPDF = errors.New("unsupported PDF structure")
+
+type pdfParser struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		p.pos++
+	}
+}
+
+// token reads a run of regular characters, such as a number or keyword.
+func (p *pdfParser) token() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *pdfParser) value() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, errUnsupportedPDF
+	}
+	switch c := p.data[p.pos]; {
+	case bytes.HasPrefix(p.data[p.pos:], []byte("<<")):
+		return p.dict()
+	case c == '<':
+		return p.hexString()
+	case c == '[':
+		return p.array()
+	case c == '(':
+		return p.literalString()
+	case c == '/':
+		return p.name(), nil
+	}
+
+	tok := p.token()
+	if tok == "" {
+		return nil, fmt.Errorf("%w: unexpected %q at offset %d", errUnsupportedPDF, p.data[p.pos], p.pos)
+	}
+	// An integer may start an indirect reference "num gen R".
+	if num, err := strconv.Atoi(tok); err == nil {
+		save := p.pos
+		if gen, err := strconv.Atoi(p.token()); err == nil && p.token() == "R" {
+			return pdfRef{Num: num, Gen: gen}, nil
+		}
+		p.pos = save
+	}
+	return pdfRaw(tok), nil
+}
+
+func (p *pdfParser) dict() (pdfDict, error) {
+	p.pos += 2
+	d := make(pdfDict)
+	for {
+		p.skipSpace()
+		if bytes.HasPrefix(p.data[p.pos:], []byte(">>")) {
+			p.pos += 2
+			return d, nil
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			return nil, fmt.Errorf("%w: bad dictionary key at offset %d", errUnsupportedPDF, p.pos)
+		}
+		key := p.name()
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		d[key] = v
+	}
+}
+
+func (p *pdfParser) array() (pdfArray, error) {
+	p.pos++
+	a := pdfArray{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, errUnsupportedPDF
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return a, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+}
+
+func (p *pdfParser) name() pdfName {
+	p.pos++
+	var b []byte
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		c := p.data[p.pos]
+		if c == '#' && p.pos+2 < len(p.data) {
+			if v, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				p.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		p.pos++
+	}
+	return pdfName(b)
+}
+
+func (p *pdfParser) hexString() (pdfString, error) {
+	p.pos++
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return nil, errUnsupportedPDF
+	}
+	var digits []byte
+	for _, c := range p.data[p.pos : p.pos+end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	p.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	s := make(pdfString, len(digits)/2)
+	for i := range s {
+		v, err := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		if err != nil {
+			return nil, errUnsupportedPDF
+		}
+		s[i] = byte(v)
+	}
+	return s, nil
+}
+
+func (p *pdfParser) literalString() (pdfString, error) {
+	p.pos++
+	var s pdfString
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s, nil
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, errUnsupportedPDF
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation.
+				if e == '\r' && p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						v = v*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return nil, errUnsupportedPDF
+}
+
+// pdfFile indexes the objects of a PDF with classic cross-reference tables.
+type pdfFile struct {
+	data      []byte
+	offsets   map[int]int
+	gens      map[int]int
+	trailer   pdfDict
+	startxref int
+}
+
+func readPDF(data []byte) (*pdfFile, error) {
+	i := bytes.LastIndex(data, []byte("startxref"))
+	if i < 0 {
+		return nil, fmt.Errorf("%w: no startxref", errUnsupportedPDF)
+	}
+	p := &pdfParser{data: data, pos: i + len("startxref")}
+	startxref, err := strconv.Atoi(p.token())
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad startxref", errUnsupportedPDF)
+	}
+
+	f := &pdfFile{data: data, offsets: make(map[int]int), gens: make(map[int]int), startxref: startxref}
+	seen := make(map[int]bool)
+	for offset := startxref; ; {
+		if seen[offset] || offset < 0 || offset >= len(data) {
+			return nil, fmt.Errorf("%w: bad cross-reference offset", errUnsupportedPDF)
+		}
+		seen[offset] = true
+		trailer, err := f.readXref(offset)
+		if err != nil {
+			return nil, err
+		}
+		if f.trailer == nil {
+			f.trailer = trailer
+		}
+		prev, ok := trailer["Prev"].(pdfRaw)
+		if !ok {
+			return f, nil
+		}
+		if offset, err = strconv.Atoi(string(prev)); err != nil {
+			return nil, fmt.Errorf("%w: bad Prev", errUnsupportedPDF)
+		}
+	}
+}
+
+// readXref reads one cross-reference section and its trailer. Entries from
+// newer sections, read first, take precedence.
+func (f *pdfFile) readXref(offset int) (pdfDict, error) {
+	p := &pdfParser{data: f.data, pos: offset}
+	if p.token() != "xref" {
+		// Cross-reference streams and hybrid files are not handled.
+		return nil, fmt.Errorf("%w: no classic cross-reference table", errUnsupportedPDF)
+	}
+	for {
+		tok := p.token()
+		if tok == "trailer" {
+			break
+		}
+		start, err1 := strconv.Atoi(tok)
+		count, err2 := strconv.Atoi(p.token())
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%w: bad cross-reference table", errUnsupportedPDF)
+		}
+		for n := start; n < start+count; n++ {
+			off, err1 := strconv.Atoi(p.token())
+			gen, err2 := strconv.Atoi(p.token())
+			kind := p.token()
+			if err1 != nil || err2 != nil || (kind != "n" && kind != "f") {
+				return nil, fmt.Errorf("%w: bad cross-reference entry", errUnsupportedPDF)
+			}
+			if _, ok := f.offsets[n]; ok || kind != "n" {
+				continue
+			}
+			f.offsets[n] = off
+			f.gens[n] = gen
+		}
+	}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	trailer, ok := v.(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("%w: bad trailer", errUnsupportedPDF)
+	}
+	return trailer, nil
+}
+
+func (f *pdfFile) object(ref pdfRef) (interface{}, error) {
+	off, ok := f.offsets[ref.Num]
+	if !ok || off >= len(f.data) {
+		return nil, fmt.Errorf("%w: object %d not found", errUnsupportedPDF, ref.Num)
+	}
+	p := &pdfParser{data: f.data, pos: off}
+	p.token()
+	p.token()
+	if p.token() != "obj" {
+		return nil, fmt.Errorf("%w: object %d not found", errUnsupportedPDF, ref.Num)
+	}
+	return p.value()
+}
+
+// resolve follows v if it is an indirect reference.
+func (f *pdfFile) resolve(v interface{}) (interface{}, error) {
+	if ref, ok := v.(pdfRef); ok {
+		return f.object(ref)
+	}
+	return v, nil
+}
+
+func (f *pdfFile) size() int {
+	if raw, ok := f.trailer["Size"].(pdfRaw); ok {
+		if n, err := strconv.Atoi(string(raw)); err == nil {
+			return n
+		}
+	}
+	size := 0
+	for n := range f.offsets {
+		size = max(size, n+1)
+	}
+	return size
+}
+
+// pdfUpdate appends new and replaced objects to a PDF as an incremental
+// update, leaving the original bytes untouched.
+type pdfUpdate struct {
+	file    *pdfFile
+	buf     bytes.Buffer
+	offsets map[int]int
+	gens    map[int]int
+	next    int
+}
+
+func newPDFUpdate(f *pdfFile) *pdfUpdate {
+	u := &pdfUpdate{file: f, offsets: make(map[int]int), gens: make(map[int]int), next: f.size()}
+	u.buf.Write(f.data)
+	if len(f.data) > 0 && f.data[len(f.data)-1] != '\n' {
+		u.buf.WriteByte('\n')
+	}
+	return u
+}
+
+func (u *pdfUpdate) alloc() pdfRef {
+	ref := pdfRef{Num: u.next}
+	u.next++
+	return ref
+}
+
+func (u *pdfUpdate) put(ref pdfRef, v interface{}) {
+	u.offsets[ref.Num] = u.buf.Len()
+	u.gens[ref.Num] = ref.Gen
+	fmt.Fprintf(&u.buf, "%d %d obj\n", ref.Num, ref.Gen)
+	writePDFValue(&u.buf, v)
+	u.buf.WriteString("\nendobj\n")
+}
+
+func (u *pdfUpdate) finish() []byte {
+	nums := make([]int, 0, len(u.offsets))
+	for n := range u.offsets {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	xref := u.buf.Len()
+	u.buf.WriteString("xref\n")
+	for i := 0; i < len(nums); {
+		j := i + 1
+		for j < len(nums) && nums[j] == nums[j-1]+1 {
+			j++
+		}
+		fmt.Fprintf(&u.buf, "%d %d\n", nums[i], j-i)
+		for _, n := range nums[i:j] {
+			fmt.Fprintf(&u.buf, "%010d %05d n \n", u.offsets[n], u.gens[n])
+		}
+		i = j
+	}
+
+	trailer := make(pdfDict)
+	for k, v := range u.file.trailer {
+		if k != "Prev" && k != "XRefStm" {
+			trailer[k] = v
+		}
+	}
+	trailer["Size"] = pdfRaw(strconv.Itoa(u.next))
+	trailer["Prev"] = pdfRaw(strconv.Itoa(u.file.startxref))
+	u.buf.WriteString("trailer\n")
+	writePDFValue(&u.buf, trailer)
+	fmt.Fprintf(&u.buf, "\nstartxref\n%d\n%%%%EOF\n", xref)
+	return u.buf.Bytes()
+}
+
+func writePDFValue(b *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case pdfName:
+		b.WriteByte('/')
+		for _, c := range []byte(v) {
+			if c < 0x21 || c > 0x7e || c == '#' || isPDFDelimiter(c) {
+				fmt.Fprintf(b, "#%02X", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	case pdfRef:
+		fmt.Fprintf(b, "%d %d R", v.Num, v.Gen)
+	case pdfDict:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		b.WriteString("<<")
+		for _, k := range keys {
+			writePDFValue(b, pdfName(k))
+			b.WriteByte(' ')
+			writePDFValue(b, v[pdfName(k)])
+		}
+		b.WriteString(">>")
+	case pdfArray:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writePDFValue(b, item)
+		}
+		b.WriteByte(']')
+	case pdfString:
+		fmt.Fprintf(b, "<%X>", []byte(v))
+	case pdfRaw:
+		b.WriteString(string(v))
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	default:
+		b.WriteString("null")
+	}
+}
@@ -0,0 +1,377 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"markviewpro/internal/markdown"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// ToPlainText writes a parsed Markdown document as plain text; see
+// PlainText.
+func (e *Exporter) ToPlainText(ctx context.Context, source []byte, doc ast.Node, outputPath string, opts Options) error {
+	opts.progress("Converting document", 10)
+	out, err := e.plainText(ctx, source, doc, opts)
+	if err != nil {
+		return err
+	}
+	opts.progress("Writing file", 95)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, []byte(out), 0644)
+}
+
+// PlainText strips the formatting from a parsed Markdown document. Prose
+// is wrapped at opts.TextWidth columns, links and footnotes become
+// numbered notes listed at the end, and tables are drawn in ASCII.
+func (e *Exporter) PlainText(source []byte, doc ast.Node, opts Options) string {
+	out, _ := e.plainText(context.Background(), source, doc, opts)
+	return out
+}
+
+func (e *Exporter) plainText(ctx context.Context, source []byte, doc ast.Node, opts Options) (string, error) {
+	w := &textWriter{
+		source:    source,
+		opts:      opts,
+		footnotes: make(map[int]*east.Footnote),
+		linkNotes: make(map[string]int),
+		noteOf:    make(map[int]int),
+	}
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if fn, ok := n.(*east.Footnote); ok && entering {
+			w.footnotes[fn.Index] = fn
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var blocks []string
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if lines := w.block(n, opts.TextWidth); len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+		}
+	}
+	if len(w.notes) > 0 {
+		blocks = append(blocks, strings.Join(w.notes, "\n"))
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+type textWriter struct {
+	source    []byte
+	opts      Options
+	footnotes map[int]*east.Footnote
+	// notes are the numbered links and footnotes, in reference order.
+	notes     []string
+	linkNotes map[string]int
+	// noteOf maps footnote indexes to note numbers.
+	noteOf map[int]int
+}
+
+// blocks returns the lines of parent's children, separated by a blank
+// line unless tight is set.
+func (w *textWriter) blocks(parent ast.Node, width int, tight bool) []string {
+	var lines []string
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		block := w.block(n, width)
+		if len(block) == 0 {
+			continue
+		}
+		if len(lines) > 0 && !tight {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+func (w *textWriter) block(n ast.Node, width int) []string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		title := strings.Join(strings.Fields(w.inlineString(n)), " ")
+		switch n.Level {
+		case 1:
+			return []string{title, strings.Repeat("=", markdown.DisplayWidth(title))}
+		case 2:
+			return []string{title, strings.Repeat("-", markdown.DisplayWidth(title))}
+		}
+		return []string{title}
+	case *ast.Paragraph, *ast.TextBlock:
+		var lines []string
+		for _, line := range strings.Split(w.inlineString(n), "\n") {
+			lines = append(lines, wrapText(line, width)...)
+		}
+		return lines
+	case *ast.List:
+		return w.list(n, width)
+	case *ast.Blockquote:
+		lines := w.blocks(n, width-2, false)
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return lines
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		return indentLines(segmentLines(n.Lines(), w.source), "    ")
+	case *markdown.MathBlock:
+		return indentLines(strings.Split(strings.TrimSpace(string(n.Literal(w.source))), "\n"), "    ")
+	case *ast.ThematicBreak:
+		return []string{"* * *"}
+	case *east.Table:
+		return w.table(n)
+	case *east.FootnoteList, *ast.HTMLBlock:
+		// Footnotes are listed with the notes; raw HTML is not text.
+		return nil
+	}
+	return w.blocks(n, width, false)
+}
+
+func (w *textWriter) list(n *ast.List, width int) []string {
+	var lines []string
+	number := n.Start
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "* "
+		if n.IsOrdered() {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		if len(lines) > 0 && !n.IsTight {
+			lines = append(lines, "")
+		}
+		indent := strings.Repeat(" ", len(marker))
+		for i, line := range w.blocks(item, width-len(marker), n.IsTight) {
+			switch {
+			case i == 0:
+				line = marker + line
+			case line != "":
+				line = indent + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// table draws a table with ASCII rules, aligning cells as the Markdown
+// delimiter row asks.
+func (w *textWriter) table(n *east.Table) []string {
+	var rows [][]string
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, strings.Join(strings.Fields(w.inlineString(cell)), " "))
+		}
+		rows = append(rows, cells)
+	}
+	widths := make([]int, len(n.Alignments))
+	for _, cells := range rows {
+		for i, cell := range cells {
+			if i < len(widths) {
+				widths[i] = max(widths[i], markdown.DisplayWidth(cell))
+			}
+		}
+	}
+
+	rule := func(char string) string {
+		var b strings.Builder
+		for _, width := range widths {
+			b.WriteString("+" + strings.Repeat(char, width+2))
+		}
+		return b.String() + "+"
+	}
+	lines := []string{rule("-")}
+	for r, cells := range rows {
+		var b strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			pad := width - markdown.DisplayWidth(cell)
+			switch n.Alignments[i] {
+			case east.AlignRight:
+				cell = strings.Repeat(" ", pad) + cell
+			case east.AlignCenter:
+				cell = strings.Repeat(" ", pad/2) + cell + strings.Repeat(" ", pad-pad/2)
+			default:
+				cell += strings.Repeat(" ", pad)
+			}
+			b.WriteString("| " + cell + " ")
+		}
+		lines = append(lines, b.String()+"|")
+		if r == 0 {
+			if _, ok := n.FirstChild().(*east.TableHeader); ok {
+				lines = append(lines, rule("="))
+			}
+		}
+	}
+	return append(lines, rule("-"))
+}
+
+// inlineString returns the text of n's inline children, with a newline for
+// each line break.
+func (w *textWriter) inlineString(n ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		w.inline(&b, c)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (w *textWriter) inline(b *strings.Builder, n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Text:
+		value := util.UnescapePunctuations(n.Segment.Value(w.source))
+		value = util.ResolveNumericReferences(util.ResolveEntityNames(value))
+		b.Write(value)
+		if n.HardLineBreak() || n.SoftLineBreak() {
+			// The preview renders soft breaks as line breaks too.
+			b.WriteString("\n")
+		}
+	case *ast.String:
+		b.WriteString(stringValue(n))
+	case *ast.CodeSpan:
+		b.WriteString(inlineText(n, w.source))
+	case *markdown.MathInline:
+		b.WriteString(mathText(n))
+	case *ast.Link:
+		label := w.inlineString(n)
+		b.WriteString(label)
+		if dest := string(n.Destination); dest != label && !strings.HasPrefix(dest, "#") {
+			fmt.Fprintf(b, " [%d]", w.linkNote(dest))
+		}
+	case *ast.AutoLink:
+		b.Write(n.Label(w.source))
+	case *ast.Image:
+		if alt := w.inlineString(n); alt != "" {
+			b.WriteString("[image: " + alt + "]")
+		} else {
+			b.WriteString("[image]")
+		}
+	case *ast.RawHTML:
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			if strings.HasPrefix(strings.ToLower(string(seg.Value(w.source))), "<br") {
+				b.WriteString("\n")
+			}
+		}
+	case *east.TaskCheckBox:
+		if n.IsChecked {
+			b.WriteString("[x] ")
+		} else {
+			b.WriteString("[ ] ")
+		}
+	case *east.FootnoteLink:
+		fmt.Fprintf(b, "[%d]", w.footnoteNote(n.Index))
+	case *east.FootnoteBacklink:
+	default:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			w.inline(b, c)
+		}
+	}
+}
+
+// linkNote returns the note number listing dest, adding the note the
+// first time the URL is linked.
+func (w *textWriter) linkNote(dest string) int {
+	if number, ok := w.linkNotes[dest]; ok {
+		return number
+	}
+	number := w.addNote([]string{dest})
+	w.linkNotes[dest] = number
+	return number
+}
+
+// footnoteNote returns the note number of a footnote, adding its text the
+// first time it is referenced.
+func (w *textWriter) footnoteNote(index int) int {
+	if number, ok := w.noteOf[index]; ok {
+		return number
+	}
+	// Reserve the number before converting, since the footnote may link.
+	number := len(w.notes) + 1
+	w.notes = append(w.notes, "")
+	w.noteOf[index] = number
+	var lines []string
+	if fn, ok := w.footnotes[index]; ok {
+		lines = w.blocks(fn, w.opts.TextWidth-noteIndent(number), false)
+	}
+	w.notes[number-1] = noteText(number, lines)
+	return number
+}
+
+func (w *textWriter) addNote(lines []string) int {
+	number := len(w.notes) + 1
+	w.notes = append(w.notes, noteText(number, lines))
+	return number
+}
+
+func noteIndent(number int) int {
+	return len(strconv.Itoa(number)) + 3
+}
+
+// noteText labels a note "[n]" and indents its following lines under it.
+func noteText(number int, lines []string) string {
+	label := "[" + strconv.Itoa(number) + "] "
+	if len(lines) == 0 {
+		return strings.TrimSpace(label)
+	}
+	indent := strings.Repeat(" ", len(label))
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return label + strings.Join(lines, "\n")
+}
+
+// wrapText breaks a line into lines of at most width columns at spaces.
+// Words longer than width get a line of their own; zero width does not
+// wrap.
+func wrapText(line string, width int) []string {
+	if width <= 0 || markdown.DisplayWidth(line) <= width {
+		return []string{line}
+	}
+	var lines []string
+	var current strings.Builder
+	for _, word := range strings.Fields(line) {
+		if current.Len() > 0 && markdown.DisplayWidth(current.String())+1+markdown.DisplayWidth(word) > width {
+			lines = append(lines, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(word)
+	}
+	return append(lines, current.String())
+}
+
+func segmentLines(segments *text.Segments, source []byte) []string {
+	var b strings.Builder
+	for i := 0; i < segments.Len(); i++ {
+		seg := segments.At(i)
+		b.Write(seg.Value(source))
+	}
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+}
+
+func indentLines(lines []string, indent string) []string {
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return lines
+}
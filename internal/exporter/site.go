@@ -0,0 +1,562 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"markviewpro/internal/localfiles"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SitePage is one rendered document of a static site.
+type SitePage struct {
+	// Path is the source file, inside the site's root folder.
+	Path string
+	// Title is used in the navigation when the page has no H1.
+	Title string
+	HTML  string
+}
+
+// SiteTemplateData is what a site layout template is executed with.
+type SiteTemplateData struct {
+	SiteTitle string
+	Title     string
+	Lang      string
+	// Root is the relative URL of the site root from the page, such as
+	// "../", for links to assets/site.css and the search scripts.
+	Root    string
+	Content htmltemplate.HTML
+	Nav     htmltemplate.HTML
+	TOC     htmltemplate.HTML
+}
+
+// searchEntry is a page in search-index.js.
+type searchEntry struct {
+	URL      string   `json:"url"`
+	Title    string   `json:"title"`
+	Headings []string `json:"headings"`
+	Text     string   `json:"text"`
+}
+
+type sitePage struct {
+	SitePage
+	out      string // output path, relative to the site root
+	headings []heading
+}
+
+type siteBuilder struct {
+	root      string
+	outputDir string
+	pages     []*sitePage
+	byPath    map[string]*sitePage
+	// assets maps copied source files to their output paths.
+	assets map[string]string
+}
+
+// ToSite renders a folder of documents as a browsable static site in
+// outputDir. Pages keep the folder structure, with .md links rewritten to
+// the .html pages and local assets copied alongside. Every page gets a
+// navigation sidebar, a table of contents and client-side search. The
+// layout comes from opts.SiteTemplate, or the built-in one when empty.
+func (e *Exporter) ToSite(ctx context.Context, root string, pages []SitePage, outputDir string, opts Options) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("nothing to export")
+	}
+	layout, err := siteLayout(opts.SiteTemplate)
+	if err != nil {
+		return err
+	}
+	css, err := e.ThemeCSS(opts.Theme)
+	if err != nil {
+		return err
+	}
+
+	b := &siteBuilder{
+		root:      filepath.Clean(root),
+		outputDir: outputDir,
+		byPath:    make(map[string]*sitePage),
+		assets:    make(map[string]string),
+	}
+	for _, p := range pages {
+		rel, err := filepath.Rel(b.root, p.Path)
+		if err != nil || isOutside(rel) {
+			return fmt.Errorf("%s is outside %s", p.Path, root)
+		}
+		page := &sitePage{
+			SitePage: p,
+			out:      path.Join(filepath.ToSlash(filepath.Dir(rel)), pageName(filepath.Base(rel))),
+			headings: collectHeadings(p.HTML),
+		}
+		for _, h := range page.headings {
+			if h.Level == 1 && h.Title != "" {
+				page.Title = h.Title
+				break
+			}
+		}
+		b.pages = append(b.pages, page)
+		b.byPath[filepath.Clean(p.Path)] = page
+	}
+	b.useReadmeAsIndex()
+
+	var index []searchEntry
+	for i, page := range b.pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		content, err := rewriteURLs(page.HTML, func(ref string) string {
+			return b.rewrite(page, ref)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page.Path, err)
+		}
+		var out strings.Builder
+		err = layout.Execute(&out, SiteTemplateData{
+			SiteTitle: opts.Title,
+			Title:     page.Title,
+			Lang:      opts.language(),
+			Root:      rootPrefix(page.out),
+			Content:   htmltemplate.HTML(content),
+			Nav:       htmltemplate.HTML(b.nav(page)),
+			TOC:       htmltemplate.HTML(pageTOC(page.headings)),
+		})
+		if err != nil {
+			return fmt.Errorf("site template failed on %s: %w", page.Path, err)
+		}
+		if err := writeDocument(out.String(), filepath.Join(outputDir, filepath.FromSlash(page.out))); err != nil {
+			return err
+		}
+		index = append(index, b.searchEntry(page))
+		opts.progress("Writing pages", 5+85*(i+1)/len(b.pages))
+	}
+
+	opts.progress("Writing search index", 95)
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	files := map[string]string{
+		"search-index.js":  "window.siteSearchIndex = " + string(data) + ";\n",
+		"assets/site.css":  css + "\n" + siteCSS,
+		"assets/search.js": siteSearchJS,
+	}
+	if _, ok := b.pageAt("index.html"); !ok {
+		files["index.html"] = siteRedirect(b.pages[0].out)
+	}
+	for name, content := range files {
+		if err := writeDocument(content, filepath.Join(outputDir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// siteLayout parses the user's layout template, or the built-in one.
+func siteLayout(templatePath string) (*htmltemplate.Template, error) {
+	source := defaultSiteTemplate
+	if templatePath != "" {
+		data, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read site template: %w", err)
+		}
+		source = string(data)
+	}
+	t, err := htmltemplate.New("site").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid site template: %w", err)
+	}
+	return t, nil
+}
+
+// pageName returns the output name of a Markdown file.
+func pageName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".html"
+}
+
+// rootPrefix returns the relative URL of the site root from a page.
+func rootPrefix(out string) string {
+	return strings.Repeat("../", strings.Count(out, "/"))
+}
+
+// useReadmeAsIndex publishes README.md as index.html in folders that have
+// no index.md, so folder URLs open something.
+func (b *siteBuilder) useReadmeAsIndex() {
+	for _, page := range b.pages {
+		dir := path.Dir(page.out)
+		if !strings.EqualFold(path.Base(page.out), "readme.html") {
+			continue
+		}
+		index := path.Join(dir, "index.html")
+		if _, ok := b.pageAt(index); !ok {
+			page.out = index
+		}
+	}
+}
+
+func (b *siteBuilder) pageAt(out string) (*sitePage, bool) {
+	for _, page := range b.pages {
+		if page.out == out {
+			return page, true
+		}
+	}
+	return nil, false
+}
+
+// rewrite points a reference made from page into the site: links to other
+// pages go to their .html file, and local files inside the root are copied.
+// Remote URLs and fragments are left as they are, and so are references to
+// missing files and files outside the root.
+func (b *siteBuilder) rewrite(page *sitePage, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Path == "" {
+		return ref
+	}
+	target, ok := localfiles.Resolve(filepath.Dir(page.Path), ref)
+	if !ok {
+		return ref
+	}
+	var out string
+	if linked, ok := b.byPath[filepath.Clean(target)]; ok {
+		out = linked.out
+	} else if out, ok = b.copyAsset(target); !ok {
+		return ref
+	}
+	rel := relativeURL(page.out, out)
+	if u.RawQuery != "" {
+		rel += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		rel += "#" + u.EscapedFragment()
+	}
+	return rel
+}
+
+// copyAsset copies a local file into the site once, at the same place
+// relative to the root, and returns its output path. Files outside the
+// root, including through symlinks, are not published.
+func (b *siteBuilder) copyAsset(source string) (string, bool) {
+	if out, ok := b.assets[source]; ok {
+		return out, true
+	}
+	info, err := os.Stat(source)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	root, resolved := b.root, source
+	if r, err := filepath.EvalSymlinks(root); err == nil {
+		root = r
+	}
+	if r, err := filepath.EvalSymlinks(source); err == nil {
+		resolved = r
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || isOutside(rel) {
+		return "", false
+	}
+	rel, err := filepath.Rel(b.root, source)
+	if err != nil || isOutside(rel) {
+		return "", false
+	}
+	out := filepath.ToSlash(rel)
+	if err := copyFile(source, filepath.Join(b.outputDir, filepath.FromSlash(out))); err != nil {
+		return "", false
+	}
+	b.assets[source] = out
+	return out, true
+}
+
+func copyFile(source, dest string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// relativeURL returns the URL of the site path to from the page at from.
+func relativeURL(from, to string) string {
+	fromDirs := strings.Split(path.Dir(from), "/")
+	toParts := strings.Split(to, "/")
+	if fromDirs[0] == "." {
+		fromDirs = nil
+	}
+	common := 0
+	for common < len(fromDirs) && common < len(toParts)-1 && fromDirs[common] == toParts[common] {
+		common++
+	}
+	return strings.Repeat("../", len(fromDirs)-common) + escapePath(strings.Join(toParts[common:], "/"))
+}
+
+type siteNavNode struct {
+	name     string
+	page     *sitePage
+	children []*siteNavNode
+}
+
+// nav renders the folder structure as nested lists, folders first, with
+// the folders leading to current open.
+func (b *siteBuilder) nav(current *sitePage) string {
+	root := &siteNavNode{}
+	for _, page := range b.pages {
+		rel, _ := filepath.Rel(b.root, page.Path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		node := root
+		for _, dir := range parts[:len(parts)-1] {
+			var next *siteNavNode
+			for _, c := range node.children {
+				if c.page == nil && c.name == dir {
+					next = c
+				}
+			}
+			if next == nil {
+				next = &siteNavNode{name: dir}
+				node.children = append(node.children, next)
+			}
+			node = next
+		}
+		node.children = append(node.children, &siteNavNode{name: page.Title, page: page})
+	}
+
+	var out strings.Builder
+	writeNavList(&out, root, current)
+	return out.String()
+}
+
+// writeNavList writes n's children and reports whether they include the
+// current page.
+func writeNavList(out *strings.Builder, n *siteNavNode, current *sitePage) bool {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		a, c := n.children[i], n.children[j]
+		if (a.page == nil) != (c.page == nil) {
+			return a.page == nil
+		}
+		return strings.ToLower(a.name) < strings.ToLower(c.name)
+	})
+	out.WriteString("<ul>\n")
+	containsCurrent := false
+	for _, c := range n.children {
+		if c.page != nil {
+			class := ""
+			if c.page == current {
+				class = ` class="active"`
+				containsCurrent = true
+			}
+			fmt.Fprintf(out, "<li><a%s href=\"%s\">%s</a></li>\n", class,
+				html.EscapeString(relativeURL(current.out, c.page.out)), html.EscapeString(c.name))
+			continue
+		}
+		var inner strings.Builder
+		attr := ""
+		if writeNavList(&inner, c, current) {
+			attr = " open"
+			containsCurrent = true
+		}
+		fmt.Fprintf(out, "<li><details%s><summary>%s</summary>\n%s</details></li>\n", attr, html.EscapeString(c.name), inner.String())
+	}
+	out.WriteString("</ul>\n")
+	return containsCurrent
+}
+
+// pageTOC lists a page's H2 and H3 headings. Pages with fewer than two
+// get none.
+func pageTOC(headings []heading) string {
+	var items []heading
+	for _, h := range headings {
+		if h.Level == 2 || h.Level == 3 {
+			items = append(items, h)
+		}
+	}
+	if len(items) < 2 {
+		return ""
+	}
+	var out strings.Builder
+	out.WriteString("<ul>\n")
+	for _, h := range items {
+		fmt.Fprintf(&out, "<li class=\"toc-h%d\"><a href=\"#%s\">%s</a></li>\n", h.Level, html.EscapeString(url.PathEscape(h.ID)), html.EscapeString(h.Title))
+	}
+	out.WriteString("</ul>\n")
+	return out.String()
+}
+
+func (b *siteBuilder) searchEntry(page *sitePage) searchEntry {
+	entry := searchEntry{URL: escapePath(page.out), Title: page.Title, Headings: []string{}}
+	for _, h := range page.headings {
+		entry.Headings = append(entry.Headings, h.Title)
+	}
+	if nodes, err := html.ParseFragment(strings.NewReader(page.HTML), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}); err == nil {
+		var text []string
+		for _, n := range nodes {
+			if t := nodeText(n); t != "" {
+				text = append(text, t)
+			}
+		}
+		entry.Text = strings.Join(text, " ")
+	}
+	return entry
+}
+
+// siteRedirect is the index page of sites without an index.md or
+// README.md at the root.
+func siteRedirect(target string) string {
+	href := html.EscapeString(escapePath(target))
+	return `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<meta http-equiv="refresh" content="0; url=` + href + `">
+</head>
+<body><a href="` + href + `">Continue</a></body>
+</html>
+`
+}
+
+const defaultSiteTemplate = `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}{{if .SiteTitle}} - {{.SiteTitle}}{{end}}</title>
+    <link rel="stylesheet" href="{{.Root}}assets/site.css">
+</head>
+<body data-root="{{.Root}}">
+<aside class="site-sidebar">
+    <a class="site-title" href="{{.Root}}index.html">{{.SiteTitle}}</a>
+    <input id="site-search" type="search" placeholder="Search" autocomplete="off">
+    <ul id="site-search-results"></ul>
+    <nav class="site-nav">{{.Nav}}</nav>
+</aside>
+<main class="site-content">
+{{.Content}}
+</main>
+{{if .TOC}}<nav class="site-toc">
+    <h2>On this page</h2>
+    {{.TOC}}
+</nav>{{end}}
+<script src="{{.Root}}search-index.js"></script>
+<script src="{{.Root}}assets/search.js"></script>
+</body>
+</html>
+`
+
+// siteCSS lays the theme out with a sidebar, after the theme's own rules.
+const siteCSS = `body {
+    max-width: none;
+    margin: 0;
+    padding: 0;
+    display: grid;
+    grid-template-columns: 260px minmax(0, 1fr) 220px;
+    min-height: 100vh;
+}
+.site-sidebar {
+    position: sticky;
+    top: 0;
+    height: 100vh;
+    overflow-y: auto;
+    padding: 1.5rem 1rem;
+    border-right: 1px solid rgba(128, 128, 128, 0.25);
+    box-sizing: border-box;
+    font-size: 0.9em;
+}
+.site-title { display: block; font-weight: 600; font-size: 1.1em; margin-bottom: 1rem; text-decoration: none; }
+#site-search { width: 100%; box-sizing: border-box; padding: 0.4em 0.6em; margin-bottom: 0.5rem; }
+#site-search-results { list-style: none; padding: 0; margin: 0 0 1rem; }
+#site-search-results li { margin-bottom: 0.6em; }
+#site-search-results small { display: block; opacity: 0.7; }
+.site-nav ul { list-style: none; padding-left: 0.9em; margin: 0; }
+.site-nav > ul { padding-left: 0; }
+.site-nav li { margin: 0.25em 0; }
+.site-nav a { text-decoration: none; }
+.site-nav a.active { font-weight: 600; }
+.site-nav summary { cursor: pointer; }
+.site-content { max-width: 900px; padding: 2rem; box-sizing: border-box; }
+.site-toc {
+    position: sticky;
+    top: 0;
+    align-self: start;
+    padding: 2rem 1rem;
+    font-size: 0.85em;
+}
+.site-toc h2 { font-size: 1em; border: none; margin-top: 0; }
+.site-toc ul { list-style: none; padding: 0; }
+.site-toc .toc-h3 { padding-left: 1em; }
+@media (max-width: 1100px) {
+    body { grid-template-columns: 240px minmax(0, 1fr); }
+    .site-toc { display: none; }
+}
+@media (max-width: 700px) {
+    body { display: block; }
+    .site-sidebar { position: static; height: auto; border-right: none; }
+}
+`
+
+// siteSearchJS searches search-index.js, which loads from file:// URLs
+// where fetching JSON would not.
+const siteSearchJS = `(function () {
+  var input = document.getElementById('site-search');
+  var results = document.getElementById('site-search-results');
+  var index = window.siteSearchIndex || [];
+  if (!input || !results) return;
+  var root = document.body.getAttribute('data-root') || '';
+
+  function snippet(text, term) {
+    var at = text.toLowerCase().indexOf(term);
+    if (at < 0) return text.slice(0, 120);
+    var start = Math.max(0, at - 40);
+    return (start > 0 ? '…' : '') + text.slice(start, at + 80) + '…';
+  }
+
+  input.addEventListener('input', function () {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.innerHTML = '';
+    if (terms.length === 0) return;
+    var matches = [];
+    index.forEach(function (page) {
+      var title = page.title.toLowerCase();
+      var headings = page.headings.join(' ').toLowerCase();
+      var text = page.text.toLowerCase();
+      var score = 0;
+      for (var i = 0; i < terms.length; i++) {
+        var t = terms[i];
+        if (title.indexOf(t) >= 0) score += 10;
+        else if (headings.indexOf(t) >= 0) score += 5;
+        else if (text.indexOf(t) >= 0) score += 1;
+        else return;
+      }
+      matches.push({ page: page, score: score });
+    });
+    matches.sort(function (a, b) { return b.score - a.score; });
+    matches.slice(0, 20).forEach(function (m) {
+      var li = document.createElement('li');
+      var a = document.createElement('a');
+      a.href = root + m.page.url;
+      a.textContent = m.page.title;
+      var small = document.createElement('small');
+      small.textContent = snippet(m.page.text, terms[0]);
+      li.appendChild(a);
+      li.appendChild(small);
+      results.appendChild(li);
+    });
+  });
+})();
+`
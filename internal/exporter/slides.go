@@ -0,0 +1,228 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// DeckSlide is one rendered slide of a presentation.
+type DeckSlide struct {
+	HTML string
+	// Notes is the rendered speaker notes.
+	Notes string
+	// Settings are the slide's directives: class, background and color
+	// style the slide.
+	Settings map[string]string
+}
+
+// slidePaperSize is the 16:9 page of slide PDFs, 1280 by 720 CSS pixels.
+const slidePaperSize = "slide"
+
+var cssClassRegex = regexp.MustCompile(`^[A-Za-z_][\w-]*$`)
+
+// ToSlidesHTML writes a presentation as a single HTML file that plays in
+// any browser: arrow keys move between slides, F goes full screen and N
+// shows the speaker notes. Local images are embedded as in
+// ToSelfContainedHTML.
+func (e *Exporter) ToSlidesHTML(ctx context.Context, slides []DeckSlide, outputPath string, opts Options) (SizeReport, error) {
+	in := newInliner(ctx, opts, outputPath)
+
+	opts.progress("Embedding images", 20)
+	body, err := in.inlineHTML(deckSections(slides))
+	if err != nil {
+		return in.report, err
+	}
+	if err := ctx.Err(); err != nil {
+		return in.report, err
+	}
+	css, err := e.ThemeCSS(opts.Theme)
+	if err != nil {
+		return in.report, err
+	}
+	css = in.inlineCSS(css, ThemesDir())
+
+	title := opts.Title
+	if title == "" {
+		title = "MarkViewPro Presentation"
+	}
+	opts.progress("Writing file", 90)
+	doc := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="%s">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s</title>
+    <style>
+%s
+%s
+    </style>
+</head>
+<body class="deck">
+%s
+<div class="deck-notes"></div>
+<div class="deck-progress"></div>
+<script>
+%s
+</script>
+</body>
+</html>`, opts.language(), html.EscapeString(title), css, deckCSS, body, deckJS)
+	if err := writeDocument(doc, outputPath); err != nil {
+		return in.report, err
+	}
+	return in.report, nil
+}
+
+// ToSlidesPDF prints a presentation with one 16:9 slide per page. It needs
+// Chrome, and returns ErrChromeNotFound without it.
+func (e *Exporter) ToSlidesPDF(ctx context.Context, slides []DeckSlide, outputPath string, opts Options) error {
+	opts.PDF = PDFOptions{PaperSize: slidePaperSize, PrintBackground: true}
+	return e.ToPDF(ctx, "<style>\n"+deckCSS+"\n</style>\n"+deckSections(slides), outputPath, opts)
+}
+
+// deckSections writes each slide as a section, with its notes alongside.
+func deckSections(slides []DeckSlide) string {
+	var b strings.Builder
+	for i, s := range slides {
+		class := "slide"
+		for _, c := range strings.Fields(s.Settings["class"]) {
+			if cssClassRegex.MatchString(c) {
+				class += " " + c
+			}
+		}
+		var style []string
+		for _, prop := range []string{"background", "color"} {
+			if v := s.Settings[prop]; v != "" && !strings.ContainsAny(v, ";{}<>") {
+				style = append(style, prop+": "+v)
+			}
+		}
+		fmt.Fprintf(&b, `<section class="%s" id="slide-%d"`, class, i+1)
+		if len(style) > 0 {
+			fmt.Fprintf(&b, ` style="%s"`, html.EscapeString(strings.Join(style, "; ")))
+		}
+		b.WriteString(">\n<div class=\"slide-content\">\n" + s.HTML + "\n</div>\n")
+		if s.Notes != "" {
+			b.WriteString("<aside class=\"notes\">\n" + s.Notes + "\n</aside>\n")
+		}
+		b.WriteString("</section>\n")
+	}
+	return b.String()
+}
+
+// deckCSS lays slides out at 1280x720, scaled to the window on screen
+// and one per page in print. It follows the theme's stylesheet.
+const deckCSS = `html, body.deck, body {
+    max-width: none;
+    margin: 0;
+    padding: 0;
+}
+body.deck { height: 100vh; overflow: hidden; }
+.slide {
+    display: none;
+    position: absolute;
+    left: 50%;
+    top: 50%;
+    width: 1280px;
+    height: 720px;
+    margin: -360px 0 0 -640px;
+    transform: scale(var(--deck-scale, 1));
+    box-sizing: border-box;
+    padding: 56px 80px;
+    overflow: hidden;
+    flex-direction: column;
+    justify-content: center;
+    font-size: 28px;
+}
+.slide.active { display: flex; }
+.slide h1 { font-size: 2.2em; }
+.slide h2 { font-size: 1.6em; }
+.slide img { max-width: 100%; max-height: 540px; }
+.slide.center, .slide.lead { text-align: center; align-items: center; }
+.slide .notes { display: none; }
+.deck-notes {
+    display: none;
+    position: fixed;
+    left: 0;
+    right: 0;
+    bottom: 0;
+    max-height: 35vh;
+    overflow-y: auto;
+    padding: 1em 2em;
+    background: rgba(0, 0, 0, 0.85);
+    color: #eee;
+    font-size: 18px;
+}
+body.show-notes .deck-notes { display: block; }
+.deck-progress {
+    position: fixed;
+    left: 0;
+    bottom: 0;
+    height: 4px;
+    background: #06b6d4;
+    transition: width 0.2s;
+}
+@page { size: 1280px 720px; margin: 0; }
+@media print {
+    body, body.deck { height: auto; overflow: visible; }
+    .slide {
+        display: flex;
+        position: relative;
+        left: 0;
+        top: 0;
+        margin: 0;
+        transform: none;
+        break-after: page;
+        page-break-after: always;
+    }
+    .deck-notes, .deck-progress { display: none; }
+}
+`
+
+const deckJS = `(function () {
+  var slides = Array.prototype.slice.call(document.querySelectorAll('.slide'));
+  var notes = document.querySelector('.deck-notes');
+  var progress = document.querySelector('.deck-progress');
+  var current = 0;
+
+  function show(index) {
+    if (slides.length === 0) return;
+    current = Math.max(0, Math.min(slides.length - 1, index));
+    slides.forEach(function (s, i) { s.classList.toggle('active', i === current); });
+    var aside = slides[current].querySelector('.notes');
+    notes.innerHTML = aside ? aside.innerHTML : '';
+    progress.style.width = ((current + 1) / slides.length * 100) + '%';
+    history.replaceState(null, '', '#' + (current + 1));
+  }
+
+  function fit() {
+    var scale = Math.min(window.innerWidth / 1280, window.innerHeight / 720);
+    document.documentElement.style.setProperty('--deck-scale', scale);
+  }
+
+  document.addEventListener('keydown', function (e) {
+    switch (e.key) {
+      case 'ArrowRight': case 'ArrowDown': case 'PageDown': case ' ': show(current + 1); break;
+      case 'ArrowLeft': case 'ArrowUp': case 'PageUp': case 'Backspace': show(current - 1); break;
+      case 'Home': show(0); break;
+      case 'End': show(slides.length - 1); break;
+      case 'n': case 'N': document.body.classList.toggle('show-notes'); break;
+      case 'f': case 'F':
+        if (document.fullscreenElement) document.exitFullscreen();
+        else document.documentElement.requestFullscreen();
+        break;
+      default: return;
+    }
+    e.preventDefault();
+  });
+  document.addEventListener('click', function (e) {
+    if (e.target.closest('a')) return;
+    show(e.clientX < window.innerWidth / 3 ? current - 1 : current + 1);
+  });
+  window.addEventListener('resize', fit);
+
+  fit();
+  show((parseInt(location.hash.slice(1), 10) || 1) - 1);
+})();
+`
@@ -0,0 +1,174 @@
+package exporter
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+//go:embed templates/*.html
+var builtinTemplates embed.FS
+
+// DefaultTemplate is the page layout used when no template is given.
+const DefaultTemplate = "default"
+
+// ExportTemplate is an HTML page layout for exports.
+type ExportTemplate struct {
+	Name string `json:"name"`
+	// Builtin is false for templates loaded from the user's templates folder.
+	Builtin bool `json:"builtin"`
+}
+
+// TemplateData is what an export template is executed with.
+type TemplateData struct {
+	Title   string
+	Authors []string
+	// Date is the front matter's date, or the day of the export.
+	Date string
+	Lang string
+	CSS  htmltemplate.CSS
+	Body htmltemplate.HTML
+	// TOC is a nested list of links to the document's headings.
+	TOC htmltemplate.HTML
+	// FrontMatter holds every front matter key, lower-cased.
+	FrontMatter map[string]string
+	// Fields are the custom fields from the settings, such as a company
+	// name for branded documents.
+	Fields map[string]string
+}
+
+// TemplatesDir is the folder users drop their own .html export templates
+// into. A user template with the name of a built-in one replaces it.
+func TemplatesDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	return filepath.Join(configDir, "MarkViewPro", "templates")
+}
+
+// Templates lists the built-in and user templates by name.
+func (e *Exporter) Templates() []ExportTemplate {
+	byName := make(map[string]ExportTemplate)
+	entries, _ := builtinTemplates.ReadDir("templates")
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".html")
+		byName[name] = ExportTemplate{Name: name, Builtin: true}
+	}
+	userEntries, _ := os.ReadDir(TemplatesDir())
+	for _, entry := range userEntries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".html") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		byName[name] = ExportTemplate{Name: name}
+	}
+
+	templates := make([]ExportTemplate, 0, len(byName))
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// loadTemplate parses the named template, preferring a user template over
+// a built-in one.
+func (e *Exporter) loadTemplate(name string) (*htmltemplate.Template, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(TemplatesDir(), name+".html"))
+	if err != nil {
+		if data, err = builtinTemplates.ReadFile("templates/" + name + ".html"); err != nil {
+			return nil, fmt.Errorf("unknown export template %q", name)
+		}
+	}
+	t, err := htmltemplate.New(name).Option("missingkey=zero").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid export template %q: %w", name, err)
+	}
+	return t, nil
+}
+
+// page lays out an exported document with opts.Template.
+func (e *Exporter) page(body, css string, opts Options) (string, error) {
+	t, err := e.loadTemplate(opts.Template)
+	if err != nil {
+		return "", err
+	}
+	title := opts.Title
+	if title == "" {
+		title = "MarkViewPro Export"
+	}
+	date := opts.Date
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	frontMatter := opts.FrontMatter
+	if frontMatter == nil {
+		frontMatter = map[string]string{}
+	}
+	fields := opts.Fields
+	if fields == nil {
+		fields = map[string]string{}
+	}
+
+	var out strings.Builder
+	err = t.Execute(&out, TemplateData{
+		Title:       title,
+		Authors:     opts.Authors,
+		Date:        date,
+		Lang:        opts.language(),
+		CSS:         htmltemplate.CSS(css),
+		Body:        htmltemplate.HTML(body),
+		TOC:         htmltemplate.HTML(tocHTML(collectHeadings(body))),
+		FrontMatter: frontMatter,
+		Fields:      fields,
+	})
+	if err != nil {
+		return "", fmt.Errorf("export template %q failed: %w", t.Name(), err)
+	}
+	return out.String(), nil
+}
+
+// tocHTML nests links to the headings by level. It is empty for documents
+// without headings.
+func tocHTML(headings []heading) string {
+	if len(headings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	var levels []int
+	for _, h := range headings {
+		for len(levels) > 0 && levels[len(levels)-1] > h.Level {
+			b.WriteString("</li></ul>")
+			levels = levels[:len(levels)-1]
+		}
+		if len(levels) > 0 && levels[len(levels)-1] == h.Level {
+			b.WriteString("</li>")
+		} else {
+			b.WriteString("<ul>")
+			levels = append(levels, h.Level)
+		}
+		fmt.Fprintf(&b, `<li><a href="#%s">%s</a>`, html.EscapeString(url.PathEscape(h.ID)), html.EscapeString(h.Title))
+	}
+	for range levels {
+		b.WriteString("</li></ul>")
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed themes/*.css
+var builtinThemes embed.FS
+
+// DefaultTheme is used when no theme is given.
+const DefaultTheme = "github"
+
+type Theme struct {
+	Name string `json:"name"`
+	// Builtin is false for themes loaded from the user's themes folder.
+	Builtin bool `json:"builtin"`
+}
+
+// ThemesDir is the folder users drop their own .css themes into. A user
+// theme with the name of a built-in one replaces it.
+func ThemesDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	return filepath.Join(configDir, "MarkViewPro", "themes")
+}
+
+// Themes lists the built-in and user themes by name.
+func (e *Exporter) Themes() []Theme {
+	byName := make(map[string]Theme)
+	entries, _ := builtinThemes.ReadDir("themes")
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".css")
+		byName[name] = Theme{Name: name, Builtin: true}
+	}
+	userEntries, _ := os.ReadDir(ThemesDir())
+	for _, entry := range userEntries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".css") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		byName[name] = Theme{Name: name}
+	}
+
+	themes := make([]Theme, 0, len(byName))
+	for _, t := range byName {
+		themes = append(themes, t)
+	}
+	sort.Slice(themes, func(i, j int) bool {
+		return themes[i].Name < themes[j].Name
+	})
+	return themes
+}
+
+// ThemeCSS returns the stylesheet of the named theme, preferring a user
+// theme over a built-in one. An unknown theme, such as a deleted user theme
+// the settings still name, falls back to DefaultTheme.
+func (e *Exporter) ThemeCSS(name string) (string, error) {
+	if name != "" && !strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".") {
+		if data, err := os.ReadFile(filepath.Join(ThemesDir(), name+".css")); err == nil {
+			return string(data), nil
+		}
+		if data, err := builtinThemes.ReadFile("themes/" + name + ".css"); err == nil {
+			return string(data), nil
+		}
+	}
+	data, err := builtinThemes.ReadFile("themes/" + DefaultTheme + ".css")
+	if err != nil {
+		return "", fmt.Errorf("missing default export theme: %w", err)
+	}
+	return string(data), nil
+}
+
+// ResolveTheme picks the export theme for a preview theme and the app's
+// light/dark setting, using the "-dark" variant when one exists. The
+// "system" setting uses the light theme, as only the webview knows the
+// system's choice.
+func (e *Exporter) ResolveTheme(previewTheme, appTheme string) string {
+	if previewTheme == "" {
+		previewTheme = DefaultTheme
+	}
+	if appTheme != "dark" || strings.HasSuffix(previewTheme, "-dark") {
+		return previewTheme
+	}
+	for _, t := range e.Themes() {
+		if t.Name == previewTheme+"-dark" {
+			return t.Name
+		}
+	}
+	return previewTheme
+}
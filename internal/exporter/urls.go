@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"markviewpro/internal/localfiles"
+
+	"golang.org/x/net/html"
+)
+
+// urlAttrs lists the attributes that hold a URL, by element.
+var urlAttrs = map[string]string{
+	"a":      "href",
+	"img":    "src",
+	"source": "src",
+	"video":  "src",
+	"audio":  "src",
+	"track":  "src",
+	"link":   "href",
+}
+
+// rewriteURLs passes every link and media URL in htmlContent through
+// rewrite. Everything else is copied through byte for byte.
+func rewriteURLs(htmlContent string, rewrite func(ref string) string) (string, error) {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(htmlContent))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return "", err
+			}
+			return b.String(), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(z.Raw())
+			tok := z.Token()
+			attr, ok := urlAttrs[tok.Data]
+			if !ok {
+				b.WriteString(raw)
+				continue
+			}
+			changed := false
+			for i, a := range tok.Attr {
+				if a.Key == attr {
+					if rewritten := rewrite(a.Val); rewritten != a.Val {
+						tok.Attr[i].Val = rewritten
+						changed = true
+					}
+				}
+			}
+			if changed {
+				b.WriteString(tok.String())
+			} else {
+				b.WriteString(raw)
+			}
+		default:
+			b.WriteString(string(z.Raw()))
+		}
+	}
+}
+
+// relativeTo rewrites a reference made from baseDir so that it resolves
+// from outDir instead.
+func relativeTo(baseDir, outDir, ref string) string {
+	target, ok := localfiles.Resolve(baseDir, ref)
+	if !ok {
+		return ref
+	}
+	rel, err := filepath.Rel(outDir, target)
+	if err != nil {
+		return fileURL(baseDir, ref)
+	}
+	return withSuffix(escapePath(filepath.ToSlash(rel)), ref)
+}
+
+// fileURL turns a reference made from baseDir into an absolute file URL.
+func fileURL(baseDir, ref string) string {
+	target, ok := localfiles.Resolve(baseDir, ref)
+	if !ok {
+		return ref
+	}
+	return withSuffix(pathToFileURL(target), ref)
+}
+
+// sameDocument reports whether ref points into the source document itself,
+// such as "notes.md#setup" from notes.md, and returns its fragment.
+func sameDocument(source, baseDir, ref string) (string, bool) {
+	if source == "" {
+		return "", false
+	}
+	target, ok := localfiles.Resolve(baseDir, ref)
+	if !ok || filepath.Clean(target) != filepath.Clean(source) {
+		return "", false
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Fragment == "" {
+		return "", false
+	}
+	return "#" + u.EscapedFragment(), true
+}
+
+// pathToFileURL returns the file URL of an absolute path.
+func pathToFileURL(name string) string {
+	p := filepath.ToSlash(name)
+	if !strings.HasPrefix(p, "/") {
+		// Windows drive paths become file:///C:/...
+		p = "/" + p
+	}
+	return "file://" + escapePath(p)
+}
+
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// withSuffix appends the query and fragment of ref to a rewritten path.
+func withSuffix(p, ref string) string {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		return p + ref[i:]
+	}
+	return p
+}
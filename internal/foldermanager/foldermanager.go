@@ -2,7 +2,10 @@ package foldermanager
 
 import (
 	"context"
+	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -47,10 +50,7 @@ func (fm *FolderManager) buildFileTree(path string, currentDepth, maxDepth int)
 
 	for _, entry := range entries {
 		// Skip hidden files and common ignore patterns
-		if strings.HasPrefix(entry.Name(), ".") ||
-			entry.Name() == "node_modules" ||
-			entry.Name() == "dist" ||
-			entry.Name() == "build" {
+		if isIgnored(entry.Name()) {
 			continue
 		}
 
@@ -68,8 +68,7 @@ func (fm *FolderManager) buildFileTree(path string, currentDepth, maxDepth int)
 				node.Children = children
 			}
 			nodes = append(nodes, node)
-		} else if strings.HasSuffix(strings.ToLower(entry.Name()), ".md") ||
-			strings.HasSuffix(strings.ToLower(entry.Name()), ".markdown") {
+		} else if IsMarkdownFile(entry.Name()) {
 			nodes = append(nodes, node)
 		}
 	}
@@ -85,6 +84,113 @@ func (fm *FolderManager) buildFileTree(path string, currentDepth, maxDepth int)
 	return nodes, nil
 }
 
+func isIgnored(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		name == "node_modules" ||
+		name == "dist" ||
+		name == "build"
+}
+
+func IsMarkdownFile(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".md") || strings.HasSuffix(lower, ".markdown")
+}
+
+// ListMarkdownFiles returns every Markdown file under root at any depth,
+// skipping the same folders as the file tree.
+func ListMarkdownFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && isIgnored(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsMarkdownFile(d.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// FilterFiles keeps the files under root that match any include pattern,
+// or all of them when there are none, and no exclude pattern. Patterns are
+// matched against the slash-separated path relative to root; a pattern
+// without a slash matches the file name at any depth, and ** matches any
+// number of folders, as in "drafts/**".
+func FilterFiles(root string, files, include, exclude []string) ([]string, error) {
+	var kept []string
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		included := len(include) == 0
+		for _, pattern := range include {
+			ok, err := MatchGlob(pattern, rel)
+			if err != nil {
+				return nil, err
+			}
+			included = included || ok
+		}
+		for _, pattern := range exclude {
+			if !included {
+				break
+			}
+			ok, err := MatchGlob(pattern, rel)
+			if err != nil {
+				return nil, err
+			}
+			included = !ok
+		}
+		if included {
+			kept = append(kept, file)
+		}
+	}
+	return kept, nil
+}
+
+// MatchGlob reports whether the slash-separated relative path name matches
+// pattern, as described for FilterFiles.
+func MatchGlob(pattern, name string) (bool, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "./")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if !strings.Contains(pattern, "/") {
+		return path.Match(pattern, path.Base(name))
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/")), nil
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// GetCurrentPath returns the folder opened last, or "" when none is open.
 func (fm *FolderManager) GetCurrentPath() string {
 	return fm.currentPath
 }
@@ -46,6 +46,16 @@ func (im *ImageManager) SaveBase64Image(base64Data, documentPath string) (string
 		ext = ".gif"
 	} else if strings.Contains(mimeType, "webp") {
 		ext = ".webp"
+	} else if strings.Contains(mimeType, "svg") {
+		ext = ".svg"
+	} else if strings.Contains(mimeType, "bmp") {
+		ext = ".bmp"
+	} else if strings.Contains(mimeType, "tiff") {
+		ext = ".tiff"
+	} else if strings.Contains(mimeType, "emf") {
+		ext = ".emf"
+	} else if strings.Contains(mimeType, "wmf") {
+		ext = ".wmf"
 	}
 
 	// Create assets folder relative to document
@@ -63,10 +73,19 @@ func (im *ImageManager) SaveBase64Image(base64Data, documentPath string) (string
 		return "", fmt.Errorf("failed to create assets folder: %w", err)
 	}
 
-	// Generate unique filename
+	// Generate unique filename; imports save several images a second
 	timestamp := time.Now().Format("20060102-150405")
 	filename := fmt.Sprintf("image-%s%s", timestamp, ext)
 	imagePath := filepath.Join(assetsPath, filename)
+	counter := 1
+	for {
+		if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+			break
+		}
+		filename = fmt.Sprintf("image-%s-%d%s", timestamp, counter, ext)
+		imagePath = filepath.Join(assetsPath, filename)
+		counter++
+	}
 
 	// Save image
 	if err := os.WriteFile(imagePath, imageData, 0644); err != nil {
@@ -0,0 +1,703 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"markviewpro/internal/markdown"
+)
+
+// anchorLinkRegex finds Markdown links to a bookmark in the document.
+var anchorLinkRegex = regexp.MustCompile(`\[((?:[^\]\\]|\\.)*)\]\(#(\w+)\)`)
+
+// monospaceFonts mark runs as code.
+var monospaceFonts = map[string]bool{
+	"consolas": true, "courier": true, "courier new": true, "menlo": true,
+	"monaco": true, "lucida console": true, "source code pro": true,
+}
+
+// DOCXToMarkdown converts a Word document to Markdown. Heading styles
+// become headings, numbered and bulleted paragraphs become lists, and
+// tables, hyperlinks, footnotes, endnotes and embedded images are kept;
+// the document is turned into HTML and converted by HTMLToMarkdown.
+func DOCXToMarkdown(path string, opts Options) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	d := &docxReader{
+		files:     make(map[string]*zip.File),
+		styles:    make(map[string]docxStyle),
+		numbering: make(map[string]map[string]string),
+		notes:     make(map[string]docxNote),
+	}
+	for _, f := range zr.File {
+		d.files[f.Name] = f
+	}
+	doc, err := d.part("word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("not a Word document: %w", err)
+	}
+	body := doc.child("body")
+	if body == nil {
+		return "", fmt.Errorf("not a Word document: no body")
+	}
+	d.rels = d.loadRels("word/_rels/document.xml.rels")
+	d.loadStyles()
+	d.loadNumbering()
+	d.loadNotes("footnotes", "fn")
+	d.loadNotes("endnotes", "en")
+
+	var b strings.Builder
+	d.blocks(&b, body)
+	if len(d.noteOrder) > 0 {
+		b.WriteString(`<section class="footnotes"><ol>`)
+		for _, id := range d.noteOrder {
+			note := d.notes[id]
+			d.rels = note.rels
+			fmt.Fprintf(&b, `<li id="%s">`, id)
+			d.blocks(&b, note.node)
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ol></section>")
+	}
+
+	md, err := HTMLToMarkdown(strings.NewReader(b.String()), opts)
+	if err != nil {
+		return "", err
+	}
+	return d.resolveAnchors(md), nil
+}
+
+// xmlNode is any element of a document part.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []xmlNode  `xml:",any"`
+	Text    string     `xml:",chardata"`
+}
+
+// child returns the first child element named local, ignoring its
+// namespace.
+func (n *xmlNode) child(local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == local {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// find returns the first element named local under n.
+func (n *xmlNode) find(local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == local {
+			return &n.Nodes[i]
+		}
+		if found := n.Nodes[i].find(local); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) attr(local string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// on reports whether a toggle property such as <w:b/> is set.
+func (n *xmlNode) on() bool {
+	if n == nil {
+		return false
+	}
+	switch n.attr("val") {
+	case "0", "false", "off", "none":
+		return false
+	}
+	return true
+}
+
+type docxStyle struct {
+	name, basedOn string
+	outline       string
+	numID, level  string
+}
+
+type docxNote struct {
+	node *xmlNode
+	rels map[string]string
+}
+
+type docxReader struct {
+	files map[string]*zip.File
+	// rels maps the relationship ids of the part being converted to their
+	// targets: zip paths for media and URLs for hyperlinks.
+	rels   map[string]string
+	styles map[string]docxStyle
+	// numbering maps numbering ids and levels to the number format.
+	numbering map[string]map[string]string
+	notes     map[string]docxNote
+	noteOrder []string
+	// headings lists the bookmarks on each heading, in document order.
+	headings [][]string
+}
+
+func (d *docxReader) part(name string) (*xmlNode, error) {
+	f, ok := d.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var n xmlNode
+	if err := xml.NewDecoder(rc).Decode(&n); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &n, nil
+}
+
+func (d *docxReader) loadRels(name string) map[string]string {
+	rels := make(map[string]string)
+	root, err := d.part(name)
+	if err != nil {
+		return rels
+	}
+	for _, rel := range root.Nodes {
+		target := rel.attr("Target")
+		switch {
+		case rel.attr("TargetMode") == "External":
+		case strings.HasPrefix(target, "/"):
+			target = target[1:]
+		default:
+			target = path.Join("word", target)
+		}
+		rels[rel.attr("Id")] = target
+	}
+	return rels
+}
+
+func (d *docxReader) loadStyles() {
+	root, err := d.part("word/styles.xml")
+	if err != nil {
+		return
+	}
+	for _, s := range root.Nodes {
+		if s.XMLName.Local != "style" {
+			continue
+		}
+		ppr := s.child("pPr")
+		numPr := ppr.child("numPr")
+		d.styles[s.attr("styleId")] = docxStyle{
+			name:    strings.ToLower(s.child("name").attr("val")),
+			basedOn: s.child("basedOn").attr("val"),
+			outline: ppr.child("outlineLvl").attr("val"),
+			numID:   numPr.child("numId").attr("val"),
+			level:   numPr.child("ilvl").attr("val"),
+		}
+	}
+}
+
+func (d *docxReader) loadNumbering() {
+	root, err := d.part("word/numbering.xml")
+	if err != nil {
+		return
+	}
+	formats := make(map[string]map[string]string)
+	for _, n := range root.Nodes {
+		if n.XMLName.Local != "abstractNum" {
+			continue
+		}
+		levels := make(map[string]string)
+		for _, lvl := range n.Nodes {
+			if lvl.XMLName.Local == "lvl" {
+				levels[lvl.attr("ilvl")] = lvl.child("numFmt").attr("val")
+			}
+		}
+		formats[n.attr("abstractNumId")] = levels
+	}
+	for _, n := range root.Nodes {
+		if n.XMLName.Local == "num" {
+			d.numbering[n.attr("numId")] = formats[n.child("abstractNumId").attr("val")]
+		}
+	}
+}
+
+// loadNotes reads the footnotes or endnotes part, keyed by prefix and id.
+func (d *docxReader) loadNotes(part, prefix string) {
+	root, err := d.part("word/" + part + ".xml")
+	if err != nil {
+		return
+	}
+	rels := d.loadRels("word/_rels/" + part + ".xml.rels")
+	for i := range root.Nodes {
+		note := &root.Nodes[i]
+		// Separators are notes too, with a type.
+		if note.attr("type") == "" {
+			d.notes[prefix+"-"+note.attr("id")] = docxNote{node: note, rels: rels}
+		}
+	}
+}
+
+// docxParagraph is what a paragraph's style and properties make it.
+type docxParagraph struct {
+	heading     int
+	quote, code bool
+	// listLevel is -1 outside lists.
+	listLevel int
+	ordered   bool
+	numID     string
+}
+
+func (d *docxReader) paragraph(p *xmlNode) docxParagraph {
+	para := docxParagraph{listLevel: -1}
+	ppr := p.child("pPr")
+	outline, numID, level := "", "", ""
+	// Follow the style's inheritance, nearest first.
+	id := ppr.child("pStyle").attr("val")
+	for i := 0; i < 10 && id != ""; i++ {
+		style, ok := d.styles[id]
+		if !ok {
+			break
+		}
+		switch {
+		case para.heading > 0 || para.quote || para.code:
+		case style.name == "title":
+			para.heading = 1
+		case strings.HasPrefix(style.name, "heading "):
+			para.heading, _ = strconv.Atoi(strings.TrimPrefix(style.name, "heading "))
+		case strings.Contains(style.name, "quote") || style.name == "block text":
+			para.quote = true
+		case strings.Contains(style.name, "code") || style.name == "html preformatted":
+			para.code = true
+		}
+		if outline == "" {
+			outline = style.outline
+		}
+		if numID == "" {
+			numID, level = style.numID, style.level
+		}
+		id = style.basedOn
+	}
+	if v := ppr.child("outlineLvl").attr("val"); v != "" {
+		outline = v
+	}
+	if lvl, err := strconv.Atoi(outline); err == nil && para.heading == 0 && lvl < 6 {
+		para.heading = lvl + 1
+	}
+	if para.heading > 6 {
+		para.heading = 0
+	}
+	if numPr := ppr.child("numPr"); numPr != nil {
+		numID, level = numPr.child("numId").attr("val"), numPr.child("ilvl").attr("val")
+	}
+	if numID != "" && numID != "0" && para.heading == 0 {
+		if level == "" {
+			level = "0"
+		}
+		para.listLevel, _ = strconv.Atoi(level)
+		para.numID = numID
+		format := d.numbering[numID][level]
+		para.ordered = format != "" && format != "bullet" && format != "none"
+	}
+	return para
+}
+
+type docxItem struct {
+	level   int
+	ordered bool
+	html    string
+}
+
+// blocks writes the paragraphs and tables in parent as HTML. Consecutive
+// code and quote paragraphs share one <pre> or <blockquote>.
+func (d *docxReader) blocks(b *strings.Builder, parent *xmlNode) {
+	var items []docxItem
+	listID, group := "", ""
+	closeGroup := func() {
+		if group != "" {
+			b.WriteString("</" + group + ">")
+			group = ""
+		}
+	}
+	flushList := func() {
+		writeList(b, items)
+		items = nil
+	}
+	for i := range parent.Nodes {
+		n := &parent.Nodes[i]
+		switch n.XMLName.Local {
+		case "p":
+			para := d.paragraph(n)
+			// A new numbering at the top level starts a new list.
+			if para.listLevel < 0 || para.listLevel == 0 && len(items) > 0 && para.numID != listID {
+				flushList()
+			}
+			want := ""
+			if para.listLevel < 0 && para.heading == 0 {
+				if para.code {
+					want = "pre"
+				} else if para.quote {
+					want = "blockquote"
+				}
+			}
+			if want != group {
+				closeGroup()
+				if want != "" {
+					b.WriteString("<" + want + ">")
+					group = want
+				}
+			} else if group == "pre" {
+				b.WriteString("\n")
+			}
+
+			switch {
+			case para.heading > 0:
+				if strings.TrimSpace(docxText(n)) == "" {
+					continue
+				}
+				var bookmarks []string
+				for _, child := range n.Nodes {
+					if child.XMLName.Local == "bookmarkStart" {
+						bookmarks = append(bookmarks, child.attr("name"))
+					}
+				}
+				d.headings = append(d.headings, bookmarks)
+				fmt.Fprintf(b, "<h%d>%s</h%d>", para.heading, d.inline(n), para.heading)
+			case para.listLevel >= 0:
+				if len(items) == 0 {
+					listID = para.numID
+				}
+				items = append(items, docxItem{level: para.listLevel, ordered: para.ordered, html: d.inline(n)})
+			case group == "pre":
+				b.WriteString(html.EscapeString(docxText(n)))
+			default:
+				b.WriteString("<p>" + d.inline(n) + "</p>")
+			}
+		case "tbl":
+			flushList()
+			closeGroup()
+			d.table(b, n)
+		case "sdt":
+			flushList()
+			closeGroup()
+			d.blocks(b, n.child("sdtContent"))
+		case "customXml":
+			flushList()
+			closeGroup()
+			d.blocks(b, n)
+		}
+	}
+	flushList()
+	closeGroup()
+}
+
+// writeList nests list items by level. A level that skips ahead gets an
+// empty item to hang from.
+func writeList(b *strings.Builder, items []docxItem) {
+	var tags []string
+	opened := false
+	for _, item := range items {
+		depth := item.level + 1
+		tag := "ul"
+		if item.ordered {
+			tag = "ol"
+		}
+		for len(tags) > depth || len(tags) == depth && tags[depth-1] != tag {
+			b.WriteString("</li></" + tags[len(tags)-1] + ">")
+			tags = tags[:len(tags)-1]
+		}
+		if len(tags) == depth {
+			b.WriteString("</li>")
+		}
+		for len(tags) < depth {
+			if opened {
+				b.WriteString("<li>")
+			}
+			b.WriteString("<" + tag + ">")
+			tags = append(tags, tag)
+			opened = true
+		}
+		b.WriteString("<li>" + item.html)
+		opened = false
+	}
+	for len(tags) > 0 {
+		b.WriteString("</li></" + tags[len(tags)-1] + ">")
+		tags = tags[:len(tags)-1]
+	}
+}
+
+func (d *docxReader) table(b *strings.Builder, tbl *xmlNode) {
+	b.WriteString("<table>")
+	for _, tr := range tbl.Nodes {
+		if tr.XMLName.Local != "tr" {
+			continue
+		}
+		b.WriteString("<tr>")
+		for i := range tr.Nodes {
+			tc := &tr.Nodes[i]
+			if tc.XMLName.Local != "tc" {
+				continue
+			}
+			tcPr := tc.child("tcPr")
+			b.WriteString("<td")
+			if span := tcPr.child("gridSpan").attr("val"); span != "" {
+				b.WriteString(` colspan="` + html.EscapeString(span) + `"`)
+			}
+			switch tc.child("p").child("pPr").child("jc").attr("val") {
+			case "center":
+				b.WriteString(` align="center"`)
+			case "right", "end":
+				b.WriteString(` align="right"`)
+			}
+			b.WriteString(">")
+			// Cells merged into the one above are left empty.
+			if merge := tcPr.child("vMerge"); merge == nil || merge.attr("val") == "restart" {
+				d.blocks(b, tc)
+			}
+			b.WriteString("</td>")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>")
+}
+
+// docxFormat is the character formatting HTML can express.
+type docxFormat struct {
+	bold, italic, strike, code bool
+	vertAlign                  string
+}
+
+type docxSpan struct {
+	format docxFormat
+	html   string
+}
+
+// inline converts the runs of a paragraph to HTML.
+func (d *docxReader) inline(p *xmlNode) string {
+	return renderSpans(d.spans(p, nil))
+}
+
+func (d *docxReader) spans(parent *xmlNode, spans []docxSpan) []docxSpan {
+	for i := range parent.Nodes {
+		n := &parent.Nodes[i]
+		switch n.XMLName.Local {
+		case "pPr", "rPr", "del", "moveFrom":
+		case "r":
+			spans = append(spans, d.run(n))
+		case "hyperlink":
+			inner := renderSpans(d.spans(n, nil))
+			href := d.rels[n.attr("id")]
+			if anchor := n.attr("anchor"); anchor != "" {
+				href = "#" + anchor
+			}
+			if href != "" {
+				inner = `<a href="` + html.EscapeString(href) + `">` + inner + "</a>"
+			}
+			spans = append(spans, docxSpan{html: inner})
+		case "AlternateContent":
+			spans = d.spans(n.child("Choice"), spans)
+		default:
+			// Insertions, fields, smart tags, content controls and math.
+			spans = d.spans(n, spans)
+		}
+	}
+	return spans
+}
+
+func (d *docxReader) run(r *xmlNode) docxSpan {
+	span := docxSpan{format: runFormat(r.child("rPr"))}
+	var b strings.Builder
+	for i := range r.Nodes {
+		n := &r.Nodes[i]
+		switch n.XMLName.Local {
+		case "t":
+			b.WriteString(html.EscapeString(n.Text))
+		case "tab":
+			b.WriteString(" ")
+		case "br", "cr":
+			if t := n.attr("type"); t == "" || t == "textWrapping" {
+				b.WriteString("<br>")
+			}
+		case "noBreakHyphen":
+			b.WriteString("-")
+		case "drawing", "pict", "object", "AlternateContent":
+			b.WriteString(d.images(n))
+		case "footnoteReference", "endnoteReference":
+			id := "fn-" + n.attr("id")
+			if n.XMLName.Local == "endnoteReference" {
+				id = "en-" + n.attr("id")
+			}
+			if _, ok := d.notes[id]; !ok {
+				continue
+			}
+			if !slices.Contains(d.noteOrder, id) {
+				d.noteOrder = append(d.noteOrder, id)
+			}
+			fmt.Fprintf(&b, `<a href="#%s">%s</a>`, id, n.attr("id"))
+			// The reference's superscript style is the footnote's marker.
+			span.format = docxFormat{}
+		}
+	}
+	span.html = b.String()
+	return span
+}
+
+func runFormat(rpr *xmlNode) docxFormat {
+	style := strings.ToLower(rpr.child("rStyle").attr("val"))
+	font := strings.ToLower(rpr.child("rFonts").attr("ascii"))
+	return docxFormat{
+		bold:      rpr.child("b").on() || style == "strong",
+		italic:    rpr.child("i").on() || style == "emphasis",
+		strike:    rpr.child("strike").on() || rpr.child("dstrike").on(),
+		code:      monospaceFonts[font] || strings.Contains(style, "verbatim") || strings.Contains(style, "code"),
+		vertAlign: rpr.child("vertAlign").attr("val"),
+	}
+}
+
+// renderSpans writes spans as HTML, merging neighbours with the same
+// formatting so their markup is not split.
+func renderSpans(spans []docxSpan) string {
+	var b strings.Builder
+	for i := 0; i < len(spans); {
+		f := spans[i].format
+		var inner strings.Builder
+		for ; i < len(spans) && spans[i].format == f; i++ {
+			inner.WriteString(spans[i].html)
+		}
+		text := inner.String()
+		if f.code {
+			text = "<code>" + text + "</code>"
+		}
+		if f.strike {
+			text = "<del>" + text + "</del>"
+		}
+		if f.italic {
+			text = "<em>" + text + "</em>"
+		}
+		if f.bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		switch f.vertAlign {
+		case "superscript":
+			text = "<sup>" + text + "</sup>"
+		case "subscript":
+			text = "<sub>" + text + "</sub>"
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// images embeds the pictures in a drawing as data URIs.
+func (d *docxReader) images(n *xmlNode) string {
+	var b strings.Builder
+	alt := n.find("docPr").attr("descr")
+	var visit func(n *xmlNode)
+	visit = func(n *xmlNode) {
+		for i := range n.Nodes {
+			child := &n.Nodes[i]
+			switch child.XMLName.Local {
+			case "Fallback":
+				// The choice before it holds the same picture.
+				continue
+			case "blip":
+				b.WriteString(d.image(child.attr("embed"), alt))
+			case "imagedata":
+				b.WriteString(d.image(child.attr("id"), alt))
+			}
+			visit(child)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+func (d *docxReader) image(relID, alt string) string {
+	f, ok := d.files[d.rels[relID]]
+	if !ok {
+		return ""
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return ""
+	}
+	return `<img src="` + dataURI(f.Name, data) + `" alt="` + html.EscapeString(alt) + `">`
+}
+
+// docxText returns the plain text of a paragraph.
+func docxText(n *xmlNode) string {
+	var b strings.Builder
+	for i := range n.Nodes {
+		child := &n.Nodes[i]
+		switch child.XMLName.Local {
+		case "t":
+			b.WriteString(child.Text)
+		case "tab":
+			b.WriteString("\t")
+		case "br", "cr":
+			b.WriteString("\n")
+		case "del", "moveFrom", "Fallback":
+		default:
+			b.WriteString(docxText(child))
+		}
+	}
+	return b.String()
+}
+
+// resolveAnchors points links to bookmarks on headings at the headings'
+// ids. Links to Word's own bookmarks, such as those of a table of contents
+// that do not mark a heading, are unlinked.
+func (d *docxReader) resolveAnchors(md string) string {
+	ids := markdown.NewRenderer().HeadingIDs(md)
+	targets := make(map[string]string)
+	for i, bookmarks := range d.headings {
+		if i < len(ids) {
+			for _, name := range bookmarks {
+				targets[name] = ids[i]
+			}
+		}
+	}
+	return anchorLinkRegex.ReplaceAllStringFunc(md, func(link string) string {
+		m := anchorLinkRegex.FindStringSubmatch(link)
+		if id, ok := targets[m[2]]; ok {
+			return "[" + m[1] + "](#" + id + ")"
+		}
+		if strings.HasPrefix(m[2], "_") {
+			return m[1]
+		}
+		return link
+	})
+}
@@ -0,0 +1,1016 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"markviewpro/internal/localfiles"
+	"markviewpro/internal/markdown"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// msoListRegex finds the list and level of a list paragraph in the HTML
+	// Word saves.
+	msoListRegex     = regexp.MustCompile(`mso-list:\s*l(\d+)\s+level(\d+)`)
+	msoFootnoteRegex = regexp.MustCompile(`mso-element:\s*(?:foot|end)note(-list)?`)
+	fontWeightRegex  = regexp.MustCompile(`font-weight:\s*(\w+)`)
+	fontStyleRegex   = regexp.MustCompile(`font-style:\s*(\w+)`)
+	textAlignRegex   = regexp.MustCompile(`text-align:\s*(\w+)`)
+	orderedLineRegex = regexp.MustCompile(`^\d{1,9}[.)](\s|$)`)
+	orderedMarkRegex = regexp.MustCompile(`^\w+[.)]$`)
+	listStartRegex   = regexp.MustCompile(`^(?:[-*+]|\d+[.)])(?: |$)`)
+	codeClassRegex   = regexp.MustCompile(`(?:^|\s)(?:(?:language|lang|highlight-source)-|brush:\s*)([\w+#-]+)`)
+	spaceRegex       = regexp.MustCompile(`[\s\x{00a0}]+`)
+	preStyleRegex    = regexp.MustCompile(`(^|;)white-space:pre(;|$)`)
+)
+
+// blockElements are the elements that end a paragraph.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Body: true, atom.Caption: true, atom.Center: true, atom.Dd: true,
+	atom.Details: true, atom.Dialog: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.Form: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hgroup: true, atom.Hr: true, atom.Html: true,
+	atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true,
+	atom.Table: true, atom.Ul: true,
+}
+
+// ignoredElements hold nothing worth importing.
+var ignoredElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Svg: true, atom.Canvas: true, atom.Button: true, atom.Select: true,
+	atom.Textarea: true, atom.Meta: true, atom.Link: true, atom.Title: true,
+}
+
+// HTMLToMarkdown converts an HTML page or fragment to Markdown. Styling is
+// dropped, except inline styles that make text bold, italic or struck
+// through, and the list paragraphs of HTML saved by Word become lists.
+// Footnotes laid out the way Markdown renderers and Word write them
+// become Markdown footnotes.
+func HTMLToMarkdown(r io.Reader, opts Options) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	c := &converter{
+		opts:      opts,
+		footnotes: make(map[string]*html.Node),
+		noteOf:    make(map[*html.Node]int),
+		refIDs:    make(map[string]bool),
+		skip:      make(map[*html.Node]bool),
+		images:    make(map[string]string),
+	}
+	bodies := c.findFootnotes(doc)
+
+	blocks := c.blocks(doc)
+	// Footnotes that are never referenced are kept too.
+	for _, body := range bodies {
+		c.noteNumber(body)
+	}
+	// Footnotes may reference further footnotes.
+	for i := 0; i < len(c.notes); i++ {
+		blocks = append(blocks, c.footnote(i))
+	}
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+type converter struct {
+	opts Options
+	// footnotes maps the ids a footnote reference may link to to the
+	// footnote's element.
+	footnotes map[string]*html.Node
+	// notes are the footnotes in the order they are numbered.
+	notes  []*html.Node
+	noteOf map[*html.Node]int
+	// refIDs are the ids of footnote references, which backlinks point to.
+	refIDs map[string]bool
+	// skip holds the footnotes, which are written at the end.
+	skip map[*html.Node]bool
+	// images maps image sources to the references they were saved as.
+	images map[string]string
+
+	inTable              int
+	bold, italic, struck int
+	// trimSpace drops the space after a task list box.
+	trimSpace bool
+}
+
+// findFootnotes records the footnote sections of Markdown renderers and
+// Word and returns the footnotes in document order.
+func (c *converter) findFootnotes(doc *html.Node) []*html.Node {
+	var bodies []*html.Node
+	add := func(body *html.Node) {
+		bodies = append(bodies, body)
+		c.skip[body] = true
+		walk(body, func(n *html.Node) {
+			for _, key := range []string{"id", "name"} {
+				if id := attr(n, key); id != "" {
+					c.footnotes[id] = body
+				}
+			}
+		})
+	}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if m := msoFootnoteRegex.FindStringSubmatch(attr(n, "style")); m != nil {
+				if m[1] == "" {
+					add(n)
+					return
+				}
+				c.skip[n] = true
+			} else if hasClass(n, "footnotes") || attr(n, "role") == "doc-endnotes" {
+				c.skip[n] = true
+				walk(n, func(li *html.Node) {
+					if li.DataAtom == atom.Li && attr(li, "id") != "" {
+						add(li)
+					}
+				})
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(doc)
+	return bodies
+}
+
+func (c *converter) noteNumber(body *html.Node) int {
+	if number, ok := c.noteOf[body]; ok {
+		return number
+	}
+	c.notes = append(c.notes, body)
+	c.noteOf[body] = len(c.notes)
+	return len(c.notes)
+}
+
+// footnote writes the definition of the i'th numbered footnote.
+func (c *converter) footnote(i int) string {
+	blocks := c.blocks(c.notes[i])
+	label := "[^" + strconv.Itoa(i+1) + "]:"
+	if len(blocks) == 0 {
+		return label
+	}
+	return label + " " + indentLines(strings.Join(blocks, "\n\n"), "    ")
+}
+
+// blocks converts the children of n, gathering runs of inline content
+// into paragraphs.
+func (c *converter) blocks(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if p := paragraph(inline.String()); p != "" {
+			blocks = append(blocks, p)
+		}
+		inline.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if c.ignored(child) {
+			continue
+		}
+		if _, ok := msoListLevel(child); ok {
+			flush()
+			var items []*html.Node
+			for ; child != nil; child = child.NextSibling {
+				if _, ok := msoListLevel(child); ok {
+					items = append(items, child)
+				} else if child.Type != html.TextNode || strings.TrimSpace(child.Data) != "" {
+					break
+				}
+			}
+			blocks = append(blocks, c.msoList(items)...)
+			if child == nil {
+				break
+			}
+			if c.ignored(child) {
+				continue
+			}
+		}
+		if child.Type == html.ElementNode && blockElements[child.DataAtom] {
+			flush()
+			blocks = append(blocks, c.block(child)...)
+		} else {
+			c.inline(&inline, child)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// paragraph tidies the inline Markdown of a paragraph, in which newlines
+// stand for line breaks.
+func paragraph(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, escapeLineStart(line))
+		}
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+// escapeLineStart escapes what would make a line of text start a block.
+func escapeLineStart(line string) string {
+	switch line[0] {
+	case '#', '>', '+', '-', '=':
+		return `\` + line
+	}
+	if orderedLineRegex.MatchString(line) {
+		i := strings.IndexAny(line, ".)")
+		return line[:i] + `\` + line[i:]
+	}
+	return line
+}
+
+func (c *converter) block(n *html.Node) []string {
+	// Code editors copy code as <div>s that keep their white space.
+	if preStyleRegex.MatchString(compactStyle(n)) {
+		return []string{codeBlock(n)}
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		title := strings.Join(strings.Fields(c.inlineString(n)), " ")
+		if title == "" {
+			return nil
+		}
+		level := int(n.Data[1] - '0')
+		return []string{strings.Repeat("#", level) + " " + title}
+	case atom.Ul, atom.Ol:
+		return []string{c.list(n)}
+	case atom.Blockquote:
+		blocks := c.blocks(n)
+		if len(blocks) == 0 {
+			return nil
+		}
+		lines := strings.Split(strings.Join(blocks, "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return []string{strings.Join(lines, "\n")}
+	case atom.Pre:
+		return []string{codeBlock(n)}
+	case atom.Table:
+		return c.table(n)
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Dt:
+		blocks := c.blocks(n)
+		if len(blocks) > 0 {
+			blocks[0] = "**" + blocks[0] + "**"
+		}
+		return blocks
+	}
+	return c.blocks(n)
+}
+
+// list converts a <ul> or <ol>. Stray content between the items, such as
+// a list nested directly in the list, joins the item before it.
+func (c *converter) list(n *html.Node) string {
+	var items [][]string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if c.ignored(child) {
+			continue
+		}
+		if child.DataAtom == atom.Li {
+			items = append(items, c.blocks(child))
+			continue
+		}
+		var blocks []string
+		if child.Type == html.ElementNode && blockElements[child.DataAtom] {
+			blocks = c.block(child)
+		} else if p := paragraph(c.inlineOf(child)); p != "" {
+			blocks = []string{p}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if len(items) == 0 {
+			items = append(items, nil)
+		}
+		items[len(items)-1] = append(items[len(items)-1], blocks...)
+	}
+	start := 1
+	if s, err := strconv.Atoi(attr(n, "start")); err == nil && s >= 0 {
+		start = s
+	}
+	return listBlock(n.DataAtom == atom.Ol, start, items)
+}
+
+type listItem struct {
+	level   int
+	ordered bool
+	blocks  []string
+}
+
+// msoList converts a run of Word list paragraphs into lists nested by
+// their levels.
+func (c *converter) msoList(ps []*html.Node) []string {
+	items := make([]listItem, len(ps))
+	for i, p := range ps {
+		level, _ := msoListLevel(p)
+		items[i] = listItem{level: level, blocks: c.blocks(p)}
+		walk(p, func(n *html.Node) {
+			if isListMarker(n) {
+				mark := strings.TrimSpace(spaceRegex.ReplaceAllString(textContent(n), " "))
+				items[i].ordered = orderedMarkRegex.MatchString(mark)
+			}
+		})
+	}
+
+	var build func(i, level int) (string, int)
+	build = func(i, level int) (string, int) {
+		ordered := items[i].ordered
+		var bodies [][]string
+		for i < len(items) && items[i].level >= level {
+			if items[i].level > level {
+				sub, next := build(i, items[i].level)
+				if len(bodies) == 0 {
+					bodies = append(bodies, nil)
+				}
+				bodies[len(bodies)-1] = append(bodies[len(bodies)-1], sub)
+				i = next
+				continue
+			}
+			bodies = append(bodies, items[i].blocks)
+			i++
+		}
+		return listBlock(ordered, 1, bodies), i
+	}
+	var lists []string
+	for i := 0; i < len(items); {
+		list, next := build(i, items[i].level)
+		lists = append(lists, list)
+		i = next
+	}
+	return lists
+}
+
+// msoListLevel reports whether n is a Word list paragraph and its level.
+func msoListLevel(n *html.Node) (int, bool) {
+	if n.Type != html.ElementNode || n.DataAtom != atom.P {
+		return 0, false
+	}
+	m := msoListRegex.FindStringSubmatch(attr(n, "style"))
+	if m == nil {
+		return 0, false
+	}
+	level, _ := strconv.Atoi(m[2])
+	return level, true
+}
+
+// listBlock writes list items from their blocks. Items holding more than
+// a paragraph and a nested list make the whole list loose.
+func listBlock(ordered bool, start int, items [][]string) string {
+	loose := false
+	for _, blocks := range items {
+		paragraphs := 0
+		for _, block := range blocks {
+			if !listStartRegex.MatchString(block) {
+				paragraphs++
+			}
+		}
+		if paragraphs > 1 {
+			loose = true
+		}
+	}
+	out := make([]string, len(items))
+	for i, blocks := range items {
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(start+i) + ". "
+		}
+		var body strings.Builder
+		for j, block := range blocks {
+			if j > 0 {
+				if loose || !listStartRegex.MatchString(block) {
+					body.WriteString("\n")
+				}
+				body.WriteString("\n")
+			}
+			body.WriteString(block)
+		}
+		if body.Len() == 0 {
+			out[i] = strings.TrimSpace(marker)
+			continue
+		}
+		out[i] = marker + indentLines(body.String(), strings.Repeat(" ", len(marker)))
+	}
+	if loose {
+		return strings.Join(out, "\n\n")
+	}
+	return strings.Join(out, "\n")
+}
+
+// indentLines indents every line of text but the first that is not blank.
+func indentLines(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeBlock fences the text of a <pre>, with the language its classes
+// name or else the one it looks like.
+func codeBlock(n *html.Node) string {
+	code := strings.TrimRight(preText(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	lang := codeLanguage(n)
+	if lang == "" {
+		lang = detectLanguage(code)
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+// codeLanguage finds a language named the way highlighters do, on a <pre>,
+// the <code> in it or the element around it.
+func codeLanguage(pre *html.Node) string {
+	candidates := []*html.Node{pre, pre.Parent}
+	for child := pre.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Code {
+			candidates = append(candidates, child)
+		}
+	}
+	for _, n := range candidates {
+		if n == nil {
+			continue
+		}
+		if lang := attr(n, "data-lang"); lang != "" {
+			return strings.ToLower(lang)
+		}
+		for _, key := range []string{"class", "data-syntaxhighlighter-params"} {
+			if m := codeClassRegex.FindStringSubmatch(attr(n, key)); m != nil {
+				return strings.ToLower(m[1])
+			}
+		}
+	}
+	return ""
+}
+
+// table converts a table to a GFM table, its first row the header. A table
+// that holds another table is taken for layout and its cells are converted
+// as blocks.
+func (c *converter) table(n *html.Node) []string {
+	var rows []*html.Node
+	var caption []string
+	var layout bool
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.DataAtom {
+		case atom.Caption:
+			caption = c.blocks(child)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			for tr := child.FirstChild; tr != nil; tr = tr.NextSibling {
+				if tr.DataAtom == atom.Tr {
+					rows = append(rows, tr)
+				}
+			}
+		case atom.Tr:
+			rows = append(rows, child)
+		}
+	}
+	var cells []*html.Node
+	for _, tr := range rows {
+		for td := tr.FirstChild; td != nil; td = td.NextSibling {
+			if td.DataAtom == atom.Td || td.DataAtom == atom.Th {
+				cells = append(cells, td)
+				walk(td, func(inner *html.Node) {
+					if inner.DataAtom == atom.Table {
+						layout = true
+					}
+				})
+			}
+		}
+	}
+	if len(cells) == 0 {
+		return caption
+	}
+	if layout || len(cells) == 1 {
+		blocks := caption
+		for _, td := range cells {
+			blocks = append(blocks, c.blocks(td)...)
+		}
+		return blocks
+	}
+
+	var grid [][]string
+	var aligns []string
+	for r, tr := range rows {
+		var row []string
+		for td := tr.FirstChild; td != nil; td = td.NextSibling {
+			if td.DataAtom != atom.Td && td.DataAtom != atom.Th {
+				continue
+			}
+			cell := c.cell(td)
+			if r == 0 {
+				// Header cells are bold already.
+				if inner, ok := strings.CutPrefix(cell, "**"); ok && strings.HasSuffix(inner, "**") && !strings.Contains(inner[:len(inner)-2], "**") && len(inner) > 2 {
+					cell = inner[:len(inner)-2]
+				}
+			}
+			row = append(row, cell)
+			span, _ := strconv.Atoi(attr(td, "colspan"))
+			for i := 1; i < span; i++ {
+				row = append(row, "")
+			}
+			if r == 0 {
+				align := strings.ToLower(attr(td, "align"))
+				if m := textAlignRegex.FindStringSubmatch(attr(td, "style")); m != nil {
+					align = m[1]
+				}
+				aligns = append(aligns, align)
+				for i := 1; i < span; i++ {
+					aligns = append(aligns, "")
+				}
+			}
+		}
+		grid = append(grid, row)
+	}
+	return append(caption, gfmTable(grid, aligns))
+}
+
+// cell converts a table cell to a single line, its paragraphs and line
+// breaks joined by <br>.
+func (c *converter) cell(td *html.Node) string {
+	c.inTable++
+	defer func() { c.inTable-- }()
+	blocks := c.blocks(td)
+	for i, block := range blocks {
+		blocks[i] = strings.ReplaceAll(block, "\n", "<br>")
+	}
+	return strings.Join(blocks, "<br>")
+}
+
+// gfmTable lays out rows as a GFM table with padded columns.
+func gfmTable(rows [][]string, aligns []string) string {
+	columns := len(aligns)
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	widths := make([]int, columns)
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], markdown.DisplayWidth(cell))
+		}
+	}
+
+	line := func(cells []string) string {
+		var b strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString("| " + cell + strings.Repeat(" ", width-markdown.DisplayWidth(cell)) + " ")
+		}
+		return b.String() + "|"
+	}
+	delimiter := make([]string, columns)
+	for i, width := range widths {
+		align := ""
+		if i < len(aligns) {
+			align = aligns[i]
+		}
+		switch align {
+		case "center":
+			delimiter[i] = ":" + strings.Repeat("-", width-2) + ":"
+		case "right":
+			delimiter[i] = strings.Repeat("-", width-1) + ":"
+		case "left":
+			delimiter[i] = ":" + strings.Repeat("-", width-1)
+		default:
+			delimiter[i] = strings.Repeat("-", width)
+		}
+	}
+
+	lines := []string{line(rows[0]), line(delimiter)}
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *converter) inlineString(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.inline(&b, child)
+	}
+	return b.String()
+}
+
+func (c *converter) inlineOf(n *html.Node) string {
+	var b strings.Builder
+	c.inline(&b, n)
+	return b.String()
+}
+
+func (c *converter) inline(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		text := spaceRegex.ReplaceAllString(n.Data, " ")
+		if c.trimSpace {
+			text = strings.TrimLeft(text, " ")
+			c.trimSpace = text == ""
+		}
+		b.WriteString(c.escape(text))
+		return
+	}
+	if n.Type != html.ElementNode || c.ignored(n) {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		if c.inTable > 0 {
+			b.WriteString("<br>")
+		} else {
+			b.WriteString("\n")
+		}
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		b.WriteString(c.codeSpan(spaceRegex.ReplaceAllString(textContent(n), " ")))
+	case atom.A:
+		c.link(b, n)
+	case atom.Img:
+		c.image(b, n)
+	case atom.Sup, atom.Sub:
+		inner := c.inlineString(n)
+		if strings.HasPrefix(inner, "[^") || strings.TrimSpace(inner) == "" {
+			b.WriteString(strings.TrimSpace(inner))
+		} else {
+			b.WriteString("<" + n.Data + ">" + inner + "</" + n.Data + ">")
+		}
+	case atom.Input:
+		if strings.EqualFold(attr(n, "type"), "checkbox") {
+			if hasAttr(n, "checked") {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+			c.trimSpace = true
+		}
+	case atom.Q:
+		b.WriteString(`"` + c.inlineString(n) + `"`)
+	default:
+		c.styled(b, n)
+	}
+}
+
+// styled converts an element that may make its text bold, italic or
+// struck through, by its tag or its inline style.
+func (c *converter) styled(b *strings.Builder, n *html.Node) {
+	bold := n.DataAtom == atom.B || n.DataAtom == atom.Strong
+	italic := n.DataAtom == atom.I || n.DataAtom == atom.Em || n.DataAtom == atom.Cite || n.DataAtom == atom.Dfn || n.DataAtom == atom.Var
+	struck := n.DataAtom == atom.S || n.DataAtom == atom.Del || n.Data == "strike"
+	style := strings.ToLower(attr(n, "style"))
+	if weight, ok := styleBold(style); ok {
+		bold = weight
+	}
+	if m := fontStyleRegex.FindStringSubmatch(style); m != nil {
+		italic = m[1] == "italic" || m[1] == "oblique"
+	}
+	if strings.Contains(style, "line-through") {
+		struck = true
+	}
+
+	var marks []string
+	for _, mark := range []struct {
+		on    bool
+		depth *int
+		delim string
+	}{{struck, &c.struck, "~~"}, {italic, &c.italic, "*"}, {bold, &c.bold, "**"}} {
+		if mark.on && *mark.depth == 0 {
+			*mark.depth++
+			defer func(depth *int) { *depth-- }(mark.depth)
+			marks = append(marks, mark.delim)
+		}
+	}
+	inner := c.inlineString(n)
+	for _, delim := range marks {
+		inner = wrap(inner, delim)
+	}
+	b.WriteString(inner)
+}
+
+// styleBold reports whether an inline style sets a bold font weight, and
+// whether it sets a weight at all.
+func styleBold(style string) (bold, ok bool) {
+	m := fontWeightRegex.FindStringSubmatch(style)
+	if m == nil {
+		return false, false
+	}
+	weight, err := strconv.Atoi(m[1])
+	return m[1] == "bold" || m[1] == "bolder" || err == nil && weight >= 600, true
+}
+
+// styledText reports whether an inline style makes text bold, italic or
+// struck through.
+func styledText(style string) bool {
+	bold, _ := styleBold(style)
+	m := fontStyleRegex.FindStringSubmatch(style)
+	return bold || m != nil && m[1] == "italic" || strings.Contains(style, "line-through")
+}
+
+// wrap puts delim around text, leaving the spaces at either end outside,
+// where emphasis needs them.
+func wrap(text, delim string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + delim + trimmed + delim + text[start+len(trimmed):]
+}
+
+func (c *converter) codeSpan(code string) string {
+	if c.inTable > 0 {
+		code = strings.ReplaceAll(code, "|", `\|`)
+	}
+	if strings.TrimSpace(code) == "" {
+		return code
+	}
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+func (c *converter) link(b *strings.Builder, n *html.Node) {
+	href := strings.TrimSpace(attr(n, "href"))
+	if strings.HasPrefix(href, "#") {
+		if body, ok := c.footnotes[href[1:]]; ok {
+			for _, id := range []string{attr(n, "id"), attr(n, "name"), attr(n.Parent, "id")} {
+				if id != "" {
+					c.refIDs[id] = true
+				}
+			}
+			fmt.Fprintf(b, "[^%d]", c.noteNumber(body))
+			return
+		}
+		if c.refIDs[href[1:]] {
+			return
+		}
+	}
+	if strings.Contains(attr(n, "class"), "footnote-back") || attr(n, "role") == "doc-backlink" {
+		return
+	}
+
+	label := c.inlineString(n)
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		b.WriteString(label)
+		return
+	}
+	if strings.TrimSpace(label) == "" {
+		return
+	}
+	if u, err := url.Parse(href); err == nil && u.Scheme != "" && label == c.escape(href) {
+		b.WriteString("<" + href + ">")
+		return
+	}
+	// Spaces at either end of the label go outside the link.
+	trimmed := strings.TrimSpace(label)
+	start := strings.Index(label, trimmed)
+	b.WriteString(label[:start] + "[" + trimmed + "](" + destination(href, attr(n, "title")) + ")" + label[start+len(trimmed):])
+}
+
+// destination writes a link destination and its optional title.
+func destination(dest, title string) string {
+	dest = strings.NewReplacer(" ", "%20", "(", `\(`, ")", `\)`).Replace(dest)
+	if title != "" {
+		dest += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return dest
+}
+
+func (c *converter) image(b *strings.Builder, n *html.Node) {
+	src := strings.TrimSpace(attr(n, "src"))
+	if src == "" {
+		return
+	}
+	alt := c.escape(spaceRegex.ReplaceAllString(attr(n, "alt"), " "))
+	b.WriteString("![" + alt + "](" + destination(c.imageRef(src), attr(n, "title")) + ")")
+}
+
+// imageRef saves an embedded image, and a local or remote one when
+// opts.LocalImages or opts.RemoteImages allows it, through opts.SaveImage
+// and returns the reference to it. Other images, and images that cannot be
+// saved, keep their source.
+func (c *converter) imageRef(src string) string {
+	if c.opts.SaveImage == nil {
+		return src
+	}
+	if ref, ok := c.images[src]; ok {
+		return ref
+	}
+	uri := ""
+	if strings.HasPrefix(src, "data:") {
+		uri = src
+	} else if isRemote(src) {
+		if c.opts.RemoteImages {
+			uri, _ = fetchImage(src)
+		}
+	} else if c.opts.LocalImages {
+		// Only real images are copied, never other files a reference
+		// happens to name.
+		if name, ok := localImage(c.opts.BaseDir, src); ok {
+			if data, err := os.ReadFile(name); err == nil && strings.HasPrefix(http.DetectContentType(data), "image/") {
+				uri = dataURI(name, data)
+			}
+		}
+	}
+	ref := src
+	if uri != "" {
+		if saved, err := c.opts.SaveImage(uri); err == nil {
+			ref = saved
+		}
+	}
+	c.images[src] = ref
+	return ref
+}
+
+// localImage returns the file a relative image reference points to. Files
+// outside baseDir, including through symlinks, are refused.
+func localImage(baseDir, src string) (string, bool) {
+	name, ok := localfiles.Resolve(baseDir, src)
+	if !ok {
+		return "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		name = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(baseDir); err == nil {
+		baseDir = resolved
+	}
+	rel, err := filepath.Rel(baseDir, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return name, true
+}
+
+// escape backslash-escapes the characters that would format text.
+// Underscores inside words and < that cannot start a tag are left alone.
+func (c *converter) escape(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch ch {
+		case '\\', '`', '*', '[', ']', '~', '$':
+			b.WriteByte('\\')
+		case '_':
+			if i == 0 || i == len(text)-1 || !isWordByte(text[i-1]) || !isWordByte(text[i+1]) {
+				b.WriteByte('\\')
+			}
+		case '<':
+			if i+1 < len(text) && (isLetter(text[i+1]) || text[i+1] == '/' || text[i+1] == '!') {
+				b.WriteByte('\\')
+			}
+		case '|':
+			if c.inTable > 0 {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+func isLetter(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isWordByte(ch byte) bool {
+	return isLetter(ch) || ch >= '0' && ch <= '9' || ch >= 0x80
+}
+
+// ignored reports whether n and its content are left out: scripts and
+// other non-content, hidden elements, Word's list markers and footnotes,
+// which are written at the end.
+func (c *converter) ignored(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return n.Type != html.TextNode
+	}
+	if ignoredElements[n.DataAtom] || c.skip[n] {
+		return true
+	}
+	return isListMarker(n) || strings.Contains(compactStyle(n), "display:none")
+}
+
+// isListMarker reports whether n is the marker of a Word list paragraph.
+func isListMarker(n *html.Node) bool {
+	return strings.Contains(compactStyle(n), "mso-list:ignore")
+}
+
+func compactStyle(n *html.Node) string {
+	return strings.ToLower(strings.ReplaceAll(attr(n, "style"), " ", ""))
+}
+
+func walk(n *html.Node, visit func(*html.Node)) {
+	visit(n)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, visit)
+	}
+}
+
+// preText returns the text of preformatted content, with a newline for
+// each <br> and after each block, as code copied line by line in <div>s
+// has.
+func preText(n *html.Node) string {
+	var b strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode:
+				b.WriteString(child.Data)
+			case child.DataAtom == atom.Br:
+				b.WriteString("\n")
+			default:
+				visit(child)
+				if blockElements[child.DataAtom] && b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+					b.WriteString("\n")
+				}
+			}
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+// textContent returns the text in n, with a newline for each <br>.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.DataAtom == atom.Br:
+			b.WriteString("\n")
+		}
+	})
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
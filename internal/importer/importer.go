@@ -0,0 +1,145 @@
+// Package importer converts HTML pages and Word documents into Markdown.
+package importer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Options controls where the images of an imported document go.
+type Options struct {
+	// BaseDir resolves relative image references in HTML. Images outside
+	// it are not read.
+	BaseDir string
+	// SaveImage stores an image given as a data URI and returns the
+	// reference to write in the Markdown. When nil, images keep their
+	// source reference.
+	SaveImage func(dataURI string) (string, error)
+	// RemoteImages downloads http and https images so SaveImage can keep
+	// a copy.
+	RemoteImages bool
+	// LocalImages reads relative images under BaseDir so SaveImage can
+	// keep a copy. Leave it off for HTML from untrusted sources such as
+	// the clipboard.
+	LocalImages bool
+}
+
+// maxImageSize bounds the download of a remote image.
+const maxImageSize = 20 << 20
+
+var imageClient = &http.Client{Timeout: 30 * time.Second}
+
+// Extensions lists the file types ImportFile understands.
+var Extensions = []string{".html", ".htm", ".docx"}
+
+// ImportFile converts an HTML or DOCX file to Markdown, choosing the
+// importer by extension. Relative images in HTML resolve from the file's
+// folder unless opts.BaseDir is set.
+func ImportFile(path string, opts Options) (string, error) {
+	if opts.BaseDir == "" {
+		opts.BaseDir = filepath.Dir(path)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return HTMLToMarkdown(f, opts)
+	case ".docx":
+		return DOCXToMarkdown(path, opts)
+	}
+	return "", fmt.Errorf("cannot import %s files", filepath.Ext(path))
+}
+
+// dataURI encodes an image as a base64 data URI, typed by the extension of
+// name or else by its content.
+func dataURI(name string, data []byte) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	mimeType := mime.TypeByExtension(ext)
+	switch {
+	case ext == ".emf" || ext == ".wmf":
+		// Word's vector formats are unknown to most MIME tables.
+		mimeType = "image/x-" + ext[1:]
+	case mimeType == "":
+		mimeType = http.DetectContentType(data)
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func isRemote(src string) bool {
+	lower := strings.ToLower(src)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "//")
+}
+
+// fetchImage downloads an image as a data URI.
+func fetchImage(src string) (string, error) {
+	if strings.HasPrefix(src, "//") {
+		src = "https:" + src
+	}
+	resp, err := imageClient.Get(src)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", src, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImageSize {
+		return "", fmt.Errorf("%s: image larger than %d MB", src, maxImageSize>>20)
+	}
+	mimeType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("%s is not an image", src)
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// HasFormatting reports whether HTML holds anything Markdown can express
+// beyond plain text: links, images, emphasis, code, lists, tables, headings
+// or quotes.
+func HasFormatting(markup string) bool {
+	z := html.NewTokenizer(strings.NewReader(markup))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.A, atom.Img, atom.B, atom.Strong, atom.I, atom.Em, atom.Code, atom.Pre,
+				atom.Ul, atom.Ol, atom.Table, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5,
+				atom.H6, atom.Blockquote, atom.S, atom.Del:
+				return true
+			}
+			// Editors such as Google Docs style spans instead.
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "style" && styledText(strings.ToLower(string(val))) {
+					return true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// languageRules guess the language of a code block from its text. The
+// first rule that matches wins, so more telling patterns come first.
+var languageRules = []struct {
+	lang    string
+	pattern *regexp.Regexp
+}{
+	{"php", regexp.MustCompile(`^\s*<\?php`)},
+	{"xml", regexp.MustCompile(`^\s*<\?xml`)},
+	{"html", regexp.MustCompile(`(?i)^\s*<(!doctype html|html|head|body|div|span|p|a|ul|table|script)[\s>]`)},
+	{"python", regexp.MustCompile(`^#!.*python`)},
+	{"javascript", regexp.MustCompile(`^#!.*node`)},
+	{"bash", regexp.MustCompile(`^#!.*(ba|z)?sh\b`)},
+	{"go", regexp.MustCompile(`(?m)^package \w+\s*$|^func (\(\w+ \*?\w+\) )?\w+\(.*\{\s*$|:= `)},
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+.*(->.*)?\{|\blet mut\b|^use \w+::`)},
+	{"cpp", regexp.MustCompile(`(?m)^#include\s*<(iostream|vector|string|map)>|\bstd::`)},
+	{"c", regexp.MustCompile(`(?m)^#include\s*[<"]`)},
+	{"csharp", regexp.MustCompile(`(?m)^using System|\bnamespace [\w.]+\s*\{?\s*$|Console\.Write`)},
+	{"java", regexp.MustCompile(`\bpublic (static )?(final )?(class|void|interface)\b|System\.out\.print`)},
+	{"python", regexp.MustCompile(`(?m)^\s*(def \w+\(.*\)( -> .+)?:|class \w+(\(.*\))?:|from [\w.]+ import |import \w+$|if __name__ ==)`)},
+	{"typescript", regexp.MustCompile(`(?m)^\s*(export )?(interface|type) \w+|: (string|number|boolean)\b`)},
+	{"javascript", regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = |\bfunction\s*\w*\(|=> \{|console\.log|require\(|^\s*(import|export) `)},
+	{"sql", regexp.MustCompile(`(?im)^\s*(select .+ from|insert into|update \w+ set|delete from|create (table|index|view))\b`)},
+	{"css", regexp.MustCompile(`(?m)^\s*[.#@]?[\w-]+(\s*[,>:.#\w-]*)*\s*\{\s*$\n\s*[\w-]+\s*:`)},
+	{"bash", regexp.MustCompile(`(?m)^\s*(\$ |sudo |apt(-get)? |brew |npm |yarn |pip |git |cd |ls |echo |export |curl |docker |go (run|build|get|install) )`)},
+	{"yaml", regexp.MustCompile(`(?m)\A(---\s*\n)?(\s*#.*\n)*\s*[\w.-]+:( .*)?\n\s*[\w.-]+:`)},
+}
+
+// detectLanguage guesses the language of code that names none, returning
+// "" when nothing is recognised.
+func detectLanguage(code string) string {
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" {
+		return ""
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+	for _, rule := range languageRules {
+		if rule.pattern.MatchString(code) {
+			return rule.lang
+		}
+	}
+	return ""
+}
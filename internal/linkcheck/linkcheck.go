@@ -0,0 +1,363 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"markviewpro/internal/foldermanager"
+	"markviewpro/internal/markdown"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	KindLink  = "link"
+	KindImage = "image"
+
+	ProblemMissingFile   = "missing-file"
+	ProblemMissingAnchor = "missing-anchor"
+	ProblemBrokenURL     = "broken-url"
+)
+
+const (
+	externalTimeout     = 10 * time.Second
+	externalConcurrency = 8
+)
+
+type Issue struct {
+	Kind    string         `json:"kind"`
+	Problem string         `json:"problem"`
+	Target  string         `json:"target"`
+	Message string         `json:"message"`
+	Range   markdown.Range `json:"range"`
+}
+
+type DocumentReport struct {
+	Path    string  `json:"path"`
+	Checked int     `json:"checked"`
+	Issues  []Issue `json:"issues"`
+}
+
+type Options struct {
+	// Root is the workspace folder. Links starting with "/" resolve
+	// against it.
+	Root string `json:"root"`
+	// CheckExternal sends a request to every http(s) URL.
+	CheckExternal bool `json:"checkExternal"`
+}
+
+type Checker struct {
+	renderer *markdown.Renderer
+	client   *http.Client
+}
+
+func NewChecker(renderer *markdown.Renderer) *Checker {
+	return &Checker{
+		renderer: renderer,
+		client:   &http.Client{Timeout: externalTimeout},
+	}
+}
+
+// checkRun holds the caches of one CheckDocument or CheckFolder call, so
+// that calls running at the same time do not share them.
+type checkRun struct {
+	*Checker
+
+	mu        sync.Mutex
+	anchors   map[string]map[string]bool
+	externals map[string]error
+}
+
+func (c *Checker) newRun() *checkRun {
+	return &checkRun{
+		Checker:   c,
+		anchors:   make(map[string]map[string]bool),
+		externals: make(map[string]error),
+	}
+}
+
+// link is a destination found in a document, with the range it was
+// reported at.
+type link struct {
+	kind   string
+	target string
+	rng    markdown.Range
+}
+
+// CheckDocument validates the links and images in content, which is the
+// text of the document at path.
+func (c *Checker) CheckDocument(ctx context.Context, path, content string, opts Options) DocumentReport {
+	return c.newRun().checkDocument(ctx, path, content, opts)
+}
+
+// CheckFolder validates every Markdown document under root.
+func (c *Checker) CheckFolder(ctx context.Context, root string, opts Options) ([]DocumentReport, error) {
+	files, err := foldermanager.ListMarkdownFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Root == "" {
+		opts.Root = root
+	}
+
+	run := c.newRun()
+	reports := make([]DocumentReport, 0, len(files))
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return reports, err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, run.checkDocument(ctx, file, string(content), opts))
+	}
+	return reports, nil
+}
+
+func (c *checkRun) checkDocument(ctx context.Context, path, content string, opts Options) DocumentReport {
+	report := DocumentReport{
+		Path:   path,
+		Issues: make([]Issue, 0),
+	}
+	links := c.collectLinks(content)
+	report.Checked = len(links)
+
+	var external []link
+	for _, l := range links {
+		u, err := url.Parse(l.target)
+		if err != nil {
+			report.Issues = append(report.Issues, newIssue(l, ProblemMissingFile, "Invalid link: %v", err))
+			continue
+		}
+
+		switch {
+		case u.Scheme == "http" || u.Scheme == "https":
+			if opts.CheckExternal {
+				external = append(external, l)
+			}
+		case u.Scheme != "" || u.Opaque != "":
+			// mailto:, data: and other schemes cannot be checked locally.
+		case u.Path == "" && u.Fragment != "":
+			if !c.hasAnchor(path, content, u.Fragment) {
+				report.Issues = append(report.Issues, newIssue(l, ProblemMissingAnchor, "No heading with id %q in this document", u.Fragment))
+			}
+		case u.Path != "":
+			if issue, ok := c.checkLocal(l, u, path, opts); !ok {
+				report.Issues = append(report.Issues, issue)
+			}
+		}
+	}
+
+	report.Issues = append(report.Issues, c.checkExternal(ctx, external)...)
+	return report
+}
+
+func newIssue(l link, problem, format string, args ...interface{}) Issue {
+	return Issue{
+		Kind:    l.kind,
+		Problem: problem,
+		Target:  l.target,
+		Message: fmt.Sprintf(format, args...),
+		Range:   l.rng,
+	}
+}
+
+func (c *checkRun) checkLocal(l link, u *url.URL, documentPath string, opts Options) (Issue, bool) {
+	target := filepath.FromSlash(u.Path)
+	switch {
+	case strings.HasPrefix(u.Path, "/") && opts.Root != "":
+		target = filepath.Join(opts.Root, target)
+	case !filepath.IsAbs(target):
+		target = filepath.Join(filepath.Dir(documentPath), target)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		what := "File"
+		if l.kind == KindImage {
+			what = "Image"
+		}
+		return newIssue(l, ProblemMissingFile, "%s not found: %s", what, target), false
+	}
+
+	if u.Fragment == "" || info.IsDir() || !foldermanager.IsMarkdownFile(target) {
+		return Issue{}, true
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return newIssue(l, ProblemMissingFile, "Cannot read %s: %v", target, err), false
+	}
+	if !c.hasAnchor(target, string(content), u.Fragment) {
+		return newIssue(l, ProblemMissingAnchor, "No heading with id %q in %s", u.Fragment, filepath.Base(target)), false
+	}
+	return Issue{}, true
+}
+
+var htmlAnchorRegex = regexp.MustCompile(`(?i)<[a-z][^>]*\s(?:id|name)\s*=\s*["']([^"']+)["']`)
+
+// hasAnchor reports whether the document defines fragment, either as a
+// heading id or as an id/name attribute in raw HTML.
+func (c *checkRun) hasAnchor(path, content, fragment string) bool {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+
+	c.mu.Lock()
+	anchors, ok := c.anchors[key]
+	c.mu.Unlock()
+
+	if !ok {
+		anchors = make(map[string]bool)
+		for _, id := range c.renderer.HeadingIDs(content) {
+			anchors[id] = true
+		}
+		for _, m := range htmlAnchorRegex.FindAllStringSubmatch(content, -1) {
+			anchors[m[1]] = true
+		}
+		c.mu.Lock()
+		c.anchors[key] = anchors
+		c.mu.Unlock()
+	}
+
+	if decoded, err := url.PathUnescape(fragment); err == nil {
+		fragment = decoded
+	}
+	return anchors[fragment]
+}
+
+// checkExternal requests every URL with bounded concurrency. Each URL is
+// only requested once per run.
+func (c *checkRun) checkExternal(ctx context.Context, links []link) []Issue {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		issues = make([]Issue, 0)
+		sem    = make(chan struct{}, externalConcurrency)
+	)
+
+	for _, l := range links {
+		wg.Add(1)
+		go func(l link) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := c.fetch(ctx, l.target); err != nil {
+				mu.Lock()
+				issues = append(issues, newIssue(l, ProblemBrokenURL, "%v", err))
+				mu.Unlock()
+			}
+		}(l)
+	}
+	wg.Wait()
+	return issues
+}
+
+func (c *checkRun) fetch(ctx context.Context, target string) error {
+	c.mu.Lock()
+	err, seen := c.externals[target]
+	c.mu.Unlock()
+	if seen {
+		return err
+	}
+
+	status, err := c.request(ctx, http.MethodHead, target)
+	if status == http.StatusMethodNotAllowed {
+		// Some servers do not allow HEAD.
+		_, err = c.request(ctx, http.MethodGet, target)
+	}
+
+	c.mu.Lock()
+	c.externals[target] = err
+	c.mu.Unlock()
+	return err
+}
+
+// request returns the response status along with an error for failed
+// requests and error statuses.
+func (c *Checker) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "MarkViewPro link checker")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// collectLinks finds link, autolink and image destinations in document
+// order. Link nodes carry no source position, so each destination is
+// located by searching its block's source lines.
+func (c *Checker) collectLinks(content string) []link {
+	source := []byte(content)
+	doc := c.renderer.Parse(source)
+	index := markdown.NewLineIndex(source)
+	var links []link
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Type() != ast.TypeBlock || n.Lines().Len() == 0 || n.Kind() == ast.KindFencedCodeBlock || n.Kind() == ast.KindCodeBlock || n.Kind() == ast.KindHTMLBlock {
+			return ast.WalkContinue, nil
+		}
+
+		blockRange, _ := index.BlockRange(n)
+		lines := n.Lines()
+		cursor := lines.At(0).Start
+		end := lines.At(lines.Len() - 1).Stop
+
+		ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+			var kind string
+			var dest, written []byte
+			switch node := child.(type) {
+			case *ast.Link:
+				kind, dest = KindLink, node.Destination
+			case *ast.Image:
+				kind, dest = KindImage, node.Destination
+			case *ast.AutoLink:
+				if node.AutoLinkType != ast.AutoLinkURL {
+					return ast.WalkContinue, nil
+				}
+				// Bare www. links gain a scheme that is not in the source.
+				kind, dest, written = KindLink, node.URL(source), node.Label(source)
+			default:
+				return ast.WalkContinue, nil
+			}
+			if len(dest) == 0 {
+				return ast.WalkContinue, nil
+			}
+			if written == nil {
+				written = dest
+			}
+
+			l := link{kind: kind, target: string(dest), rng: blockRange}
+			if i := strings.Index(string(source[cursor:end]), string(written)); i >= 0 {
+				start := cursor + i
+				cursor = start + len(written)
+				l.rng = index.Range(start, cursor)
+			}
+			links = append(links, l)
+			return ast.WalkContinue, nil
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return links
+}
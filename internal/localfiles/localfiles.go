@@ -0,0 +1,210 @@
+package localfiles
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Prefix is the URL path under which the webview requests files from disk.
+const Prefix = "/localfile/"
+
+// Handler serves files from the active document's folder and the open
+// workspace. Requests outside those folders are refused, including ones
+// that escape through ".." or symlinks. Only images, audio and video are
+// served for display; anything else, such as HTML that could script the
+// app's origin, is sent as a download.
+type Handler struct {
+	documentDir string
+	workspace   string
+	mu          sync.RWMutex
+}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SetDocument scopes the handler to the folder of the document at path.
+func (h *Handler) SetDocument(path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if path == "" {
+		h.documentDir = ""
+		return
+	}
+	h.documentDir = filepath.Dir(path)
+}
+
+func (h *Handler) SetWorkspace(folder string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workspace = folder
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, Prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ok := h.resolve(strings.TrimPrefix(r.URL.Path, Prefix))
+	if !ok {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	header := w.Header()
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "sandbox")
+	contentType, err := mediaType(f)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if contentType == "" {
+		header.Set("Content-Type", "application/octet-stream")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	} else {
+		header.Set("Content-Type", contentType)
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// mediaType returns the image, audio or video type of f, judged by its
+// extension or else its content, and "" for any other file.
+func mediaType(f *os.File) (string, error) {
+	contentType := mime.TypeByExtension(filepath.Ext(f.Name()))
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(f, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return "", err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		contentType = http.DetectContentType(head[:n])
+	}
+	media, _, _ := strings.Cut(contentType, "/")
+	switch media {
+	case "image", "audio", "video":
+		return contentType, nil
+	}
+	return "", nil
+}
+
+// resolve maps the slash-separated path from a request URL to a file on
+// disk, reporting false when it lies outside the allowed folders.
+func (h *Handler) resolve(urlPath string) (string, bool) {
+	if strings.Contains(urlPath, "\x00") {
+		return "", false
+	}
+	name := filepath.Clean(filepath.FromSlash(fromURLPath(urlPath)))
+	if !filepath.IsAbs(name) {
+		return "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		name = resolved
+	}
+
+	h.mu.RLock()
+	roots := []string{h.documentDir, h.workspace}
+	h.mu.RUnlock()
+
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		if isWithin(root, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func isWithin(root, name string) bool {
+	rel, err := filepath.Rel(root, name)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// fromURLPath restores the leading slash of Unix paths, which is dropped
+// when the path is appended to Prefix. Windows paths start with a drive
+// letter and are used as they are.
+func fromURLPath(p string) string {
+	if len(p) >= 2 && p[1] == ':' {
+		return p
+	}
+	return "/" + p
+}
+
+// URL returns the URL under which the handler serves the file at name.
+func URL(name string) string {
+	p := filepath.ToSlash(name)
+	p = strings.TrimPrefix(p, "/")
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return Prefix + strings.Join(segments, "/")
+}
+
+// Resolve returns the file a relative link in a document in baseDir points
+// to. URLs with a scheme, protocol-relative URLs, fragments and absolute
+// paths are left alone and reported as false.
+func Resolve(baseDir, ref string) (string, bool) {
+	if baseDir == "" || ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "\\") {
+		return "", false
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(path.Clean(u.Path))), true
+}
+
+// Rewrite turns a relative link in a document in baseDir into a handler
+// URL, keeping its query and fragment. Other links are returned unchanged.
+func Rewrite(baseDir, ref string) string {
+	name, ok := Resolve(baseDir, ref)
+	if !ok {
+		return ref
+	}
+	u, _ := url.Parse(ref)
+	rewritten := URL(name)
+	if u.RawQuery != "" {
+		rewritten += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		rewritten += "#" + u.EscapedFragment()
+	}
+	return rewritten
+}
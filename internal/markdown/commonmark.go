@@ -0,0 +1,309 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// CommonMarkOptions names the extensions the target of CommonMark
+// understands. Syntax it does not understand is rewritten.
+type CommonMarkOptions struct {
+	Tables    bool `json:"tables"`
+	TaskLists bool `json:"taskLists"`
+	Footnotes bool `json:"footnotes"`
+}
+
+// typographerSource is the punctuation the typographer replaces with each
+// entity.
+var typographerSource = map[string]string{
+	"&ldquo;": `"`, "&rdquo;": `"`, "&lsquo;": "'", "&rsquo;": "'",
+	"&ndash;": "--", "&mdash;": "---", "&hellip;": "...",
+	"&laquo;": "<<", "&raquo;": ">>",
+}
+
+var (
+	footnoteRefRegex   = regexp.MustCompile(`^\[\^[^\]\s]+\]`)
+	footnoteLabelRegex = regexp.MustCompile(`^[ \t>]*\[\^[^\]\s]+\]:[ \t]?`)
+	taskBoxRegex       = regexp.MustCompile(`^\[[ xX]\]`)
+)
+
+// CommonMark rewrites content as portable CommonMark. Typographer
+// punctuation becomes the characters the preview shows, strikethrough
+// becomes <del> and bare URLs become autolinks. Unless opts says the
+// target supports them, tables become HTML tables, task list boxes become
+// literal brackets and footnotes become numbered notes at the end. Like
+// Format, it edits the source in place, so everything else is left as
+// written.
+func (r *Renderer) CommonMark(content string, opts CommonMarkOptions) string {
+	source := []byte(content)
+	var notes []string
+	source = r.formatPass(source, func(f *formatter) {
+		if !opts.Tables {
+			r.htmlTables(f)
+		}
+		if !opts.Footnotes {
+			notes = f.footnotes()
+		}
+	}, FormatOptions{})
+	if len(notes) > 0 {
+		source = append(bytes.TrimRight(source, " \t\r\n"), "\n\n---\n\n"+strings.Join(notes, "\n")+"\n"...)
+	}
+	source = r.formatPass(source, func(f *formatter) {
+		f.typography()
+		f.strikethrough()
+		f.autolinks()
+		if !opts.TaskLists {
+			f.taskBoxes()
+		}
+	}, FormatOptions{})
+	return string(source)
+}
+
+// htmlTables replaces tables with the HTML the preview renders for them,
+// which CommonMark passes through as an HTML block.
+func (r *Renderer) htmlTables(f *formatter) {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		table, ok := n.(*east.Table)
+		if !ok {
+			return ast.WalkContinue
+		}
+		firstLine, lastLine := 0, 0
+		for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				if cell.Lines().Len() > 0 {
+					line := f.index.Position(cell.Lines().At(0).Start).Line
+					if firstLine == 0 {
+						firstLine = line
+					}
+					lastLine = line
+				}
+			}
+		}
+		if firstLine == 0 {
+			return ast.WalkSkipChildren
+		}
+		prefix := containerPrefixRegex.FindString(f.lines[firstLine-1])
+		for line := firstLine; line <= lastLine; line++ {
+			if containerPrefixRegex.FindString(f.lines[line-1]) != prefix {
+				return ast.WalkSkipChildren
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := r.md.Renderer().Render(&buf, f.source, table); err != nil {
+			return ast.WalkSkipChildren
+		}
+		markup := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		start, _ := f.lineBounds(firstLine)
+		_, stop := f.lineBounds(lastLine)
+		f.replace(start, stop, prefix+strings.Join(markup, "\n"+prefix))
+		return ast.WalkSkipChildren
+	})
+}
+
+// footnotes replaces references with superscript numbers and removes the
+// definitions, returning them as an ordered list in reference order.
+func (f *formatter) footnotes() []string {
+	var notes []string
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		switch n := n.(type) {
+		case *east.FootnoteLink:
+			if start, ok := inlineStart(n); ok {
+				if ref := footnoteRefRegex.Find(f.source[start:]); ref != nil {
+					f.replace(start, start+len(ref), fmt.Sprintf("<sup>%d</sup>", n.Index))
+				}
+			}
+		case *east.Footnote:
+			first, last, ok := blockLines(n, f.index)
+			if !ok {
+				return ast.WalkSkipChildren
+			}
+			marker := strconv.Itoa(n.Index) + ". "
+			var note []string
+			for line := first; line <= last; line++ {
+				text := f.lines[line-1]
+				if line == first {
+					text = footnoteLabelRegex.ReplaceAllString(text, "")
+				} else if strings.TrimSpace(text) != "" {
+					text = strings.Repeat(" ", len(marker)) + trimIndent(text, 4)
+				} else {
+					text = ""
+				}
+				note = append(note, text)
+			}
+			notes = append(notes, marker+strings.Join(note, "\n"))
+
+			start := f.index.LineStart(first)
+			end := last + 1
+			if end <= len(f.lines) && strings.TrimSpace(f.lines[end-1]) == "" {
+				end++
+			}
+			stop := len(f.source)
+			if end <= len(f.lines) {
+				stop = f.index.LineStart(end)
+			}
+			f.replace(start, stop, "")
+			return ast.WalkSkipChildren
+		}
+		return ast.WalkContinue
+	})
+	return notes
+}
+
+// blockLines returns the first and last 1-based source lines of a
+// container block's content.
+func blockLines(n ast.Node, index *LineIndex) (int, int, bool) {
+	first := n
+	for first.Lines().Len() == 0 && first.FirstChild() != nil {
+		first = first.FirstChild()
+	}
+	last := n
+	for last.Lines().Len() == 0 && last.LastChild() != nil {
+		last = last.LastChild()
+	}
+	if first.Lines().Len() == 0 || last.Lines().Len() == 0 {
+		return 0, 0, false
+	}
+	lines := last.Lines()
+	return index.Position(first.Lines().At(0).Start).Line, index.Position(lines.At(lines.Len() - 1).Start).Line, true
+}
+
+// trimIndent removes up to width leading spaces.
+func trimIndent(line string, width int) string {
+	for i := 0; i < width && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
+
+// typography replaces the typographer's quotes, dashes and ellipses with
+// the characters they render as.
+func (f *formatter) typography() {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		s, ok := n.(*ast.String)
+		if !ok || !s.IsCode() {
+			return ast.WalkContinue
+		}
+		punct, ok := typographerSource[string(s.Value)]
+		if !ok {
+			return ast.WalkContinue
+		}
+		start, ok := inlineStart(s)
+		if !ok {
+			// Quotes closing emphasis or a link are found from the text
+			// that follows them.
+			next, isText := s.NextSibling().(*ast.Text)
+			if !isText {
+				return ast.WalkContinue
+			}
+			start = next.Segment.Start - len(punct)
+		}
+		if start >= 0 && string(f.source[start:min(start+len(punct), len(f.source))]) == punct {
+			f.replace(start, start+len(punct), html.UnescapeString(string(s.Value)))
+		}
+		return ast.WalkContinue
+	})
+}
+
+// strikethrough rewrites ~~text~~ as <del>text</del>, for strikethrough
+// that starts and ends with plain text.
+func (f *formatter) strikethrough() {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		if _, ok := n.(*east.Strikethrough); !ok {
+			return ast.WalkContinue
+		}
+		first, ok1 := n.FirstChild().(*ast.Text)
+		last, ok2 := n.LastChild().(*ast.Text)
+		if !ok1 || !ok2 {
+			return ast.WalkContinue
+		}
+		open := first.Segment.Start
+		for open > 0 && f.source[open-1] == '~' && first.Segment.Start-open < 2 {
+			open--
+		}
+		close := last.Segment.Stop
+		for close < len(f.source) && f.source[close] == '~' && close-last.Segment.Stop < 2 {
+			close++
+		}
+		if open == first.Segment.Start || first.Segment.Start-open != close-last.Segment.Stop {
+			return ast.WalkContinue
+		}
+		f.replace(open, first.Segment.Start, "<del>")
+		f.replace(last.Segment.Stop, close, "</del>")
+		return ast.WalkContinue
+	})
+}
+
+// autolinks puts bare URLs and email addresses in angle brackets.
+func (f *formatter) autolinks() {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		link, ok := n.(*ast.AutoLink)
+		if !ok {
+			return ast.WalkContinue
+		}
+		label := link.Label(f.source)
+		start, ok := inlineStart(link)
+		if !ok || start > 0 && f.source[start-1] == '<' || !bytes.HasPrefix(f.source[start:], label) {
+			return ast.WalkContinue
+		}
+		dest := string(label)
+		if link.AutoLinkType == ast.AutoLinkURL {
+			dest = string(link.URL(f.source))
+		}
+		f.replace(start, start+len(label), "<"+dest+">")
+		return ast.WalkContinue
+	})
+}
+
+// taskBoxes escapes task list checkboxes so they show as brackets.
+func (f *formatter) taskBoxes() {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		box, ok := n.(*east.TaskCheckBox)
+		if !ok {
+			return ast.WalkContinue
+		}
+		start, ok := inlineStart(box)
+		if !ok || !taskBoxRegex.Match(f.source[start:]) {
+			return ast.WalkContinue
+		}
+		if box.IsChecked {
+			f.replace(start, start+3, `\[x\]`)
+		} else {
+			f.replace(start, start+3, `\[ \]`)
+		}
+		return ast.WalkContinue
+	})
+}
+
+// inlineStart returns where an inline node that carries no source segment
+// begins: where the text before it ends, or where its block's text starts.
+func inlineStart(n ast.Node) (int, bool) {
+	switch prev := n.PreviousSibling().(type) {
+	case *ast.Text:
+		if prev.SoftLineBreak() || prev.HardLineBreak() {
+			return 0, false
+		}
+		return prev.Segment.Stop, true
+	case *ast.String:
+		punct, ok := typographerSource[string(prev.Value)]
+		if !ok || !prev.IsCode() {
+			return 0, false
+		}
+		start, ok := inlineStart(prev)
+		return start + len(punct), ok
+	case nil:
+		parent := n.Parent()
+		if parent == nil || parent.Type() != ast.TypeBlock || parent.Lines().Len() == 0 {
+			return 0, false
+		}
+		return parent.Lines().At(0).Start, true
+	}
+	return 0, false
+}
@@ -0,0 +1,127 @@
+package markdown
+
+import (
+	"sort"
+	"strings"
+)
+
+// TextEdit replaces a range of the original document with NewText.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// byteEdit is a TextEdit in byte offsets, used while building a result.
+type byteEdit struct {
+	start, stop int
+	text        string
+}
+
+// applyEdits applies non-overlapping edits to source. Edits that overlap an
+// earlier one are dropped; callers re-run their pass to pick them up.
+func applyEdits(source []byte, edits []byteEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	var out []byte
+	pos := 0
+	for _, e := range edits {
+		if e.start < pos {
+			continue
+		}
+		out = append(out, source[pos:e.start]...)
+		out = append(out, e.text...)
+		pos = e.stop
+	}
+	return append(out, source[pos:]...)
+}
+
+// maxDiffCells bounds the line diff table; larger changes are reported as a
+// single edit covering the changed region.
+const maxDiffCells = 4_000_000
+
+// diffEdits returns line-based edits that turn before into after.
+func diffEdits(before, after string) []TextEdit {
+	a := strings.SplitAfter(before, "\n")
+	b := strings.SplitAfter(after, "\n")
+	index := NewLineIndex([]byte(before))
+
+	offsets := make([]int, len(a)+1)
+	for i, line := range a {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	// Trim the common prefix and suffix so the table only covers changes.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	am := a[prefix : len(a)-suffix]
+	bm := b[prefix : len(b)-suffix]
+
+	var edits []TextEdit
+	addHunk := func(i1, i2, j1, j2 int) {
+		edits = append(edits, TextEdit{
+			Range:   index.Range(offsets[prefix+i1], offsets[prefix+i2]),
+			NewText: strings.Join(bm[j1:j2], ""),
+		})
+	}
+
+	if len(am) == 0 && len(bm) == 0 {
+		return edits
+	}
+	if len(am)*len(bm) > maxDiffCells || len(am) == 0 || len(bm) == 0 {
+		addHunk(0, len(am), 0, len(bm))
+		return edits
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of am[i:]
+	// and bm[j:].
+	lcs := make([][]int, len(am)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bm)+1)
+	}
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			if am[i] == bm[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	hunkI, hunkJ := -1, -1
+	flush := func() {
+		if hunkI >= 0 {
+			addHunk(hunkI, i, hunkJ, j)
+			hunkI, hunkJ = -1, -1
+		}
+	}
+	for i < len(am) || j < len(bm) {
+		switch {
+		case i < len(am) && j < len(bm) && am[i] == bm[j]:
+			flush()
+			i++
+			j++
+		case j < len(bm) && (i == len(am) || lcs[i][j+1] >= lcs[i+1][j]):
+			if hunkI < 0 {
+				hunkI, hunkJ = i, j
+			}
+			j++
+		default:
+			if hunkI < 0 {
+				hunkI, hunkJ = i, j
+			}
+			i++
+		}
+	}
+	flush()
+	return edits
+}
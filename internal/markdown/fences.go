@@ -0,0 +1,70 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var fenceRegex = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+
+// fenceBlock is a fenced code block found by scanning source lines. Open
+// and Close are 0-based line indexes; Close is -1 when the block runs to the
+// end of the document.
+type fenceBlock struct {
+	Open   int
+	Close  int
+	Indent string
+	Marker string
+	Info   string
+}
+
+// scanFences finds fenced code blocks line by line. Checks that work on
+// raw lines use it to skip code without mapping AST nodes back to lines.
+func scanFences(lines []string) []fenceBlock {
+	var blocks []fenceBlock
+	var current *fenceBlock
+	for i, line := range lines {
+		if current != nil {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, current.Marker) && strings.Trim(trimmed, current.Marker[:1]) == "" {
+				current.Close = i
+				blocks = append(blocks, *current)
+				current = nil
+			}
+			continue
+		}
+		m := fenceRegex.FindStringSubmatch(line)
+		if m == nil || m[2][0] == '`' && strings.Contains(m[3], "`") {
+			continue
+		}
+		current = &fenceBlock{Open: i, Close: -1, Indent: m[1], Marker: m[2], Info: strings.TrimSpace(m[3])}
+	}
+	if current != nil {
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// codeLines marks every line that belongs to a fenced block, fences included.
+func codeLines(lines []string, fences []fenceBlock) []bool {
+	inCode := make([]bool, len(lines))
+	for _, f := range fences {
+		end := f.Close
+		if end < 0 {
+			end = len(lines) - 1
+		}
+		for i := f.Open; i <= end; i++ {
+			inCode[i] = true
+		}
+	}
+	return inCode
+}
+
+// splitLines splits source into lines without their terminators.
+func splitLines(source []byte) []string {
+	lines := strings.Split(string(source), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
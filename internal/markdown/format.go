@@ -0,0 +1,653 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	ProseWrapPreserve = "preserve"
+	ProseWrapAlways   = "always"
+	ProseWrapNever    = "never"
+)
+
+// FormatOptions controls Format. The preview renders soft line breaks as
+// <br>, so ProseWrap defaults to preserving the author's line breaks.
+type FormatOptions struct {
+	ListMarker     string `json:"listMarker"`     // "-", "*" or "+"
+	EmphasisMarker string `json:"emphasisMarker"` // "*" or "_"
+	StrongMarker   string `json:"strongMarker"`   // "**" or "__"
+	CodeFence      string `json:"codeFence"`      // "```" or "~~~"
+	ProseWrap      string `json:"proseWrap"`
+	LineWidth      int    `json:"lineWidth"`
+	AlignTables    bool   `json:"alignTables"`
+}
+
+func DefaultFormatOptions() FormatOptions {
+	return FormatOptions{
+		ListMarker:     "-",
+		EmphasisMarker: "*",
+		StrongMarker:   "**",
+		CodeFence:      "```",
+		ProseWrap:      ProseWrapPreserve,
+		LineWidth:      80,
+		AlignTables:    true,
+	}
+}
+
+type FormatResult struct {
+	Text    string     `json:"text"`
+	Edits   []TextEdit `json:"edits"`
+	Changed bool       `json:"changed"`
+}
+
+// Format rewrites content into a consistent Markdown style. It edits the
+// original source in place rather than re-rendering the AST, so anything a
+// pass does not understand is left exactly as written. Front matter is
+// kept as is.
+func (r *Renderer) Format(content string, opts FormatOptions) FormatResult {
+	_, source := SplitFrontMatter([]byte(content))
+	frontMatter := content[:len(content)-len(source)]
+
+	// Inline markers are fixed first because block passes rewrite whole
+	// lines and would overlap with them.
+	source = r.formatPass(source, func(f *formatter) {
+		f.emphasis()
+		f.listMarkers()
+		f.codeFences()
+	}, opts)
+	source = r.formatPass(source, func(f *formatter) {
+		f.headings()
+		f.tables()
+		f.paragraphs()
+	}, opts)
+	source = formatWhitespace(source, r.verbatimLines(source))
+
+	text := frontMatter + string(source)
+	return FormatResult{
+		Text:    text,
+		Edits:   diffEdits(content, text),
+		Changed: text != content,
+	}
+}
+
+type formatter struct {
+	source []byte
+	doc    ast.Node
+	opts   FormatOptions
+	index  *LineIndex
+	lines  []string
+	edits  []byteEdit
+}
+
+func (r *Renderer) formatPass(source []byte, pass func(f *formatter), opts FormatOptions) []byte {
+	f := &formatter{
+		source: source,
+		doc:    r.Parse(source),
+		opts:   opts,
+		index:  NewLineIndex(source),
+		lines:  splitLines(source),
+	}
+	pass(f)
+	if len(f.edits) == 0 {
+		return source
+	}
+	return applyEdits(source, f.edits)
+}
+
+func (f *formatter) replace(start, stop int, text string) {
+	if string(f.source[start:stop]) == text {
+		return
+	}
+	f.edits = append(f.edits, byteEdit{start: start, stop: stop, text: text})
+}
+
+// lineBounds returns the byte offsets of the 1-based line, without its
+// terminator.
+func (f *formatter) lineBounds(line int) (int, int) {
+	start := f.index.LineStart(line)
+	return start, start + len(f.lines[line-1])
+}
+
+func (f *formatter) walk(fn func(n ast.Node) ast.WalkStatus) {
+	ast.Walk(f.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		return fn(n), nil
+	})
+}
+
+// emphasis normalizes emphasis and strong delimiters. Only emphasis that
+// starts and ends with plain text is rewritten, since the delimiter
+// positions are otherwise not known.
+func (f *formatter) emphasis() {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		em, ok := n.(*ast.Emphasis)
+		if !ok {
+			return ast.WalkContinue
+		}
+		marker := f.opts.EmphasisMarker
+		if em.Level == 2 {
+			marker = f.opts.StrongMarker
+		}
+		first, ok1 := em.FirstChild().(*ast.Text)
+		last, ok2 := em.LastChild().(*ast.Text)
+		if !ok1 || !ok2 || len(marker) != em.Level {
+			return ast.WalkContinue
+		}
+
+		open := first.Segment.Start - em.Level
+		close := last.Segment.Stop
+		if open < 0 || close+em.Level > len(f.source) {
+			return ast.WalkContinue
+		}
+		current := string(f.source[open:first.Segment.Start])
+		if current != string(f.source[close:close+em.Level]) || strings.Trim(current, current[:1]) != "" || current[0] != '*' && current[0] != '_' {
+			return ast.WalkContinue
+		}
+		// Underscores do not work inside words, so keep asterisks there.
+		if marker[0] == '_' && (open > 0 && isWordByte(f.source[open-1]) || close+em.Level < len(f.source) && isWordByte(f.source[close+em.Level])) {
+			return ast.WalkContinue
+		}
+		f.replace(open, first.Segment.Start, marker)
+		f.replace(close, close+em.Level, marker)
+		return ast.WalkContinue
+	})
+}
+
+func isWordByte(b byte) bool {
+	return b >= utf8.RuneSelf || b == '_' || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+// listMarkers normalizes bullet list markers. A list next to another
+// bullet list is left alone, since only the change of marker keeps the two
+// lists apart.
+func (f *formatter) listMarkers() {
+	if len(f.opts.ListMarker) != 1 {
+		return
+	}
+	marker := f.opts.ListMarker[0]
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		list, ok := n.(*ast.List)
+		if !ok || list.IsOrdered() || list.Marker == marker || isBulletList(list.PreviousSibling()) || isBulletList(list.NextSibling()) {
+			return ast.WalkContinue
+		}
+		for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+			child := item.FirstChild()
+			if child == nil || child.Lines().Len() == 0 || child.Kind() == ast.KindFencedCodeBlock || child.Kind() == ast.KindCodeBlock {
+				continue
+			}
+			contentStart := child.Lines().At(0).Start
+			lineStart := f.index.LineStart(f.index.Position(contentStart).Line)
+			pos := strings.LastIndexByte(string(f.source[lineStart:contentStart]), list.Marker)
+			if pos < 0 {
+				continue
+			}
+			f.replace(lineStart+pos, lineStart+pos+1, string(marker))
+		}
+		return ast.WalkContinue
+	})
+}
+
+func isBulletList(n ast.Node) bool {
+	list, ok := n.(*ast.List)
+	return ok && !list.IsOrdered()
+}
+
+func (f *formatter) codeFences() {
+	if f.opts.CodeFence == "" {
+		return
+	}
+	char := f.opts.CodeFence[:1]
+	for _, block := range scanFences(f.lines) {
+		if block.Marker[:1] == char || block.Close < 0 {
+			continue
+		}
+		// The new fence must be longer than any run of its character that
+		// starts a line inside the block.
+		length := 3
+		for i := block.Open + 1; i < block.Close; i++ {
+			trimmed := strings.TrimLeft(f.lines[i], " ")
+			run := len(trimmed) - len(strings.TrimLeft(trimmed, char))
+			length = max(length, run+1)
+		}
+		if char == "`" && strings.Contains(block.Info, "`") {
+			continue
+		}
+		fence := strings.Repeat(char, length)
+
+		start, stop := f.lineBounds(block.Open + 1)
+		open := block.Indent + fence
+		if block.Info != "" {
+			open += block.Info
+		}
+		f.replace(start, stop, open)
+
+		start, stop = f.lineBounds(block.Close + 1)
+		closeIndent := f.lines[block.Close][:len(f.lines[block.Close])-len(strings.TrimLeft(f.lines[block.Close], " "))]
+		f.replace(start, stop, closeIndent+fence)
+	}
+}
+
+// headings rewrites setext headings and untidy ATX headings as
+// "## Heading", dropping optional closing hashes.
+func (f *formatter) headings() {
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue
+		}
+		lines := h.Lines()
+		if lines.Len() == 0 {
+			return ast.WalkSkipChildren
+		}
+
+		var parts []string
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			parts = append(parts, strings.TrimSpace(string(seg.Value(f.source))))
+		}
+		heading := strings.Repeat("#", h.Level) + " " + strings.Join(parts, " ")
+
+		first := lines.At(0)
+		firstLine := f.index.Position(first.Start).Line
+		lineStart, lineStop := f.lineBounds(firstLine)
+		if lines.Len() == 1 && strings.Contains(string(f.source[lineStart:first.Start]), "#") {
+			hash := lineStart + strings.IndexByte(string(f.source[lineStart:first.Start]), '#')
+			f.replace(hash, lineStop, heading)
+			return ast.WalkSkipChildren
+		}
+
+		// Setext: replace the text lines and the underline below them.
+		lastLine := f.index.Position(lines.At(lines.Len() - 1).Start).Line
+		if lastLine >= len(f.lines) {
+			return ast.WalkSkipChildren
+		}
+		_, underlineStop := f.lineBounds(lastLine + 1)
+		f.replace(first.Start, underlineStop, heading)
+		return ast.WalkSkipChildren
+	})
+}
+
+var containerPrefixRegex = regexp.MustCompile(`^(?:[ \t]*>)*[ \t]*`)
+
+func (f *formatter) tables() {
+	if !f.opts.AlignTables {
+		return
+	}
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		table, ok := n.(*east.Table)
+		if !ok {
+			return ast.WalkContinue
+		}
+
+		t, err := readTable(table, f.source, f.index, f.lines)
+		if err != nil {
+			return ast.WalkSkipChildren
+		}
+
+		start, _ := f.lineBounds(t.firstLine)
+		_, stop := f.lineBounds(t.lastLine)
+		f.replace(start, stop, renderTable(t.prefix, t.alignments, t.rows))
+		return ast.WalkSkipChildren
+	})
+}
+
+// hasExtraCells reports whether a row has more cells than the delimiter
+// row. Goldmark drops those cells, so reformatting would lose their text.
+func hasExtraCells(table *east.Table, source []byte) bool {
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var last ast.Node
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			if cell.Lines().Len() > 0 {
+				last = cell
+			}
+		}
+		if last == nil {
+			continue
+		}
+		stop := last.Lines().At(0).Stop
+		end := stop
+		for end < len(source) && source[end] != '\n' {
+			end++
+		}
+		if strings.Trim(string(source[stop:end]), " \t|\r") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func renderTable(prefix string, alignments []east.Alignment, rows [][]string) string {
+	widths := make([]int, len(alignments))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], DisplayWidth(cell))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	writeRow := func(cells []string) {
+		sb.WriteString(prefix)
+		sb.WriteString("|")
+		for i, width := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			sb.WriteString(" ")
+			sb.WriteString(padCell(cell, width, alignments[i]))
+			sb.WriteString(" |")
+		}
+	}
+
+	for r, row := range rows {
+		if r > 0 {
+			sb.WriteString("\n")
+		}
+		writeRow(row)
+		if r == 0 {
+			sb.WriteString("\n")
+			sb.WriteString(prefix)
+			sb.WriteString("|")
+			for i, width := range widths {
+				sb.WriteString(" ")
+				sb.WriteString(delimiterCell(width, alignments[i]))
+				sb.WriteString(" |")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func padCell(cell string, width int, align east.Alignment) string {
+	pad := width - DisplayWidth(cell)
+	switch align {
+	case east.AlignRight:
+		return strings.Repeat(" ", pad) + cell
+	case east.AlignCenter:
+		left := pad / 2
+		return strings.Repeat(" ", left) + cell + strings.Repeat(" ", pad-left)
+	default:
+		return cell + strings.Repeat(" ", pad)
+	}
+}
+
+func delimiterCell(width int, align east.Alignment) string {
+	switch align {
+	case east.AlignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case east.AlignRight:
+		return strings.Repeat("-", width-1) + ":"
+	case east.AlignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	default:
+		return strings.Repeat("-", width)
+	}
+}
+
+// DisplayWidth approximates the column width of s, counting CJK and other
+// wide characters as two columns.
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if isCJK(r) || unicode.Is(unicode.Hangul, r) || r >= 0xFF01 && r <= 0xFF60 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// paragraphs wraps or unwraps paragraph text according to ProseWrap.
+func (f *formatter) paragraphs() {
+	if f.opts.ProseWrap != ProseWrapAlways && f.opts.ProseWrap != ProseWrapNever {
+		return
+	}
+	f.walk(func(n ast.Node) ast.WalkStatus {
+		if n.Kind() != ast.KindParagraph && n.Kind() != ast.KindTextBlock {
+			return ast.WalkContinue
+		}
+		lines := n.Lines()
+		if lines.Len() == 0 {
+			return ast.WalkSkipChildren
+		}
+
+		first := lines.At(0)
+		lineStart := f.index.LineStart(f.index.Position(first.Start).Line)
+		prefix := continuationPrefix(string(f.source[lineStart:first.Start]))
+
+		// Hard line breaks split the paragraph into groups that are
+		// wrapped separately.
+		var groups [][]string
+		var current []string
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			raw := strings.TrimRight(string(seg.Value(f.source)), "\r\n")
+			hardBreak := i < lines.Len()-1 && (strings.HasSuffix(raw, "  ") || strings.HasSuffix(raw, "\\"))
+			current = append(current, raw)
+			if hardBreak {
+				groups = append(groups, current)
+				current = nil
+			}
+		}
+		if current != nil {
+			groups = append(groups, current)
+		}
+
+		var out []string
+		firstWidth := f.opts.LineWidth - DisplayWidth(string(f.source[lineStart:first.Start]))
+		for g, group := range groups {
+			hardBreak := ""
+			lastLine := group[len(group)-1]
+			if g < len(groups)-1 {
+				if strings.HasSuffix(lastLine, "\\") {
+					hardBreak = "\\"
+				} else {
+					hardBreak = "  "
+				}
+				group[len(group)-1] = strings.TrimSuffix(strings.TrimRight(lastLine, " "), "\\")
+			}
+			words := proseTokens(strings.Join(group, "\n"))
+			var wrapped []string
+			if f.opts.ProseWrap == ProseWrapNever || f.opts.LineWidth <= 0 {
+				wrapped = []string{strings.Join(words, " ")}
+			} else {
+				width := f.opts.LineWidth - DisplayWidth(prefix)
+				if g == 0 {
+					width = firstWidth
+				}
+				wrapped = wrapTokens(words, width, f.opts.LineWidth-DisplayWidth(prefix))
+			}
+			wrapped[len(wrapped)-1] += hardBreak
+			out = append(out, wrapped...)
+		}
+
+		last := lines.At(lines.Len() - 1)
+		stop := last.Stop
+		for stop > first.Start && (f.source[stop-1] == '\n' || f.source[stop-1] == '\r') {
+			stop--
+		}
+		f.replace(first.Start, stop, strings.Join(out, "\n"+prefix))
+		return ast.WalkSkipChildren
+	})
+}
+
+// continuationPrefix turns the prefix of a paragraph's first line into the
+// prefix for its following lines: blockquote markers are kept and list
+// markers become spaces.
+func continuationPrefix(prefix string) string {
+	var sb strings.Builder
+	for _, r := range prefix {
+		if r == '>' || r == ' ' || r == '\t' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteString(strings.Repeat(" ", utf8.RuneLen(r)))
+		}
+	}
+	return sb.String()
+}
+
+// proseTokens splits paragraph text on whitespace while keeping inline code
+// spans whole, because whitespace inside them is significant.
+func proseTokens(text string) []string {
+	var tokens []string
+	var current strings.Builder
+	fence := ""
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c == '`' {
+			run := 1
+			for i+run < len(text) && text[i+run] == '`' {
+				run++
+			}
+			ticks := text[i : i+run]
+			switch {
+			case fence == ticks:
+				fence = ""
+			case fence == "" && strings.Contains(text[i+run:], ticks):
+				fence = ticks
+			}
+			current.WriteString(ticks)
+			i += run
+			continue
+		}
+		if fence == "" && (c == ' ' || c == '\t' || c == '\n' || c == '\r') {
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			i++
+			continue
+		}
+		if c == '\n' {
+			c = ' '
+		}
+		current.WriteByte(c)
+		i++
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+var blockStartRegex = regexp.MustCompile("^(?:[-+*]|#{1,6}|\\d{1,9}[.)]|=+|-+|\\*{3,}|_{3,}|>.*|<.*|```.*|~~~.*|\\|.*)$")
+
+// wrapTokens greedily fills lines up to width. It never starts a line with
+// a token that would begin a new block, such as "-" or "#".
+func wrapTokens(tokens []string, firstWidth, width int) []string {
+	var lines []string
+	var line strings.Builder
+	limit := firstWidth
+	for _, token := range tokens {
+		if line.Len() > 0 && DisplayWidth(line.String())+1+DisplayWidth(token) > limit && !blockStartRegex.MatchString(token) {
+			lines = append(lines, line.String())
+			line.Reset()
+			limit = width
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(token)
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+var atxHeadingRegex = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]|$)`)
+
+// verbatimLines marks the lines whose whitespace is content: fenced and
+// indented code, HTML blocks and math blocks.
+func (r *Renderer) verbatimLines(source []byte) []bool {
+	lines := splitLines(source)
+	verbatim := codeLines(lines, scanFences(lines))
+	index := NewLineIndex(source)
+	ast.Walk(r.Parse(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindCodeBlock, ast.KindFencedCodeBlock, ast.KindHTMLBlock, KindMathBlock:
+		default:
+			return ast.WalkContinue, nil
+		}
+		segments := n.Lines()
+		if segments.Len() == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		first := index.Position(segments.At(0).Start).Line
+		last := index.Position(segments.At(segments.Len() - 1).Start).Line
+		if html, ok := n.(*ast.HTMLBlock); ok && html.HasClosure() {
+			last = index.Position(html.ClosureLine.Start).Line
+		}
+		for line := first; line <= last; line++ {
+			verbatim[line-1] = true
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return verbatim
+}
+
+// formatWhitespace fixes blank lines and trailing whitespace outside the
+// verbatim lines: trailing spaces are removed unless they form a hard line
+// break, runs of blank lines collapse to one, headings get a blank line on
+// each side, and the file ends with a single newline.
+func formatWhitespace(source []byte, inCode []bool) []byte {
+	lines := splitLines(source)
+	blank := func(i int) bool {
+		return i >= 0 && i < len(lines) && strings.TrimSpace(lines[i]) == "" && !inCode[i]
+	}
+
+	var out []string
+	for i, line := range lines {
+		if inCode[i] {
+			out = append(out, line)
+			continue
+		}
+
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != "" && len(line)-len(trimmed) >= 2 && strings.HasSuffix(line, "  ") && i+1 < len(lines) && !blank(i+1) && !atxHeadingRegex.MatchString(line) {
+			trimmed += "  "
+		}
+
+		if trimmed == "" {
+			if len(out) == 0 || out[len(out)-1] == "" {
+				continue
+			}
+			out = append(out, "")
+			continue
+		}
+
+		heading := atxHeadingRegex.MatchString(trimmed)
+		if heading && len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+		out = append(out, trimmed)
+		if heading && i+1 < len(lines) && !blank(i+1) {
+			out = append(out, "")
+		}
+	}
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return []byte{}
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
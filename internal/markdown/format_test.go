@@ -0,0 +1,51 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestFormatKeepsRendering checks that formatting never changes what a
+// document renders to, since format-on-save rewrites files with it.
+func TestFormatKeepsRendering(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"indented code blank lines", "    code line 1\n\n\n    code line 2\n"},
+		{"indented code trailing spaces", "Text\n\n    code  \n    more\t\n"},
+		{"fenced code", "```go\nfunc main() {  \n\n\n}\n```\n"},
+		{"fenced code in blockquote", "> ```\n> a  \n>\n>\n> b\n> ```\n"},
+		{"fenced code in list", "- item\n\n  ```\n  a\n\n\n  b  \n  ```\n"},
+		{"html comment", "text\n<!-- comment\n\n\n-->\n"},
+		{"html block", "<div>\n  cell  \n</div>\n"},
+		{"html pre", "<pre>\nline  \n\n\nline\n</pre>\n"},
+		{"math block", "$$\na = b  \n\n\nc = d\n$$\n"},
+		{"math block leading blank lines", "$$\n\n\nx\n$$\n"},
+		{"front matter", "---\ntitle: Report\nauthor: Me\n---\n\n* item\n"},
+		{"sibling lists", "* a\n* b\n\n+ c\n"},
+		{"sibling lists with the target marker", "* a\n\n- b\n\n+ c\n"},
+	}
+
+	r := NewRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := r.Render(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted := r.Format(tt.content, DefaultFormatOptions()).Text
+			_, body := SplitFrontMatter([]byte(tt.content))
+			if frontMatter := tt.content[:len(tt.content)-len(body)]; !strings.HasPrefix(formatted, frontMatter) {
+				t.Errorf("Format(%q) = %q, want front matter %q kept", tt.content, formatted, frontMatter)
+			}
+			after, err := r.Render(formatted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if before != after {
+				t.Errorf("Format(%q) = %q\nrendered before: %q\nrendered after:  %q", tt.content, formatted, before, after)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+)
+
+// FrontMatter is the metadata block at the top of a document, between
+// "---" lines. Only the flat subset of YAML that notes use is understood:
+// "key: value" pairs and lists written as "- item" lines or "[a, b]".
+type FrontMatter struct {
+	Title   string
+	Authors []string
+	Date    string
+	// Fields holds every key, lower-cased, with list items joined by ", ".
+	Fields map[string]string
+}
+
+// SplitFrontMatter separates front matter from the document body. Without
+// front matter, it returns an empty FrontMatter and source unchanged.
+func SplitFrontMatter(source []byte) (FrontMatter, []byte) {
+	fm := FrontMatter{Fields: make(map[string]string)}
+	rest, ok := bytes.CutPrefix(source, []byte("---\n"))
+	if !ok {
+		if rest, ok = bytes.CutPrefix(source, []byte("---\r\n")); !ok {
+			return fm, source
+		}
+	}
+
+	lists := make(map[string][]string)
+	var key string
+	for len(rest) > 0 {
+		line := rest
+		next := []byte(nil)
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, next = rest[:i], rest[i+1:]
+		}
+		text := strings.TrimRight(string(line), " \t\r")
+		rest = next
+
+		if text == "---" || text == "..." {
+			for k, items := range lists {
+				fm.Fields[k] = strings.Join(items, ", ")
+			}
+			fm.Title = fm.Fields["title"]
+			fm.Date = fm.Fields["date"]
+			fm.Authors = lists["author"]
+			if fm.Authors == nil {
+				fm.Authors = lists["authors"]
+			}
+			return fm, rest
+		}
+
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "- ") && key != "":
+			lists[key] = append(lists[key], unquote(trimmed[2:]))
+		case !strings.HasPrefix(text, " ") && strings.Contains(text, ":"):
+			k, v, _ := strings.Cut(text, ":")
+			key = strings.ToLower(strings.TrimSpace(k))
+			v = strings.TrimSpace(v)
+			if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+				for _, item := range strings.Split(v[1:len(v)-1], ",") {
+					if item = unquote(item); item != "" {
+						lists[key] = append(lists[key], item)
+					}
+				}
+			} else if v != "" {
+				lists[key] = []string{unquote(v)}
+			}
+		case strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t"):
+			// Nested values are beyond the subset, but still YAML.
+		default:
+			// Prose between two rules, not front matter.
+			return FrontMatter{Fields: make(map[string]string)}, source
+		}
+	}
+	// No closing line: this was a thematic break, not front matter.
+	return FrontMatter{Fields: make(map[string]string)}, source
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
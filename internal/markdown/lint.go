@@ -0,0 +1,291 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// LintConfigFile is the per-workspace configuration file. It uses the
+// markdownlint format, so existing configurations keep working.
+const LintConfigFile = ".markdownlint.json"
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+type Diagnostic struct {
+	RuleID    string   `json:"ruleId"`
+	RuleAlias string   `json:"ruleAlias"`
+	Message   string   `json:"message"`
+	Severity  Severity `json:"severity"`
+	Range     Range    `json:"range"`
+}
+
+// RuleConfig is the resolved configuration of a single rule. Params holds
+// the markdownlint rule options, such as "line_length" for MD013.
+type RuleConfig struct {
+	Enabled  bool                   `json:"enabled"`
+	Severity Severity               `json:"severity"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// LintConfig mirrors a .markdownlint.json file. Rules are keyed by ID or
+// alias and may be set to a boolean or an object of parameters; "severity"
+// is accepted as an extra parameter.
+type LintConfig struct {
+	Default bool                  `json:"default"`
+	Rules   map[string]RuleConfig `json:"rules"`
+}
+
+func DefaultLintConfig() LintConfig {
+	return LintConfig{
+		Default: true,
+		Rules:   make(map[string]RuleConfig),
+	}
+}
+
+func (c *LintConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = DefaultLintConfig()
+	for key, value := range raw {
+		if key == "default" {
+			if err := json.Unmarshal(value, &c.Default); err != nil {
+				return fmt.Errorf("lint config: default: %w", err)
+			}
+			continue
+		}
+		if key == "$schema" || key == "extends" {
+			continue
+		}
+
+		var enabled bool
+		if err := json.Unmarshal(value, &enabled); err == nil {
+			c.Rules[strings.ToLower(key)] = RuleConfig{Enabled: enabled}
+			continue
+		}
+
+		var params map[string]interface{}
+		if err := json.Unmarshal(value, &params); err != nil {
+			return fmt.Errorf("lint config: %s: expected boolean or object", key)
+		}
+		rule := RuleConfig{Enabled: true, Params: params}
+		if sev, ok := params["severity"].(string); ok {
+			rule.Severity = Severity(sev)
+			delete(params, "severity")
+		}
+		if en, ok := params["enabled"].(bool); ok {
+			rule.Enabled = en
+			delete(params, "enabled")
+		}
+		c.Rules[strings.ToLower(key)] = rule
+	}
+	return nil
+}
+
+func LoadLintConfig(path string) (LintConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DefaultLintConfig(), err
+	}
+	var cfg LintConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultLintConfig(), err
+	}
+	return cfg, nil
+}
+
+// FindLintConfig looks for a LintConfigFile in the document's directory and
+// its parents. It returns the default configuration and an empty path when
+// none is found.
+func FindLintConfig(documentPath string) (LintConfig, string, error) {
+	if documentPath == "" {
+		return DefaultLintConfig(), "", nil
+	}
+	dir, err := filepath.Abs(filepath.Dir(documentPath))
+	if err != nil {
+		return DefaultLintConfig(), "", err
+	}
+	for {
+		candidate := filepath.Join(dir, LintConfigFile)
+		if _, err := os.Stat(candidate); err == nil {
+			cfg, err := LoadLintConfig(candidate)
+			return cfg, candidate, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return DefaultLintConfig(), "", nil
+		}
+		dir = parent
+	}
+}
+
+// resolve returns the configuration for rule, looking it up by ID and then
+// by alias.
+func (c LintConfig) resolve(rule *LintRule) RuleConfig {
+	resolved := RuleConfig{Enabled: c.Default, Severity: rule.Severity}
+	for _, key := range []string{strings.ToLower(rule.ID), rule.Alias} {
+		if rc, ok := c.Rules[key]; ok {
+			resolved.Enabled = rc.Enabled
+			resolved.Params = rc.Params
+			if rc.Severity != "" {
+				resolved.Severity = rc.Severity
+			}
+			break
+		}
+	}
+	return resolved
+}
+
+// LintRule is a single check. IDs and aliases follow markdownlint so that
+// configurations and inline knowledge carry over.
+type LintRule struct {
+	ID          string
+	Alias       string
+	Description string
+	Severity    Severity
+	Check       func(ctx *lintContext, params ruleParams)
+}
+
+type Linter struct {
+	renderer *Renderer
+	rules    []*LintRule
+}
+
+func NewLinter(renderer *Renderer) *Linter {
+	return &Linter{
+		renderer: renderer,
+		rules:    defaultLintRules(),
+	}
+}
+
+func (l *Linter) Rules() []*LintRule {
+	return l.rules
+}
+
+// Lint runs every enabled rule over content and returns the diagnostics
+// ordered by position.
+func (l *Linter) Lint(content string, cfg LintConfig) []Diagnostic {
+	source := []byte(content)
+	ctx := newLintContext(source, l.renderer.Parse(source))
+
+	for _, rule := range l.rules {
+		rc := cfg.resolve(rule)
+		if !rc.Enabled {
+			continue
+		}
+		ctx.rule = rule
+		ctx.severity = rc.Severity
+		rule.Check(ctx, ruleParams(rc.Params))
+	}
+
+	sort.SliceStable(ctx.diagnostics, func(i, j int) bool {
+		a, b := ctx.diagnostics[i].Range.Start, ctx.diagnostics[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return ctx.diagnostics
+}
+
+type lintContext struct {
+	source []byte
+	doc    ast.Node
+	index  *LineIndex
+	// lines holds the source split on newlines without line terminators.
+	lines []string
+	// inCode marks lines that belong to a fenced code block, fences included.
+	inCode []bool
+	fences []fenceBlock
+
+	rule        *LintRule
+	severity    Severity
+	diagnostics []Diagnostic
+}
+
+func newLintContext(source []byte, doc ast.Node) *lintContext {
+	lines := splitLines(source)
+	fences := scanFences(lines)
+	return &lintContext{
+		source:      source,
+		doc:         doc,
+		index:       NewLineIndex(source),
+		lines:       lines,
+		inCode:      codeLines(lines, fences),
+		fences:      fences,
+		diagnostics: make([]Diagnostic, 0),
+	}
+}
+
+func (ctx *lintContext) report(rng Range, format string, args ...interface{}) {
+	ctx.diagnostics = append(ctx.diagnostics, Diagnostic{
+		RuleID:    ctx.rule.ID,
+		RuleAlias: ctx.rule.Alias,
+		Message:   fmt.Sprintf(format, args...),
+		Severity:  ctx.severity,
+		Range:     rng,
+	})
+}
+
+// lineRange returns the range of a 1-based line, or of the columns
+// [startCol, endCol) within it when endCol is positive.
+func (ctx *lintContext) lineRange(line, startCol, endCol int) Range {
+	start := ctx.index.LineStart(line)
+	text := ctx.lines[line-1]
+	if endCol <= 0 {
+		return ctx.index.Range(start, start+len(text))
+	}
+	runes := []rune(text)
+	startCol = max(1, min(startCol, len(runes)+1))
+	endCol = max(startCol, min(endCol, len(runes)+1))
+	return ctx.index.Range(
+		start+len(string(runes[:startCol-1])),
+		start+len(string(runes[:endCol-1])),
+	)
+}
+
+// nodeLine returns the 1-based line on which a block node starts.
+func (ctx *lintContext) nodeLine(n ast.Node) (int, bool) {
+	rng, ok := ctx.index.BlockRange(n)
+	if !ok {
+		return 0, false
+	}
+	return rng.Start.Line, true
+}
+
+type ruleParams map[string]interface{}
+
+func (p ruleParams) int(key string, def int) int {
+	if v, ok := p[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+func (p ruleParams) bool(key string, def bool) bool {
+	if v, ok := p[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+func (p ruleParams) string(key string, def string) string {
+	if v, ok := p[key].(string); ok {
+		return v
+	}
+	return def
+}
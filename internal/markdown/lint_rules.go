@@ -0,0 +1,390 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+func defaultLintRules() []*LintRule {
+	return []*LintRule{
+		{ID: "MD001", Alias: "heading-increment", Description: "Heading levels should only increment by one level at a time", Severity: SeverityWarning, Check: checkHeadingIncrement},
+		{ID: "MD003", Alias: "heading-style", Description: "Heading style", Severity: SeverityWarning, Check: checkHeadingStyle},
+		{ID: "MD004", Alias: "ul-style", Description: "Unordered list style", Severity: SeverityWarning, Check: checkListStyle},
+		{ID: "MD009", Alias: "no-trailing-spaces", Description: "Trailing spaces", Severity: SeverityWarning, Check: checkTrailingSpaces},
+		{ID: "MD010", Alias: "no-hard-tabs", Description: "Hard tabs", Severity: SeverityWarning, Check: checkHardTabs},
+		{ID: "MD012", Alias: "no-multiple-blanks", Description: "Multiple consecutive blank lines", Severity: SeverityWarning, Check: checkMultipleBlanks},
+		{ID: "MD013", Alias: "line-length", Description: "Line length", Severity: SeverityInfo, Check: checkLineLength},
+		{ID: "MD018", Alias: "no-missing-space-atx", Description: "No space after hash on atx style heading", Severity: SeverityError, Check: checkMissingSpaceATX},
+		{ID: "MD022", Alias: "blanks-around-headings", Description: "Headings should be surrounded by blank lines", Severity: SeverityWarning, Check: checkBlanksAroundHeadings},
+		{ID: "MD024", Alias: "no-duplicate-heading", Description: "Multiple headings with the same content", Severity: SeverityWarning, Check: checkDuplicateHeadings},
+		{ID: "MD025", Alias: "single-title", Description: "Multiple top-level headings in the same document", Severity: SeverityWarning, Check: checkSingleTitle},
+		{ID: "MD026", Alias: "no-trailing-punctuation", Description: "Trailing punctuation in heading", Severity: SeverityWarning, Check: checkHeadingPunctuation},
+		{ID: "MD034", Alias: "no-bare-urls", Description: "Bare URL used", Severity: SeverityWarning, Check: checkBareURLs},
+		{ID: "MD040", Alias: "fenced-code-language", Description: "Fenced code blocks should have a language specified", Severity: SeverityWarning, Check: checkFenceLanguage},
+		{ID: "MD045", Alias: "no-alt-text", Description: "Images should have alternate text (alt text)", Severity: SeverityError, Check: checkAltText},
+		{ID: "MD047", Alias: "single-trailing-newline", Description: "Files should end with a single newline character", Severity: SeverityWarning, Check: checkTrailingNewline},
+	}
+}
+
+// walkHeadings calls fn for every heading with its start line.
+func (ctx *lintContext) walkHeadings(fn func(h *ast.Heading, line int)) {
+	ast.Walk(ctx.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if h, ok := n.(*ast.Heading); ok {
+			if line, ok := ctx.nodeLine(h); ok {
+				fn(h, line)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// isSetext reports whether h is underlined rather than opened by hashes.
+// Only an ATX heading has a "#" between its container prefix (blockquote
+// markers, list markers and indentation) and its text.
+func (ctx *lintContext) isSetext(h *ast.Heading) bool {
+	start := h.Lines().At(0).Start
+	lineStart := ctx.index.LineStart(ctx.index.Position(start).Line)
+	return !strings.HasSuffix(strings.TrimRight(string(ctx.source[lineStart:start]), " \t"), "#")
+}
+
+func checkHeadingIncrement(ctx *lintContext, params ruleParams) {
+	prev := 0
+	ctx.walkHeadings(func(h *ast.Heading, line int) {
+		if prev > 0 && h.Level > prev+1 {
+			ctx.report(ctx.lineRange(line, 1, 0), "Expected: h%d; Actual: h%d", prev+1, h.Level)
+		}
+		prev = h.Level
+	})
+}
+
+func checkHeadingStyle(ctx *lintContext, params ruleParams) {
+	style := params.string("style", "consistent")
+	ctx.walkHeadings(func(h *ast.Heading, line int) {
+		actual := "atx"
+		if ctx.isSetext(h) {
+			actual = "setext"
+		}
+		if style == "consistent" {
+			style = actual
+		}
+		if actual != style && !(style == "setext_with_atx" && (actual == "setext" || h.Level > 2)) {
+			ctx.report(ctx.lineRange(line, 1, 0), "Expected: %s; Actual: %s", style, actual)
+		}
+	})
+}
+
+func checkListStyle(ctx *lintContext, params ruleParams) {
+	markers := map[string]byte{"asterisk": '*', "dash": '-', "plus": '+'}
+	names := map[byte]string{'*': "asterisk", '-': "dash", '+': "plus"}
+	style := params.string("style", "consistent")
+	var expected byte
+	if m, ok := markers[style]; ok {
+		expected = m
+	}
+
+	ast.Walk(ctx.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		list, ok := n.(*ast.List)
+		if !entering || !ok || list.IsOrdered() {
+			return ast.WalkContinue, nil
+		}
+		if expected == 0 {
+			expected = list.Marker
+		}
+		if list.Marker == expected {
+			return ast.WalkContinue, nil
+		}
+		for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+			if item.FirstChild() == nil {
+				continue
+			}
+			if line, ok := ctx.nodeLine(item.FirstChild()); ok {
+				col := strings.IndexByte(ctx.lines[line-1], list.Marker) + 1
+				ctx.report(ctx.lineRange(line, col, col+1), "Expected: %s; Actual: %s", names[expected], names[list.Marker])
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func checkTrailingSpaces(ctx *lintContext, params ruleParams) {
+	brSpaces := params.int("br_spaces", 2)
+	for i, line := range ctx.lines {
+		trimmed := strings.TrimRight(line, " ")
+		spaces := len(line) - len(trimmed)
+		if spaces == 0 || spaces == brSpaces && trimmed != "" {
+			continue
+		}
+		col := utf8.RuneCountInString(trimmed) + 1
+		ctx.report(ctx.lineRange(i+1, col, col+spaces), "Expected: 0 or %d; Actual: %d", brSpaces, spaces)
+	}
+}
+
+func checkHardTabs(ctx *lintContext, params ruleParams) {
+	codeBlocks := params.bool("code_blocks", true)
+	for i, line := range ctx.lines {
+		if ctx.inCode[i] && !codeBlocks {
+			continue
+		}
+		if idx := strings.IndexByte(line, '\t'); idx >= 0 {
+			col := utf8.RuneCountInString(line[:idx]) + 1
+			ctx.report(ctx.lineRange(i+1, col, col+1), "Column: %d", col)
+		}
+	}
+}
+
+func checkMultipleBlanks(ctx *lintContext, params ruleParams) {
+	maximum := params.int("maximum", 1)
+	blanks := 0
+	for i, line := range ctx.lines {
+		if strings.TrimSpace(line) != "" || ctx.inCode[i] {
+			blanks = 0
+			continue
+		}
+		blanks++
+		// The empty string after a final newline is not a blank line.
+		if blanks > maximum && i < len(ctx.lines)-1 {
+			ctx.report(ctx.lineRange(i+1, 1, 0), "Expected: %d; Actual: %d", maximum, blanks)
+		}
+	}
+}
+
+func checkLineLength(ctx *lintContext, params ruleParams) {
+	limit := params.int("line_length", 80)
+	headingLimit := params.int("heading_line_length", limit)
+	codeLimit := params.int("code_block_line_length", limit)
+	checkCode := params.bool("code_blocks", true)
+	checkTables := params.bool("tables", true)
+	checkHeadings := params.bool("headings", true)
+	strict := params.bool("strict", false)
+
+	for i, line := range ctx.lines {
+		maxLen := limit
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case ctx.inCode[i]:
+			if !checkCode {
+				continue
+			}
+			maxLen = codeLimit
+		case strings.HasPrefix(trimmed, "#"):
+			if !checkHeadings {
+				continue
+			}
+			maxLen = headingLimit
+		case strings.HasPrefix(trimmed, "|"):
+			if !checkTables {
+				continue
+			}
+		}
+
+		runes := []rune(line)
+		if len(runes) <= maxLen {
+			continue
+		}
+		// Like markdownlint, allow long lines whose overflow has no
+		// whitespace, such as a long URL, unless strict is set.
+		if !strict && !strings.ContainsAny(string(runes[maxLen:]), " \t") {
+			continue
+		}
+		ctx.report(ctx.lineRange(i+1, maxLen+1, len(runes)+1), "Expected: %d; Actual: %d", maxLen, len(runes))
+	}
+}
+
+var missingSpaceATXRegex = regexp.MustCompile(`^ {0,3}(#{1,6})[^#\s]`)
+
+func checkMissingSpaceATX(ctx *lintContext, params ruleParams) {
+	for i, line := range ctx.lines {
+		if ctx.inCode[i] {
+			continue
+		}
+		if m := missingSpaceATXRegex.FindStringSubmatchIndex(line); m != nil {
+			ctx.report(ctx.lineRange(i+1, m[2]+1, m[3]+2), "No space after %s", line[m[2]:m[3]])
+		}
+	}
+}
+
+func checkBlanksAroundHeadings(ctx *lintContext, params ruleParams) {
+	above := params.int("lines_above", 1)
+	below := params.int("lines_below", 1)
+	isBlank := func(line int) bool {
+		return line < 1 || line > len(ctx.lines) || strings.TrimSpace(ctx.lines[line-1]) == ""
+	}
+
+	ctx.walkHeadings(func(h *ast.Heading, line int) {
+		last := line
+		if ctx.isSetext(h) {
+			rng, _ := ctx.index.BlockRange(h)
+			last = rng.End.Line + 1
+		}
+		if above > 0 && line > 1 && !isBlank(line-1) {
+			ctx.report(ctx.lineRange(line, 1, 0), "Expected: %d blank line above; Actual: 0", above)
+		}
+		if below > 0 && last < len(ctx.lines) && !isBlank(last+1) {
+			ctx.report(ctx.lineRange(line, 1, 0), "Expected: %d blank line below; Actual: 0", below)
+		}
+	})
+}
+
+func checkDuplicateHeadings(ctx *lintContext, params ruleParams) {
+	siblingsOnly := params.bool("siblings_only", false)
+	seen := make(map[string]int)
+	// parents holds the heading text at each level, for siblings_only.
+	parents := make([]string, 7)
+
+	ctx.walkHeadings(func(h *ast.Heading, line int) {
+		title := strings.TrimSpace(PlainText(h, ctx.source, true))
+		parents[h.Level] = title
+		for l := h.Level + 1; l < len(parents); l++ {
+			parents[l] = ""
+		}
+
+		key := title
+		if siblingsOnly {
+			key = strings.Join(parents[:h.Level], "\x00") + "\x00" + title
+		}
+		if first, ok := seen[key]; ok {
+			ctx.report(ctx.lineRange(line, 1, 0), "Duplicate heading %q, first used on line %d", title, first)
+			return
+		}
+		seen[key] = line
+	})
+}
+
+func checkSingleTitle(ctx *lintContext, params ruleParams) {
+	level := params.int("level", 1)
+	first := 0
+	ctx.walkHeadings(func(h *ast.Heading, line int) {
+		if h.Level != level {
+			return
+		}
+		if first == 0 {
+			first = line
+			return
+		}
+		ctx.report(ctx.lineRange(line, 1, 0), "Multiple h%d headings, first on line %d", level, first)
+	})
+}
+
+func checkHeadingPunctuation(ctx *lintContext, params ruleParams) {
+	punctuation := params.string("punctuation", ".,;:!。，；：！")
+	ctx.walkHeadings(func(h *ast.Heading, line int) {
+		title := strings.TrimSpace(PlainText(h, ctx.source, true))
+		if title == "" {
+			return
+		}
+		last, _ := utf8.DecodeLastRuneInString(title)
+		if strings.ContainsRune(punctuation, last) {
+			ctx.report(ctx.lineRange(line, 1, 0), "Punctuation: '%c'", last)
+		}
+	})
+}
+
+var bareURLRegex = regexp.MustCompile(`https?://[^\s<>\[\]()"'` + "`" + `]+`)
+
+func checkBareURLs(ctx *lintContext, params ruleParams) {
+	for i, line := range ctx.lines {
+		if ctx.inCode[i] {
+			continue
+		}
+		masked := maskCodeSpans(line)
+		for _, m := range bareURLRegex.FindAllStringIndex(masked, -1) {
+			if m[0] > 0 && strings.ContainsRune(`<([="'`, rune(masked[m[0]-1])) {
+				continue
+			}
+			url := strings.TrimRight(line[m[0]:m[1]], ".,;:!?")
+			startCol := utf8.RuneCountInString(line[:m[0]]) + 1
+			ctx.report(ctx.lineRange(i+1, startCol, startCol+utf8.RuneCountInString(url)), "Bare URL: %s", url)
+		}
+	}
+}
+
+// maskCodeSpans replaces the contents of inline code spans with spaces so
+// that line-based checks ignore them while byte offsets stay valid.
+func maskCodeSpans(line string) string {
+	b := []byte(line)
+	for i := 0; i < len(b); {
+		if b[i] != '`' {
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(b) && b[i+run] == '`' {
+			run++
+		}
+		fence := strings.Repeat("`", run)
+		end := strings.Index(string(b[i+run:]), fence)
+		if end < 0 {
+			i += run
+			continue
+		}
+		for j := i; j < i+run+end+run; j++ {
+			b[j] = ' '
+		}
+		i += run + end + run
+	}
+	return string(b)
+}
+
+func checkFenceLanguage(ctx *lintContext, params ruleParams) {
+	for _, f := range ctx.fences {
+		if f.Info == "" {
+			ctx.report(ctx.lineRange(f.Open+1, 1, 0), "Fenced code block has no language")
+		}
+	}
+}
+
+var emptyAltRegex = regexp.MustCompile(`!\[\s*\][(\[]`)
+
+func checkAltText(ctx *lintContext, params ruleParams) {
+	ast.Walk(ctx.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Type() != ast.TypeBlock || n.Lines().Len() == 0 {
+			return ast.WalkContinue, nil
+		}
+		if _, isTable := n.(*east.Table); isTable {
+			return ast.WalkContinue, nil
+		}
+		if !hasEmptyAltImage(n, ctx.source) {
+			return ast.WalkContinue, nil
+		}
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			text := maskCodeSpans(string(seg.Value(ctx.source)))
+			for _, m := range emptyAltRegex.FindAllStringIndex(text, -1) {
+				ctx.report(ctx.index.Range(seg.Start+m[0], seg.Start+m[1]-1), "Image has no alt text")
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+func hasEmptyAltImage(block ast.Node, source []byte) bool {
+	found := false
+	ast.Walk(block, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if img, ok := n.(*ast.Image); ok && entering {
+			if strings.TrimSpace(PlainText(img, source, true)) == "" {
+				found = true
+				return ast.WalkStop, nil
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return found
+}
+
+func checkTrailingNewline(ctx *lintContext, params ruleParams) {
+	if len(ctx.source) == 0 {
+		return
+	}
+	if ctx.source[len(ctx.source)-1] != '\n' {
+		ctx.report(ctx.lineRange(len(ctx.lines), 1, 0), "File should end with a newline")
+	}
+}
@@ -0,0 +1,204 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindMathInline and KindMathBlock are the AST kinds of TeX math, written
+// as $...$ or $$...$$ like in the preview. The parser only keeps math away
+// from Markdown syntax; exports decide how to show it.
+var (
+	KindMathInline = ast.NewNodeKind("MathInline")
+	KindMathBlock  = ast.NewNodeKind("MathBlock")
+)
+
+// MathInline is $...$, or $$...$$ within a line when Display is set.
+type MathInline struct {
+	ast.BaseInline
+	Literal []byte
+	Display bool
+}
+
+func (n *MathInline) Kind() ast.NodeKind { return KindMathInline }
+
+func (n *MathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Literal": string(n.Literal)}, nil)
+}
+
+// MathBlock is display math between $$ lines. Its lines hold the TeX.
+type MathBlock struct {
+	ast.BaseBlock
+	closed bool
+}
+
+func (n *MathBlock) Kind() ast.NodeKind { return KindMathBlock }
+
+func (n *MathBlock) IsRaw() bool { return true }
+
+func (n *MathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// Literal returns the TeX of the block.
+func (n *MathBlock) Literal(source []byte) []byte {
+	var b bytes.Buffer
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		b.Write(line.Value(source))
+	}
+	return bytes.TrimSpace(b.Bytes())
+}
+
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Parse follows Pandoc's rules so prices like "$5 and $10" stay text: the
+// opening $ is not followed by a space, and the closing one is not preceded
+// by a space or followed by a digit.
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	delim := 1
+	if len(line) > 1 && line[1] == '$' {
+		delim = 2
+	}
+	if len(line) <= delim || util.IsSpace(line[delim]) {
+		return nil
+	}
+	for i := delim; i+delim <= len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+			continue
+		case '$':
+		default:
+			continue
+		}
+		if delim == 2 && (i+1 >= len(line) || line[i+1] != '$') {
+			continue
+		}
+		if delim == 1 && (util.IsSpace(line[i-1]) || i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9') {
+			continue
+		}
+		if i == delim {
+			return nil
+		}
+		node := &MathInline{Literal: append([]byte(nil), line[delim:i]...), Display: delim == 2}
+		block.Advance(i + delim)
+		return node
+	}
+	return nil
+}
+
+type mathBlockParser struct{}
+
+func (p *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	node := &MathBlock{}
+	rest := bytes.TrimSpace(line[pos+2:])
+	start := segment.Start + pos + 2
+	if bytes.HasSuffix(rest, []byte("$$")) {
+		// $$ x $$ on a single line.
+		stop := segment.Start + bytes.LastIndex(line, []byte("$$"))
+		if stop > start {
+			node.Lines().Append(text.NewSegment(start, stop))
+		}
+		node.closed = true
+		reader.Advance(segment.Len() - 1)
+		return node, parser.NoChildren
+	}
+	if len(rest) > 0 {
+		// Only a lone $$ opens a block, so inline $$...$$ in a paragraph
+		// is left to the inline parser.
+		return nil, parser.NoChildren
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	if node.(*MathBlock).closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if trimmed := bytes.TrimSpace(line); bytes.HasSuffix(trimmed, []byte("$$")) {
+		if stop := segment.Start + bytes.LastIndex(line, []byte("$$")); stop > segment.Start {
+			node.Lines().Append(text.NewSegment(segment.Start, stop))
+		}
+		reader.Advance(segment.Len())
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+// mathHTMLRenderer writes math back out as escaped TeX with its
+// delimiters, for scripts such as KaTeX's auto-render to pick up.
+type mathHTMLRenderer struct{}
+
+func (r *mathHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMathInline, r.renderInline)
+	reg.Register(KindMathBlock, r.renderBlock)
+}
+
+func (r *mathHTMLRenderer) renderInline(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*MathInline)
+	delim := "$"
+	if n.Display {
+		delim = "$$"
+	}
+	w.WriteString(`<span class="math">` + delim)
+	w.Write(util.EscapeHTML(n.Literal))
+	w.WriteString(delim + `</span>`)
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathHTMLRenderer) renderBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	w.WriteString("<div class=\"math\">$$\n")
+	w.Write(util.EscapeHTML(node.(*MathBlock).Literal(source)))
+	w.WriteString("\n$$</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+type mathExtension struct{}
+
+// Math parses $...$ and $$...$$ as TeX.
+var Math goldmark.Extender = &mathExtension{}
+
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 150)),
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&mathHTMLRenderer{}, 500)))
+}
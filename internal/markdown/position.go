@@ -0,0 +1,82 @@
+package markdown
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Position is a location in the source document. Line and Column are
+// 1-based and Column counts characters rather than bytes; Offset is the
+// 0-based byte offset.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// LineIndex converts byte offsets into line and column positions.
+type LineIndex struct {
+	source []byte
+	starts []int
+}
+
+func NewLineIndex(source []byte) *LineIndex {
+	starts := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &LineIndex{source: source, starts: starts}
+}
+
+func (li *LineIndex) Position(offset int) Position {
+	offset = max(0, min(offset, len(li.source)))
+	line := sort.Search(len(li.starts), func(i int) bool {
+		return li.starts[i] > offset
+	}) - 1
+	column := utf8.RuneCount(li.source[li.starts[line]:offset]) + 1
+	return Position{Line: line + 1, Column: column, Offset: offset}
+}
+
+func (li *LineIndex) Range(start, stop int) Range {
+	return Range{Start: li.Position(start), End: li.Position(stop)}
+}
+
+// LineStart returns the byte offset at which the 1-based line begins.
+func (li *LineIndex) LineStart(line int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(li.starts) {
+		return len(li.source)
+	}
+	return li.starts[line-1]
+}
+
+// LineCount returns the number of lines in the source.
+func (li *LineIndex) LineCount() int {
+	return len(li.starts)
+}
+
+// BlockRange returns the source range covered by the lines of a block node.
+// It reports false for nodes that carry no source lines.
+func (li *LineIndex) BlockRange(n ast.Node) (Range, bool) {
+	lines := n.Lines()
+	if lines == nil || lines.Len() == 0 {
+		return Range{}, false
+	}
+	start := lines.At(0).Start
+	stop := lines.At(lines.Len() - 1).Stop
+	for stop > start && (li.source[stop-1] == '\n' || li.source[stop-1] == '\r') {
+		stop--
+	}
+	return li.Range(start, stop), true
+}
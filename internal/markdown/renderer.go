@@ -8,9 +8,11 @@ import (
 
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 )
 
 type Renderer struct {
@@ -23,13 +25,6 @@ type TOCItem struct {
 	ID    string `json:"id"`
 }
 
-type Stats struct {
-	Words      int `json:"words"`
-	Characters int `json:"characters"`
-	Lines      int `json:"lines"`
-	Paragraphs int `json:"paragraphs"`
-}
-
 type SearchResult struct {
 	Line       int    `json:"line"`
 	Column     int    `json:"column"`
@@ -44,6 +39,7 @@ func NewRenderer() *Renderer {
 			extension.GFM,
 			extension.Typographer,
 			extension.Footnote,
+			Math,
 			highlighting.NewHighlighting(
 				highlighting.WithStyle("monokai"),
 				highlighting.WithFormatOptions(),
@@ -70,6 +66,52 @@ func (r *Renderer) Render(content string) (string, error) {
 	return buf.String(), nil
 }
 
+// RenderRewritingImages renders content after passing every image
+// destination through rewrite.
+func (r *Renderer) RenderRewritingImages(content string, rewrite func(dest string) string) (string, error) {
+	source := []byte(content)
+	doc := r.Parse(source)
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if image, ok := n.(*ast.Image); ok {
+			image.Destination = []byte(rewrite(string(image.Destination)))
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Parse parses content into a goldmark AST using the same extensions as Render.
+func (r *Renderer) Parse(source []byte) ast.Node {
+	return r.md.Parser().Parse(text.NewReader(source))
+}
+
+// HeadingIDs returns the id attributes that Render assigns to headings, in
+// document order.
+func (r *Renderer) HeadingIDs(content string) []string {
+	var ids []string
+	doc := r.Parse([]byte(content))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Kind() != ast.KindHeading {
+			return ast.WalkContinue, nil
+		}
+		if id, ok := n.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				ids = append(ids, string(b))
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return ids
+}
+
 func (r *Renderer) ExtractTOC(content string) []TOCItem {
 	var items []TOCItem
 	headingRegex := regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
@@ -108,38 +150,6 @@ func generateID(title string) string {
 	return id
 }
 
-func (r *Renderer) GetStats(content string) Stats {
-	lines := strings.Split(content, "\n")
-	lineCount := len(lines)
-
-	characters := len([]rune(content))
-
-	words := 0
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		words += len(fields)
-	}
-
-	paragraphs := 0
-	inParagraph := false
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			inParagraph = false
-		} else if !inParagraph {
-			paragraphs++
-			inParagraph = true
-		}
-	}
-
-	return Stats{
-		Words:      words,
-		Characters: characters,
-		Lines:      lineCount,
-		Paragraphs: paragraphs,
-	}
-}
-
 func (r *Renderer) Search(content, query string) []SearchResult {
 	if query == "" {
 		return nil
@@ -0,0 +1,49 @@
+package markdown
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// sanitizePolicy is the allowlist applied to documents that are not in a
+// trusted folder. It starts from bluemonday's user-generated content policy
+// and adds what the renderer itself emits: heading ids, task list
+// checkboxes, footnote classes and the inline styles of highlighted code.
+var sanitizePolicy = newSanitizePolicy()
+
+func newSanitizePolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[\w\- ]+$`)).Globally()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_\-:.]+$`)).Globally()
+	p.AllowStyles("color", "background-color", "font-weight", "font-style", "text-decoration").
+		OnElements("span", "pre", "code")
+	p.AllowAttrs("style").OnElements("span", "pre", "code")
+
+	p.AllowElements("input")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	p.AllowElements("kbd", "mark", "details", "summary")
+	p.AllowAttrs("open").OnElements("details")
+	p.AllowAttrs("align").OnElements("th", "td", "p", "div", "img")
+	p.AllowDataURIImages()
+	return p
+}
+
+// Sanitize removes scripts, event handlers, frames and any other markup
+// outside the allowlist from rendered HTML.
+func Sanitize(html string) string {
+	return sanitizePolicy.Sanitize(html)
+}
+
+// RenderSafe renders content and sanitizes the result. Use it for documents
+// from folders the user has not trusted.
+func (r *Renderer) RenderSafe(content string) (string, error) {
+	html, err := r.Render(content)
+	if err != nil {
+		return "", err
+	}
+	return Sanitize(html), nil
+}
@@ -0,0 +1,105 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Slide is one slide of a presentation, still as Markdown.
+type Slide struct {
+	Markdown string `json:"markdown"`
+	// Notes are the speaker notes, written after a "Note:" line.
+	Notes string `json:"notes"`
+	// Settings are the document's front matter with the slide's own
+	// <!-- key: value --> directives applied over it.
+	Settings map[string]string `json:"settings"`
+}
+
+var (
+	slideDirectiveRegex = regexp.MustCompile(`^<!--\s*([A-Za-z][\w-]*)\s*:\s*(.*?)\s*-->$`)
+	slideNoteRegex      = regexp.MustCompile(`^(?i:notes?):\s*(.*)$`)
+	slideHeadingRegex   = regexp.MustCompile(`^(#{1,6})\s`)
+)
+
+// SplitSlides turns a document into slides. A "---" line after a blank
+// line separates slides, and so do headings up to the front matter's
+// slide-level. Without either, slides start at every H1 and H2.
+func SplitSlides(content string) (FrontMatter, []Slide) {
+	fm, body := SplitFrontMatter([]byte(content))
+	lines := splitLines(body)
+	inCode := codeLines(lines, scanFences(lines))
+
+	level, _ := strconv.Atoi(fm.Fields["slide-level"])
+	if _, set := fm.Fields["slide-level"]; !set && !hasSlideRule(lines, inCode) {
+		level = 2
+	}
+
+	var slides []Slide
+	var text, notes []string
+	settings := map[string]string{}
+	inNotes := false
+	flush := func() {
+		md := strings.TrimSpace(strings.Join(text, "\n"))
+		if md != "" || len(settings) > 0 {
+			merged := make(map[string]string, len(fm.Fields)+len(settings))
+			for k, v := range fm.Fields {
+				merged[k] = v
+			}
+			for k, v := range settings {
+				merged[k] = v
+			}
+			slides = append(slides, Slide{
+				Markdown: md,
+				Notes:    strings.TrimSpace(strings.Join(notes, "\n")),
+				Settings: merged,
+			})
+		}
+		text, notes, settings, inNotes = nil, nil, map[string]string{}, false
+	}
+
+	for i, line := range lines {
+		if !inCode[i] {
+			trimmed := strings.TrimSpace(line)
+			if isSlideRule(lines, i) {
+				flush()
+				continue
+			}
+			if m := slideHeadingRegex.FindStringSubmatch(line); m != nil && len(m[1]) <= level &&
+				(inNotes || strings.TrimSpace(strings.Join(text, "")) != "") {
+				flush()
+			}
+			if m := slideDirectiveRegex.FindStringSubmatch(trimmed); m != nil {
+				settings[strings.ToLower(m[1])] = m[2]
+				continue
+			}
+			if m := slideNoteRegex.FindStringSubmatch(trimmed); m != nil && !inNotes {
+				inNotes = true
+				notes = append(notes, m[1])
+				continue
+			}
+		}
+		if inNotes {
+			notes = append(notes, line)
+		} else {
+			text = append(text, line)
+		}
+	}
+	flush()
+	return fm, slides
+}
+
+// isSlideRule reports whether line i is a "---" after a blank line, which
+// separates slides rather than underlining a heading.
+func isSlideRule(lines []string, i int) bool {
+	return strings.TrimSpace(lines[i]) == "---" && (i == 0 || strings.TrimSpace(lines[i-1]) == "")
+}
+
+func hasSlideRule(lines []string, inCode []bool) bool {
+	for i := range lines {
+		if !inCode[i] && isSlideRule(lines, i) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package markdown
+
+import (
+	"html"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	readingWordsPerMinute  = 200
+	readingCJKPerMinute    = 500
+	speakingWordsPerMinute = 130
+	speakingCJKPerMinute   = 250
+)
+
+type Stats struct {
+	Words              int `json:"words"`
+	CJKCharacters      int `json:"cjkCharacters"`
+	Characters         int `json:"characters"`
+	CharactersNoSpaces int `json:"charactersNoSpaces"`
+	Lines              int `json:"lines"`
+	Paragraphs         int `json:"paragraphs"`
+	Sentences          int `json:"sentences"`
+	Headings           int `json:"headings"`
+	Links              int `json:"links"`
+	Images             int `json:"images"`
+	CodeBlocks         int `json:"codeBlocks"`
+	ReadingTime        int `json:"readingTime"`  // seconds
+	SpeakingTime       int `json:"speakingTime"` // seconds
+}
+
+type StatsOptions struct {
+	// IncludeCode counts the contents of code blocks and code spans as words.
+	IncludeCode bool `json:"includeCode"`
+}
+
+func (r *Renderer) GetStats(content string) Stats {
+	return r.GetStatsWithOptions(content, StatsOptions{})
+}
+
+// GetStatsWithOptions computes statistics from the parsed document so that
+// Markdown syntax, URLs and raw HTML are not counted as prose.
+func (r *Renderer) GetStatsWithOptions(content string, opts StatsOptions) Stats {
+	source := []byte(content)
+	doc := r.Parse(source)
+
+	stats := Stats{
+		Lines: len(strings.Split(content, "\n")),
+	}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Heading:
+			stats.Headings++
+			stats.addProse(PlainText(node, source, opts.IncludeCode))
+			countInlines(node, &stats)
+			return ast.WalkSkipChildren, nil
+		case *ast.Paragraph:
+			stats.Paragraphs++
+			stats.addProse(PlainText(node, source, opts.IncludeCode))
+			countInlines(node, &stats)
+			return ast.WalkSkipChildren, nil
+		case *ast.TextBlock, *east.TableCell:
+			stats.addProse(PlainText(node, source, opts.IncludeCode))
+			countInlines(node, &stats)
+			return ast.WalkSkipChildren, nil
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			stats.CodeBlocks++
+			if opts.IncludeCode {
+				stats.addCode(string(linesValue(node, source)))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	latinWords := stats.Words - stats.CJKCharacters
+	stats.ReadingTime = durationSeconds(latinWords, readingWordsPerMinute, stats.CJKCharacters, readingCJKPerMinute)
+	stats.SpeakingTime = durationSeconds(latinWords, speakingWordsPerMinute, stats.CJKCharacters, speakingCJKPerMinute)
+
+	return stats
+}
+
+// addProse adds the words, characters and sentences of one block of prose.
+func (s *Stats) addProse(prose string) {
+	words, cjk := countWords(prose)
+	s.Words += words
+	s.CJKCharacters += cjk
+	s.Sentences += countSentences(prose)
+	s.addCharacters(prose)
+}
+
+// addCode adds code block contents, which have words but no sentences.
+func (s *Stats) addCode(code string) {
+	words, cjk := countWords(code)
+	s.Words += words
+	s.CJKCharacters += cjk
+	s.addCharacters(code)
+}
+
+func (s *Stats) addCharacters(str string) {
+	for _, r := range str {
+		if r == '\n' {
+			continue
+		}
+		s.Characters++
+		if !unicode.IsSpace(r) {
+			s.CharactersNoSpaces++
+		}
+	}
+}
+
+// countInlines counts the links and images inside a block.
+func countInlines(block ast.Node, stats *Stats) {
+	ast.Walk(block, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *ast.Link, *ast.AutoLink:
+			stats.Links++
+		case *ast.Image:
+			stats.Images++
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// PlainText returns the text a reader would see for the inline children of
+// a block: markup, URLs, raw HTML and image alt text are dropped, and code
+// spans are kept only when includeCode is set.
+func PlainText(block ast.Node, source []byte, includeCode bool) string {
+	var sb strings.Builder
+
+	ast.Walk(block, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n == block {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Text:
+			sb.Write(node.Segment.Value(source))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.WriteString(html.UnescapeString(string(node.Value)))
+		case *ast.CodeSpan:
+			if includeCode {
+				for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+					if t, ok := c.(*ast.Text); ok {
+						sb.Write(t.Segment.Value(source))
+					}
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.Image, *ast.AutoLink, *ast.RawHTML, *east.FootnoteLink, *east.TaskCheckBox:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return sb.String()
+}
+
+func linesValue(n ast.Node, source []byte) []byte {
+	var buf []byte
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		buf = append(buf, line.Value(source)...)
+	}
+	return buf
+}
+
+// isCJK reports whether r is a Chinese or Japanese character. These scripts
+// do not separate words with spaces, so each character counts as a word.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// countWords returns the number of words in s and how many of them are CJK
+// characters.
+func countWords(s string) (words, cjk int) {
+	inWord := false
+	var prev rune
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			words++
+			cjk++
+			inWord = false
+		case isWordRune(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case inWord && (r == '\'' || r == '’' || r == '-' || r == '_') && prev != r:
+			// Joiners keep contractions and compounds as a single word.
+		default:
+			inWord = false
+		}
+		prev = r
+	}
+	return words, cjk
+}
+
+// countSentences counts sentence terminators in a block of prose. A block
+// that ends without a terminator, such as a heading, still counts as one.
+func countSentences(s string) int {
+	sentences := 0
+	hasContent := false
+	runes := []rune(s)
+	for i, r := range runes {
+		switch r {
+		case '.', '!', '?':
+			// Skip decimals and dotted names such as "3.14" or "example.com".
+			if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && !unicode.IsPunct(runes[i+1]) {
+				continue
+			}
+			if hasContent {
+				sentences++
+				hasContent = false
+			}
+		case '。', '！', '？':
+			if hasContent {
+				sentences++
+				hasContent = false
+			}
+		default:
+			if isWordRune(r) {
+				hasContent = true
+			}
+		}
+	}
+	if hasContent {
+		sentences++
+	}
+	return sentences
+}
+
+func durationSeconds(words, wordsPerMinute, cjk, cjkPerMinute int) int {
+	minutes := float64(words)/float64(wordsPerMinute) + float64(cjk)/float64(cjkPerMinute)
+	return int(math.Ceil(minutes * 60))
+}
@@ -0,0 +1,384 @@
+package markdown
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// Table operations for EditTable.
+const (
+	TableSort         = "sort"
+	TableInsertLeft   = "insertLeft"
+	TableInsertRight  = "insertRight"
+	TableDeleteColumn = "deleteColumn"
+	TableTranspose    = "transpose"
+)
+
+// TableEdit is an operation on the table column under the cursor.
+type TableEdit struct {
+	Op         string `json:"op"`
+	Descending bool   `json:"descending"` // for TableSort
+}
+
+// sourceTable is a pipe table as written in the source. Every row has one
+// cell per column.
+type sourceTable struct {
+	firstLine, lastLine int
+	prefix              string
+	alignments          []east.Alignment
+	rows                [][]string
+}
+
+// readTable collects the cells of table. It fails when rewriting the table
+// would lose text: when a row has cells past the delimiter row, or the rows
+// do not share one container prefix. The table's lines are still returned
+// with those errors.
+func readTable(table *east.Table, source []byte, index *LineIndex, lines []string) (*sourceTable, error) {
+	t := &sourceTable{alignments: table.Alignments}
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		cells := make([]string, len(t.alignments))
+		i := 0
+		for cell := row.FirstChild(); cell != nil && i < len(cells); cell = cell.NextSibling() {
+			if cell.Lines().Len() > 0 {
+				seg := cell.Lines().At(0)
+				cells[i] = string(seg.Value(source))
+				// Each row is a single line, below the header and the
+				// delimiter row.
+				if t.firstLine == 0 {
+					t.firstLine = index.Position(seg.Start).Line - len(t.rows)
+					if len(t.rows) > 0 {
+						t.firstLine--
+					}
+				}
+			}
+			i++
+		}
+		t.rows = append(t.rows, cells)
+	}
+	if t.firstLine == 0 {
+		return nil, errors.New("table has no text")
+	}
+	t.lastLine = t.firstLine + len(t.rows)
+	if hasExtraCells(table, source) {
+		return t, errors.New("a row has more cells than the header")
+	}
+
+	t.prefix = containerPrefixRegex.FindString(lines[t.firstLine-1])
+	for line := t.firstLine; line <= t.lastLine; line++ {
+		if containerPrefixRegex.FindString(lines[line-1]) != t.prefix {
+			return t, errors.New("table rows are indented differently")
+		}
+	}
+	return t, nil
+}
+
+// tableAt finds the table covering the 1-based line, or the first table
+// when line is 0.
+func (r *Renderer) tableAt(source []byte, line int) (*sourceTable, error) {
+	index := NewLineIndex(source)
+	lines := splitLines(source)
+	var found *sourceTable
+	var err error
+	ast.Walk(r.Parse(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		table, ok := n.(*east.Table)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		t, readErr := readTable(table, source, index, lines)
+		if t == nil || line > 0 && (line < t.firstLine || line > t.lastLine) {
+			return ast.WalkSkipChildren, nil
+		}
+		if readErr != nil {
+			if line == 0 {
+				return ast.WalkSkipChildren, nil
+			}
+			err = readErr
+		}
+		found = t
+		return ast.WalkStop, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found != nil {
+		return found, nil
+	}
+	if line == 0 {
+		return nil, errors.New("the document has no tables")
+	}
+	return nil, fmt.Errorf("no table on line %d", line)
+}
+
+// TableToCSV returns the table covering the 1-based line as CSV, or the
+// first table when line is 0. A zero delimiter means a comma.
+func (r *Renderer) TableToCSV(content string, line int, delimiter rune) (string, error) {
+	t, err := r.tableAt([]byte(content), line)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if delimiter != 0 {
+		w.Comma = delimiter
+	}
+	for _, row := range t.rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = csvCell(cell)
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return sb.String(), w.Error()
+}
+
+var breakTagRegex = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// csvCell undoes the escaping that cellText adds.
+func csvCell(cell string) string {
+	cell = strings.ReplaceAll(cell, `\|`, "|")
+	return breakTagRegex.ReplaceAllString(cell, "\n")
+}
+
+// cellText makes a CSV field fit in a table cell.
+func cellText(field string) string {
+	field = strings.TrimSpace(strings.ReplaceAll(field, "\r\n", "\n"))
+	field = strings.ReplaceAll(field, "|", `\|`)
+	return strings.ReplaceAll(field, "\n", "<br>")
+}
+
+// CSVToTable converts CSV or TSV text into an aligned pipe table whose
+// first record is the header. A zero delimiter is detected from the first
+// line. Columns holding only numbers are right-aligned.
+func CSVToTable(text string, delimiter rune) (string, error) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	if delimiter == 0 {
+		delimiter = detectDelimiter(text)
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = delimiter != '\t'
+
+	var rows [][]string
+	columns := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		row := make([]string, len(record))
+		for i, field := range record {
+			row[i] = cellText(field)
+		}
+		rows = append(rows, row)
+		columns = max(columns, len(row))
+	}
+	if len(rows) == 0 {
+		return "", errors.New("no data to convert")
+	}
+	for i, row := range rows {
+		rows[i] = append(row, make([]string, columns-len(row))...)
+	}
+	return renderTable("", numericAlignments(rows), rows), nil
+}
+
+// detectDelimiter picks the tab, semicolon or comma that splits the first
+// line most often, ignoring quoted text.
+func detectDelimiter(text string) rune {
+	line, _, _ := strings.Cut(text, "\n")
+	counts := map[rune]int{}
+	quoted := false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == '\t' || c == ';' || c == ','):
+			counts[c]++
+		}
+	}
+	best := ','
+	for _, c := range []rune{'\t', ';'} {
+		if counts[c] > counts[best] {
+			best = c
+		}
+	}
+	return best
+}
+
+var numberRegex = regexp.MustCompile(`^([-+]?)[$€£¥]?((?:\d{1,3}(?:,\d{3})+|\d+)?(?:\.\d+)?(?:[eE][-+]?\d+)?)%?$`)
+
+// parseNumber reads a cell as a number, allowing a currency sign, thousands
+// separators and a percent sign.
+func parseNumber(cell string) (float64, bool) {
+	m := numberRegex.FindStringSubmatch(strings.TrimSpace(cell))
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(m[1]+strings.ReplaceAll(m[2], ",", ""), 64)
+	return v, err == nil
+}
+
+// numericAlignments right-aligns the columns whose body cells are all
+// numbers, ignoring empty cells.
+func numericAlignments(rows [][]string) []east.Alignment {
+	alignments := make([]east.Alignment, len(rows[0]))
+	for col := range alignments {
+		numeric := false
+		for _, row := range rows[1:] {
+			if strings.TrimSpace(row[col]) == "" {
+				continue
+			}
+			if _, ok := parseNumber(row[col]); !ok {
+				numeric = false
+				break
+			}
+			numeric = true
+		}
+		if numeric {
+			alignments[col] = east.AlignRight
+		}
+	}
+	return alignments
+}
+
+// EditTable applies edit to the table at pos, acting on the column under
+// the cursor, and returns the rewritten document.
+func (r *Renderer) EditTable(content string, pos Position, edit TableEdit) (FormatResult, error) {
+	source := []byte(content)
+	t, err := r.tableAt(source, pos.Line)
+	if err != nil {
+		return FormatResult{}, err
+	}
+	lines := splitLines(source)
+	col := 0
+	if pos.Line > 0 {
+		col = min(cursorColumn(lines[pos.Line-1], t.prefix, pos.Column), len(t.alignments)-1)
+	}
+
+	switch edit.Op {
+	case TableSort:
+		sortRows(t.rows[1:], col, edit.Descending)
+	case TableInsertLeft, TableInsertRight:
+		at := col
+		if edit.Op == TableInsertRight {
+			at++
+		}
+		t.alignments = slices.Insert(slices.Clone(t.alignments), at, east.AlignNone)
+		for i, row := range t.rows {
+			t.rows[i] = slices.Insert(row, at, "")
+		}
+	case TableDeleteColumn:
+		if len(t.alignments) == 1 {
+			return FormatResult{}, errors.New("cannot delete the only column")
+		}
+		t.alignments = slices.Delete(slices.Clone(t.alignments), col, col+1)
+		for i, row := range t.rows {
+			t.rows[i] = slices.Delete(row, col, col+1)
+		}
+	case TableTranspose:
+		rows := make([][]string, len(t.alignments))
+		for i := range rows {
+			rows[i] = make([]string, len(t.rows))
+			for j, row := range t.rows {
+				rows[i][j] = row[i]
+			}
+		}
+		t.rows = rows
+		t.alignments = numericAlignments(rows)
+	default:
+		return FormatResult{}, fmt.Errorf("unknown table operation %q", edit.Op)
+	}
+
+	index := NewLineIndex(source)
+	start := index.LineStart(t.firstLine)
+	stop := index.LineStart(t.lastLine) + len(lines[t.lastLine-1])
+	text := content[:start] + renderTable(t.prefix, t.alignments, t.rows) + content[stop:]
+	return FormatResult{
+		Text:    text,
+		Edits:   diffEdits(content, text),
+		Changed: text != content,
+	}, nil
+}
+
+// cursorColumn returns the 0-based table column at the 1-based character
+// column of a table row, counting the unescaped pipes before it.
+func cursorColumn(line, prefix string, column int) int {
+	runes := []rune(line)
+	text := string(runes[:max(0, min(column-1, len(runes)))])
+	if len(text) <= len(prefix) {
+		return 0
+	}
+	text = strings.TrimLeft(text[len(prefix):], " \t")
+	text = strings.TrimPrefix(text, "|")
+	col := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '|':
+			col++
+		}
+	}
+	return col
+}
+
+// sortRows sorts table rows by one column. Numbers sort by value and
+// before text, which sorts without regard to case; empty cells always
+// come last.
+func sortRows(rows [][]string, col int, descending bool) {
+	slices.SortStableFunc(rows, func(a, b []string) int {
+		x, y := strings.TrimSpace(a[col]), strings.TrimSpace(b[col])
+		if x == "" || y == "" {
+			return boolCompare(x == "", y == "")
+		}
+		c := compareCells(x, y)
+		if descending {
+			return -c
+		}
+		return c
+	})
+}
+
+func compareCells(x, y string) int {
+	xv, xNum := parseNumber(x)
+	yv, yNum := parseNumber(y)
+	switch {
+	case xNum && yNum:
+		if xv < yv {
+			return -1
+		} else if xv > yv {
+			return 1
+		}
+		return 0
+	case xNum != yNum:
+		return boolCompare(yNum, xNum)
+	}
+	return strings.Compare(strings.ToLower(x), strings.ToLower(y))
+}
+
+// boolCompare orders false before true.
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
@@ -8,20 +8,54 @@ import (
 )
 
 type UserSettings struct {
-	Theme           string  `json:"theme"`
-	FontSize        int     `json:"fontSize"`
-	FontFamily      string  `json:"fontFamily"`
-	LineHeight      float64 `json:"lineHeight"`
-	EditorTheme     string  `json:"editorTheme"`
-	PreviewTheme    string  `json:"previewTheme"`
-	AutoSave        bool    `json:"autoSave"`
-	AutoSaveDelay   int     `json:"autoSaveDelay"`
-	AutoReload      bool    `json:"autoReload"`
-	SyncScroll      bool    `json:"syncScroll"`
-	ShowLineNumbers bool    `json:"showLineNumbers"`
-	WordWrap        bool    `json:"wordWrap"`
-	SpellCheck      bool    `json:"spellCheck"`
-	OpenInNewTab    bool    `json:"openInNewTab"`
+	Theme               string            `json:"theme"`
+	FontSize            int               `json:"fontSize"`
+	FontFamily          string            `json:"fontFamily"`
+	LineHeight          float64           `json:"lineHeight"`
+	EditorTheme         string            `json:"editorTheme"`
+	PreviewTheme        string            `json:"previewTheme"`
+	AutoSave            bool              `json:"autoSave"`
+	AutoSaveDelay       int               `json:"autoSaveDelay"`
+	AutoReload          bool              `json:"autoReload"`
+	SyncScroll          bool              `json:"syncScroll"`
+	ShowLineNumbers     bool              `json:"showLineNumbers"`
+	WordWrap            bool              `json:"wordWrap"`
+	SpellCheck          bool              `json:"spellCheck"`
+	OpenInNewTab        bool              `json:"openInNewTab"`
+	StatsIncludeCode    bool              `json:"statsIncludeCode"`
+	FormatOnSave        bool              `json:"formatOnSave"`
+	FormatProseWrap     string            `json:"formatProseWrap"`
+	FormatLineWidth     int               `json:"formatLineWidth"`
+	CheckExternalLinks  bool              `json:"checkExternalLinks"`
+	ExportMaxImageWidth int               `json:"exportMaxImageWidth"`
+	DocxReference       string            `json:"docxReference"`
+	EpubChapterLevel    *int              `json:"epubChapterLevel"`
+	LatexTemplate       string            `json:"latexTemplate"`
+	LatexMinted         bool              `json:"latexMinted"`
+	SiteTemplate        string            `json:"siteTemplate"`
+	ExportTemplate      string            `json:"exportTemplate"`
+	TemplateFields      map[string]string `json:"templateFields"`
+	PlainTextWidth      *int              `json:"plainTextWidth"`
+	CommonMarkTables    bool              `json:"commonMarkTables"`
+	CommonMarkTaskLists bool              `json:"commonMarkTaskLists"`
+	CommonMarkFootnotes bool              `json:"commonMarkFootnotes"`
+	PlainPaste          bool              `json:"plainPaste"`
+	PDF                 PDFSettings       `json:"pdf"`
+}
+
+// PDFSettings is the page setup for PDF export. Margins are in millimetres.
+type PDFSettings struct {
+	PaperSize       string  `json:"paperSize"`
+	Landscape       bool    `json:"landscape"`
+	MarginTop       float64 `json:"marginTop"`
+	MarginBottom    float64 `json:"marginBottom"`
+	MarginLeft      float64 `json:"marginLeft"`
+	MarginRight     float64 `json:"marginRight"`
+	Scale           float64 `json:"scale"`
+	PrintBackground bool    `json:"printBackground"`
+	HeaderFooter    bool    `json:"headerFooter"`
+	HeaderTemplate  string  `json:"headerTemplate"`
+	FooterTemplate  string  `json:"footerTemplate"`
 }
 
 type Settings struct {
@@ -37,23 +71,57 @@ func NewSettings() *Settings {
 
 func defaultSettings() UserSettings {
 	return UserSettings{
-		Theme:           "system",
-		FontSize:        14,
-		FontFamily:      "JetBrains Mono, Consolas, monospace",
-		LineHeight:      1.6,
-		EditorTheme:     "default",
-		PreviewTheme:    "github",
-		AutoSave:        true,
-		AutoSaveDelay:   3000,
-		AutoReload:      true,
-		SyncScroll:      true,
-		ShowLineNumbers: true,
-		WordWrap:        true,
-		SpellCheck:      false,
-		OpenInNewTab:    true,
+		Theme:               "system",
+		FontSize:            14,
+		FontFamily:          "JetBrains Mono, Consolas, monospace",
+		LineHeight:          1.6,
+		EditorTheme:         "default",
+		PreviewTheme:        "github",
+		AutoSave:            true,
+		AutoSaveDelay:       3000,
+		AutoReload:          true,
+		SyncScroll:          true,
+		ShowLineNumbers:     true,
+		WordWrap:            true,
+		SpellCheck:          false,
+		OpenInNewTab:        true,
+		StatsIncludeCode:    false,
+		FormatOnSave:        false,
+		FormatProseWrap:     "preserve",
+		FormatLineWidth:     80,
+		CheckExternalLinks:  false,
+		ExportMaxImageWidth: 0,
+		DocxReference:       "",
+		EpubChapterLevel:    intPtr(1),
+		LatexTemplate:       "",
+		LatexMinted:         false,
+		SiteTemplate:        "",
+		ExportTemplate:      "default",
+		TemplateFields:      map[string]string{},
+		PlainTextWidth:      intPtr(72),
+		CommonMarkTables:    false,
+		CommonMarkTaskLists: false,
+		CommonMarkFootnotes: false,
+		PlainPaste:          false,
+		PDF: PDFSettings{
+			PaperSize:       "A4",
+			MarginTop:       15,
+			MarginBottom:    15,
+			MarginLeft:      15,
+			MarginRight:     15,
+			Scale:           1,
+			PrintBackground: true,
+			HeaderFooter:    false,
+			HeaderTemplate:  "{title}",
+			FooterTemplate:  "Page {page} of {pages}",
+		},
 	}
 }
 
+func intPtr(n int) *int {
+	return &n
+}
+
 func (s *Settings) getConfigPath() string {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
@@ -104,7 +172,7 @@ func (s *Settings) Get() UserSettings {
 
 func (s *Settings) Update(newSettings UserSettings) error {
 	s.mu.Lock()
-	s.settings = newSettings
+	s.settings = mergeWithDefaults(newSettings)
 	s.mu.Unlock()
 
 	return s.Save()
@@ -134,6 +202,29 @@ func mergeWithDefaults(loaded UserSettings) UserSettings {
 	if loaded.AutoSaveDelay == 0 {
 		loaded.AutoSaveDelay = defaults.AutoSaveDelay
 	}
+	if loaded.FormatProseWrap == "" {
+		loaded.FormatProseWrap = defaults.FormatProseWrap
+	}
+	if loaded.FormatLineWidth == 0 {
+		loaded.FormatLineWidth = defaults.FormatLineWidth
+	}
+	if loaded.PDF.PaperSize == "" {
+		loaded.PDF = defaults.PDF
+	}
+	if loaded.ExportTemplate == "" {
+		loaded.ExportTemplate = defaults.ExportTemplate
+	}
+	if loaded.TemplateFields == nil {
+		loaded.TemplateFields = defaults.TemplateFields
+	}
+	// Zero is a valid choice for these, so only a missing value takes
+	// the default.
+	if loaded.EpubChapterLevel == nil {
+		loaded.EpubChapterLevel = defaults.EpubChapterLevel
+	}
+	if loaded.PlainTextWidth == nil {
+		loaded.PlainTextWidth = defaults.PlainTextWidth
+	}
 
 	return loaded
 }
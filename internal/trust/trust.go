@@ -0,0 +1,133 @@
+package trust
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store keeps the folders whose documents may run raw HTML unsanitized.
+// Everything else is rendered through the sanitizer.
+type Store struct {
+	folders []string
+	mu      sync.RWMutex
+}
+
+func NewStore() *Store {
+	s := &Store{
+		folders: make([]string, 0),
+	}
+	s.load()
+	return s
+}
+
+func (s *Store) Folders() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.folders...)
+}
+
+// IsTrusted reports whether path is inside a trusted folder. Unsaved
+// documents have no path and are never trusted.
+func (s *Store) IsTrusted(path string) bool {
+	if path == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, folder := range s.folders {
+		if isWithin(folder, abs) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) Trust(folder string) error {
+	abs, err := normalize(folder)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.folders {
+		if f == abs {
+			return nil
+		}
+	}
+	s.folders = append(s.folders, abs)
+	return s.save()
+}
+
+func (s *Store) Revoke(folder string) error {
+	abs, err := normalize(folder)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.folders {
+		if f == abs {
+			s.folders = append(s.folders[:i], s.folders[i+1:]...)
+			return s.save()
+		}
+	}
+	return nil
+}
+
+func normalize(folder string) (string, error) {
+	abs, err := filepath.Abs(folder)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	return filepath.Clean(abs), nil
+}
+
+func isWithin(folder, path string) bool {
+	rel, err := filepath.Rel(folder, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func (s *Store) getConfigPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	appDir := filepath.Join(configDir, "MarkViewPro")
+	os.MkdirAll(appDir, 0755)
+	return filepath.Join(appDir, "trusted.json")
+}
+
+func (s *Store) load() {
+	data, err := os.ReadFile(s.getConfigPath())
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &s.folders)
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.folders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.getConfigPath(), data, 0644)
+}
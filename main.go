@@ -42,7 +42,8 @@ func main() {
 		StartHidden:       false,
 		HideWindowOnClose: false,
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: app.localFiles,
 		},
 		BackgroundColour: &options.RGBA{R: 12, G: 12, B: 16, A: 1},
 		OnStartup:        app.startup,